@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	agentOutput   string
+	agentInterval time.Duration
+	agentOnChange string
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Keep the output file up to date, polling the store and rewriting it when values change",
+	Long: `Keep the output file up to date, polling the store and rewriting it when values change.
+
+The file is only rewritten when its contents would change, and the --on-change
+command is then run by the shell, e.g. to reload the application. Errors while
+polling are logged and the previous file is kept.`,
+	Example: `  ssmeb agent -i params.yaml -e production -o /etc/myapp/env.config --interval 1m --on-change "systemctl reload myapp"`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if agentInterval <= 0 {
+			return fmt.Errorf("The interval must be positive, and is %s", agentInterval)
+		}
+		printSettings("input", inputName(), "output", agentOutput, "environment", environment, "interval", agentInterval.String())
+		return runAgent(agentOutput, agentInterval, agentOnChange)
+	},
+}
+
+func init() {
+	agentCmd.Flags().StringVarP(&agentOutput, "output", "o", getEnv("SSMEB_OUTPUT", ""), "destination of the resulting elastic beanstalk data (required)")
+	agentCmd.Flags().DurationVar(&agentInterval, "interval", time.Minute, "interval between polls of the store")
+	agentCmd.Flags().StringVar(&agentOnChange, "on-change", "", "shell command run after the output file changes")
+	agentCmd.Flags().BoolVar(&sortOutput, "sort", false, "sort the options by name, so the output doesn't depend on the input order")
+	agentCmd.Flags().StringVar(&outputMode, "output-mode", getEnv("SSMEB_OUTPUT_MODE", ""), outputModeUsage)
+	rootCmd.AddCommand(agentCmd)
+}
+
+// runAgent regenerates output every interval, running onChange whenever its contents change
+func runAgent(output string, interval time.Duration, onChange string) error {
+	if output == "" {
+		return fmt.Errorf("Missing mandatory argument: `output`")
+	}
+
+	// the first poll must succeed, so configuration errors are reported right away
+	if err := pollOnce(output, onChange); err != nil {
+		return err
+	}
+	for range time.Tick(interval) {
+		if err := pollOnce(output, onChange); err != nil {
+			log.Printf("Error polling, keeping the previous `%s`: %v", output, err)
+		}
+	}
+	return nil
+}
+
+// pollOnce regenerates output, running onChange if its contents changed
+func pollOnce(output string, onChange string) error {
+	options, err := resolveOptions()
+	if err != nil {
+		return err
+	}
+	ebYaml, err := renderOutput(options)
+	if err != nil {
+		return err
+	}
+	mode, err := outputFileMode(options)
+	if err != nil {
+		return err
+	}
+
+	current, err := ioutil.ReadFile(output)
+	if err == nil && bytes.Equal(current, ebYaml) {
+		return nil
+	}
+	if err := writeToFile(output, ebYaml, mode); err != nil {
+		return fmt.Errorf("Error writing to file `%s`: %v", output, err)
+	}
+
+	if onChange == "" {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Running `%s`...\n", onChange)
+	hook := exec.Command("sh", "-c", onChange)
+	hook.Stdout = os.Stderr
+	hook.Stderr = os.Stderr
+	if err := hook.Run(); err != nil {
+		// the file was updated, so a failing hook shouldn't be retried with the same values
+		log.Printf("Error running `%s`: %v", onChange, err)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAgentRejectsInterval(t *testing.T) {
+	defer func(i time.Duration) { agentInterval = i }(agentInterval)
+	for _, interval := range []time.Duration{0, -time.Minute} {
+		agentInterval = interval
+		err := agentCmd.RunE(agentCmd, nil)
+		if err == nil || !strings.Contains(err.Error(), "must be positive") {
+			t.Errorf("agent --interval %s error = %v, want the interval rejected", interval, err)
+		}
+	}
+}
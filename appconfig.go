@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/private/protocol/restjson"
+	"github.com/codacy/ssmeb/pkg/render"
+	"github.com/spf13/cobra"
+)
+
+var (
+	appConfigFormat      string
+	appConfigDescription string
+	appConfigDeploy      string
+	appConfigStrategy    string
+)
+
+// appConfigContentTypes maps the formats of the published documents to their content type
+var appConfigContentTypes = map[string]string{"json": "application/json", "yaml": "application/x-yaml"}
+
+var publishAppConfigCmd = &cobra.Command{
+	Use:   "publish-appconfig <application-id> <configuration-profile-id>",
+	Short: "Publish the options as an AWS AppConfig hosted configuration version",
+	Long: `Publish the options as an AWS AppConfig hosted configuration version.
+
+The options are assembled into a JSON or yaml document mapping their names to
+their values, which is stored as a new version of the hosted configuration
+profile of the AppConfig application. With --deploy, the version is then
+deployed to the AppConfig environment with the given id, with the deployment
+strategy given in --deployment-strategy. Applications, profiles and
+environments are given by id, as shown by the AWS console and CLI.`,
+	Example: `  ssmeb publish-appconfig abc1234 def5678 -i params.yaml -e production
+  ssmeb publish-appconfig abc1234 def5678 -i params.yaml -e production --format yaml --deploy ghi9012`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("input", inputName(), "environment", environment, "application", args[0], "profile", args[1], "deploy", appConfigDeploy)
+		return runPublishAppConfig(args[0], args[1])
+	},
+}
+
+func init() {
+	publishAppConfigCmd.Flags().StringVar(&appConfigFormat, "format", "json", "format of the published document, `json` or `yaml`")
+	publishAppConfigCmd.Flags().StringVar(&appConfigDescription, "description", "", "description of the configuration version and of its deployment")
+	publishAppConfigCmd.Flags().StringVar(&appConfigDeploy, "deploy", "", "id of the AppConfig environment the version is deployed to after publishing it")
+	publishAppConfigCmd.Flags().StringVar(&appConfigStrategy, "deployment-strategy", "AppConfig.AllAtOnce", "id of the deployment strategy used with --deploy")
+	rootCmd.AddCommand(publishAppConfigCmd)
+}
+
+// runPublishAppConfig stores the options as a new version of the hosted configuration
+// profile, deploying it if requested
+func runPublishAppConfig(application string, profile string) error {
+	contentType, ok := appConfigContentTypes[appConfigFormat]
+	if !ok {
+		return fmt.Errorf("Invalid format `%s`, expected `json` or `yaml`", appConfigFormat)
+	}
+	options, err := resolveOptions()
+	if err != nil {
+		return err
+	}
+	content, err := render.Render(appConfigFormat, options)
+	if err != nil {
+		return fmt.Errorf("Error rendering %s document: %v", appConfigFormat, err)
+	}
+	if render.HasSecrets(options) {
+		fmt.Fprintln(os.Stderr, "Warning: secret options will be readable by anyone allowed to get the configuration from AppConfig")
+	}
+
+	c := newAppConfigClient(newSession())
+	fmt.Fprintf(os.Stderr, "* Publishing %d option(s) to configuration profile `%s` of `%s`... ", len(options), profile, application)
+	version := &hostedConfigurationVersionOutput{}
+	err = c.NewRequest(&request.Operation{
+		Name:       "CreateHostedConfigurationVersion",
+		HTTPMethod: "POST",
+		HTTPPath:   "/applications/{ApplicationId}/configurationprofiles/{ConfigurationProfileId}/hostedconfigurationversions",
+	}, &hostedConfigurationVersionInput{
+		ApplicationId:          aws.String(application),
+		ConfigurationProfileId: aws.String(profile),
+		Content:                content,
+		ContentType:            aws.String(contentType),
+		Description:            optionalString(appConfigDescription),
+	}, version).Send()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "FAILED")
+		return fmt.Errorf("Error publishing the configuration: %v", err)
+	}
+	fmt.Fprintln(os.Stderr, "OK")
+	fmt.Fprintf(os.Stderr, "Published version %d\n", aws.Int64Value(version.VersionNumber))
+	if appConfigDeploy == "" {
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "* Deploying version %d to environment `%s`... ", aws.Int64Value(version.VersionNumber), appConfigDeploy)
+	deployment := &startDeploymentOutput{}
+	err = c.NewRequest(&request.Operation{
+		Name:       "StartDeployment",
+		HTTPMethod: "POST",
+		HTTPPath:   "/applications/{ApplicationId}/environments/{EnvironmentId}/deployments",
+	}, &startDeploymentInput{
+		ApplicationId:          aws.String(application),
+		EnvironmentId:          aws.String(appConfigDeploy),
+		ConfigurationProfileId: aws.String(profile),
+		ConfigurationVersion:   aws.String(strconv.FormatInt(aws.Int64Value(version.VersionNumber), 10)),
+		DeploymentStrategyId:   aws.String(appConfigStrategy),
+		Description:            optionalString(appConfigDescription),
+	}, deployment).Send()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "FAILED")
+		return fmt.Errorf("Error starting the deployment: %v", err)
+	}
+	fmt.Fprintln(os.Stderr, "OK")
+	fmt.Fprintf(os.Stderr, "Deployment #%d is %s\n", aws.Int64Value(deployment.DeploymentNumber), aws.StringValue(deployment.State))
+	return nil
+}
+
+// optionalString returns a pointer to s, or nil if it's empty so it's left out of requests
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return aws.String(s)
+}
+
+// newAppConfigClient returns a client of the AppConfig REST API, which this version of
+// the SDK has no package for. Its operations are sent with the types below.
+func newAppConfigClient(s *session.Session) *client.Client {
+	config := s.ClientConfig("appconfig")
+	c := client.New(*config.Config, metadata.ClientInfo{
+		ServiceName:   "appconfig",
+		ServiceID:     "AppConfig",
+		SigningName:   "appconfig",
+		SigningRegion: config.SigningRegion,
+		Endpoint:      config.Endpoint,
+		APIVersion:    "2019-10-09",
+	}, config.Handlers)
+	c.Handlers.Sign.PushBackNamed(v4.SignRequestHandler)
+	c.Handlers.Build.PushBackNamed(restjson.BuildHandler)
+	c.Handlers.Unmarshal.PushBackNamed(restjson.UnmarshalHandler)
+	c.Handlers.UnmarshalMeta.PushBackNamed(restjson.UnmarshalMetaHandler)
+	c.Handlers.UnmarshalError.PushBackNamed(restjson.UnmarshalErrorHandler)
+	return c
+}
+
+// hostedConfigurationVersionInput is the input of CreateHostedConfigurationVersion
+type hostedConfigurationVersionInput struct {
+	_                      struct{} `type:"structure" payload:"Content"`
+	ApplicationId          *string  `location:"uri" locationName:"ApplicationId" type:"string"`
+	ConfigurationProfileId *string  `location:"uri" locationName:"ConfigurationProfileId" type:"string"`
+	Content                []byte   `type:"blob" sensitive:"true"`
+	ContentType            *string  `location:"header" locationName:"Content-Type" type:"string"`
+	Description            *string  `location:"header" locationName:"Description" type:"string"`
+}
+
+// hostedConfigurationVersionOutput is the part of the output of CreateHostedConfigurationVersion
+// used, the content being the one published
+type hostedConfigurationVersionOutput struct {
+	_             struct{} `type:"structure" payload:"Content"`
+	Content       []byte   `type:"blob" sensitive:"true"`
+	VersionNumber *int64   `location:"header" locationName:"Version-Number" type:"integer"`
+}
+
+// startDeploymentInput is the input of StartDeployment
+type startDeploymentInput struct {
+	_                      struct{} `type:"structure"`
+	ApplicationId          *string  `location:"uri" locationName:"ApplicationId" type:"string"`
+	EnvironmentId          *string  `location:"uri" locationName:"EnvironmentId" type:"string"`
+	ConfigurationProfileId *string  `type:"string"`
+	ConfigurationVersion   *string  `type:"string"`
+	DeploymentStrategyId   *string  `type:"string"`
+	Description            *string  `type:"string"`
+}
+
+// startDeploymentOutput is the part of the output of StartDeployment used
+type startDeploymentOutput struct {
+	_                struct{} `type:"structure"`
+	DeploymentNumber *int64   `type:"integer"`
+	State            *string  `type:"string"`
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/codacy/ssmeb/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+var auditSince string
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show who changed the parameters and when, from the CloudTrail events",
+	Long: `Show who changed the parameters and when, from the CloudTrail events.
+
+The management events of the SSM parameters in the environment recorded by
+CloudTrail are listed, oldest first. CloudTrail keeps them for 90 days.`,
+	Example: `  ssmeb audit -i params.yaml -e production --since 7d`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("input", inputName(), "environment", environment, "since", auditSince)
+		return runAudit(auditSince)
+	},
+}
+
+func init() {
+	auditCmd.Flags().StringVar(&auditSince, "since", "7d", "how far back to look, in days like 7d or as a duration like 12h")
+	rootCmd.AddCommand(auditCmd)
+}
+
+// auditedEvents are the names of the CloudTrail events changing SSM parameters
+var auditedEvents = []string{"PutParameter", "DeleteParameter", "DeleteParameters", "LabelParameterVersion"}
+
+// auditEntry is a change to a parameter recorded by CloudTrail
+type auditEntry struct {
+	Time  time.Time
+	Event string
+	Path  string
+	User  string
+}
+
+// runAudit prints the changes to the parameters in the input recorded by CloudTrail
+// in the given period
+func runAudit(since string) error {
+	period, err := parseSince(since)
+	if err != nil {
+		return err
+	}
+	parameters, err := loadParameters()
+	if err != nil {
+		return err
+	}
+	parameters, err = expandPlaceholders(parameters)
+	if err != nil {
+		return err
+	}
+
+	// paths at the root may be written with or without the leading slash
+	exact := map[string]bool{}
+	var prefixes []string
+	for _, par := range parameters.All() {
+		if scheme, _ := store.SplitScheme(par.Path); scheme != "" {
+			continue
+		}
+		if par.IsWildcard() {
+			prefixes = append(prefixes, strings.TrimPrefix(par.WildcardPrefix(), "/")+"/")
+			continue
+		}
+		exact[strings.TrimPrefix(par.Path, "/")] = true
+	}
+	audited := func(path string) bool {
+		path = strings.TrimPrefix(path, "/")
+		if exact[path] {
+			return true
+		}
+		for _, prefix := range prefixes {
+			if leaf := strings.TrimPrefix(path, prefix); leaf != path && !strings.Contains(leaf, "/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	client := cloudtrail.New(newSession())
+	start := time.Now().Add(-period)
+	var entries []auditEntry
+	for _, name := range auditedEvents {
+		input := &cloudtrail.LookupEventsInput{
+			StartTime: aws.Time(start),
+			LookupAttributes: []*cloudtrail.LookupAttribute{{
+				AttributeKey:   aws.String(cloudtrail.LookupAttributeKeyEventName),
+				AttributeValue: aws.String(name),
+			}},
+		}
+		err := client.LookupEventsPages(input, func(page *cloudtrail.LookupEventsOutput, lastPage bool) bool {
+			for _, event := range page.Events {
+				for _, entry := range auditEntries(event) {
+					if audited(entry.Path) {
+						entries = append(entries, entry)
+					}
+				}
+			}
+			return true
+		})
+		if err != nil {
+			return fmt.Errorf("Error looking up `%s` events: %v", name, err)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tEVENT\tPATH\tUSER")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.Time.UTC().Format(time.RFC3339), entry.Event, entry.Path, entry.User)
+	}
+	return w.Flush()
+}
+
+// auditEntries returns an entry for each parameter changed in the event
+func auditEntries(event *cloudtrail.Event) []auditEntry {
+	var details struct {
+		UserIdentity struct {
+			Arn string `json:"arn"`
+		} `json:"userIdentity"`
+		RequestParameters struct {
+			Name  string   `json:"name"`
+			Names []string `json:"names"`
+		} `json:"requestParameters"`
+	}
+	if err := json.Unmarshal([]byte(aws.StringValue(event.CloudTrailEvent)), &details); err != nil {
+		return nil
+	}
+	user := details.UserIdentity.Arn
+	if user == "" {
+		user = aws.StringValue(event.Username)
+	}
+
+	var entries []auditEntry
+	for _, name := range append(details.RequestParameters.Names, details.RequestParameters.Name) {
+		if name == "" {
+			continue
+		}
+		// parameters can also be named by ARN
+		if i := strings.Index(name, ":parameter/"); strings.HasPrefix(name, "arn:") && i >= 0 {
+			name = "/" + name[i+len(":parameter/"):]
+		}
+		entries = append(entries, auditEntry{
+			Time:  aws.TimeValue(event.EventTime),
+			Event: aws.StringValue(event.EventName),
+			Path:  name,
+			User:  user,
+		})
+	}
+	return entries
+}
+
+// parseSince parses a period like `7d`, in days, or a duration like `12h`
+func parseSince(since string) (time.Duration, error) {
+	if strings.HasSuffix(since, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(since, "d"))
+		if err == nil && days > 0 {
+			return time.Duration(days) * 24 * time.Hour, nil
+		}
+	} else if period, err := time.ParseDuration(since); err == nil && period > 0 {
+		return period, nil
+	}
+	return 0, fmt.Errorf("Invalid period `%s`, expected days like 7d or a duration like 12h", since)
+}
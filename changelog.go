@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/codacy/ssmeb/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	changelogSince  string
+	changelogValues bool
+)
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Print the history of the component parameters in SSM as a Markdown changelog",
+	Long: `Print the history of the component parameters in SSM as a Markdown changelog.
+
+Each version of the component parameters in the environment is listed under
+the day it was written and the user who wrote it, newest first. The values are
+left out, unless --values shows the ones of String parameters. Wildcard paths
+and parameters in other stores are skipped.`,
+	Example: `  ssmeb changelog -i params.yaml -e production --since 14d >> RELEASE_NOTES.md`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("input", inputName(), "environment", environment, "since", changelogSince)
+		return runChangelog()
+	},
+}
+
+func init() {
+	changelogCmd.Flags().StringVar(&changelogSince, "since", "", "only list the versions written in this period, in days like 7d or as a duration like 12h (defaults to all)")
+	changelogCmd.Flags().BoolVar(&changelogValues, "values", false, "show the values of the String parameters")
+	rootCmd.AddCommand(changelogCmd)
+}
+
+// changelogEntry is a version of a parameter
+type changelogEntry struct {
+	Time    time.Time
+	User    string
+	Path    string
+	Version int64
+	// Value is empty unless shown
+	Value string
+}
+
+// runChangelog prints the history of the component parameters in the input
+func runChangelog() error {
+	var start time.Time
+	if changelogSince != "" {
+		period, err := parseSince(changelogSince)
+		if err != nil {
+			return err
+		}
+		start = time.Now().Add(-period)
+	}
+	parameters, err := loadParameters()
+	if err != nil {
+		return err
+	}
+	parameters, err = expandPlaceholders(parameters)
+	if err != nil {
+		return err
+	}
+
+	client := ssm.New(newSession())
+	var entries []changelogEntry
+	for _, par := range parameters.Component {
+		if scheme, _ := store.SplitScheme(par.Path); scheme != "" || par.IsWildcard() {
+			continue
+		}
+		input := &ssm.GetParameterHistoryInput{Name: aws.String(par.Path)}
+		err := client.GetParameterHistoryPages(input, func(page *ssm.GetParameterHistoryOutput, lastPage bool) bool {
+			for _, version := range page.Parameters {
+				entry := changelogEntry{
+					Time:    aws.TimeValue(version.LastModifiedDate),
+					User:    aws.StringValue(version.LastModifiedUser),
+					Path:    par.Path,
+					Version: aws.Int64Value(version.Version),
+				}
+				if entry.Time.Before(start) {
+					continue
+				}
+				if changelogValues && aws.StringValue(version.Type) == ssm.ParameterTypeString {
+					entry.Value = aws.StringValue(version.Value)
+				}
+				entries = append(entries, entry)
+			}
+			return true
+		})
+		if err != nil {
+			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == ssm.ErrCodeParameterNotFound {
+				// not set yet
+				continue
+			}
+			return fmt.Errorf("Error getting the history of `%s`: %v", par.Path, err)
+		}
+	}
+
+	_, err = os.Stdout.Write(renderChangelog(entries))
+	return err
+}
+
+// renderChangelog renders the entries as Markdown, grouped by day and user, newest first
+func renderChangelog(entries []changelogEntry) []byte {
+	sort.SliceStable(entries, func(i, j int) bool {
+		dayI, dayJ := entries[i].Time.UTC().Format("2006-01-02"), entries[j].Time.UTC().Format("2006-01-02")
+		if dayI != dayJ {
+			return dayI > dayJ
+		}
+		if entries[i].User != entries[j].User {
+			return entries[i].User < entries[j].User
+		}
+		return entries[i].Time.After(entries[j].Time)
+	})
+
+	var out bytes.Buffer
+	out.WriteString("# Configuration changes\n")
+	if len(entries) == 0 {
+		out.WriteString("\nNo changes.\n")
+	}
+	var day, user string
+	for _, entry := range entries {
+		if entryDay := entry.Time.UTC().Format("2006-01-02"); entryDay != day {
+			day, user = entryDay, ""
+			fmt.Fprintf(&out, "\n## %s\n", day)
+		}
+		if entry.User != user {
+			user = entry.User
+			fmt.Fprintf(&out, "\n%s:\n\n", user)
+		}
+		fmt.Fprintf(&out, "- `%s` version %d", entry.Path, entry.Version)
+		if entry.Value != "" {
+			fmt.Fprintf(&out, ": `%s`", entry.Value)
+		}
+		fmt.Fprintf(&out, " (%s)\n", entry.Time.UTC().Format("15:04"))
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// clipboardCommands are the commands writing their stdin to the system clipboard, in
+// order of preference. The first one found in the PATH is used.
+var clipboardCommands = [][]string{
+	{"pbcopy"},
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+	{"clip.exe"},
+}
+
+// copyToClipboard puts the value on the system clipboard, with the first clipboard
+// command available
+func copyToClipboard(value string) error {
+	for _, command := range clipboardCommands {
+		path, err := exec.LookPath(command[0])
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, command[1:]...)
+		cmd.Stdin = strings.NewReader(value)
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("Error running `%s`: %v", command[0], err)
+		}
+		return nil
+	}
+	return fmt.Errorf("No clipboard command found, install one of pbcopy, wl-copy, xclip or xsel")
+}
@@ -0,0 +1,210 @@
+// Command ssmeb-lambda runs ssmeb as an AWS Lambda function. When invoked, it
+// resolves the parameters file and writes the resulting elastic beanstalk
+// options to S3, applies them to an elastic beanstalk environment, or both.
+//
+// It's configured through the same SSMEB_* environment variables as ssmeb,
+// which the fields of the invocation event override:
+//
+//	{"input": "s3://bucket/params.yaml", "environment": "staging", "component": "api",
+//	 "output": "s3://bucket/env_variables.config", "eb_environment": "my-env"}
+//
+// Build it for the provided.al2 runtime with:
+//
+//	GOOS=linux GOARCH=amd64 go build -tags lambda.norpc -o bootstrap ./cmd/ssmeb-lambda
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/elasticbeanstalk"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/render"
+	"github.com/codacy/ssmeb/pkg/resolver"
+	"github.com/codacy/ssmeb/pkg/s3io"
+	"github.com/codacy/ssmeb/pkg/stores"
+)
+
+// environmentNamespace is the elastic beanstalk namespace of the environment variables
+const environmentNamespace = "aws:elasticbeanstalk:application:environment"
+
+// Event is the invocation payload. Empty fields take the value of the matching
+// SSMEB_* environment variable.
+type Event struct {
+	// Input is the parameters file, either an s3:// url or a file bundled with the function
+	Input string `json:"input"`
+	// Environment is the name used as prefix for the ssm parameters
+	Environment string `json:"environment"`
+	// Component selects the component used from inputs with a components section
+	Component string `json:"component"`
+	// Backend is the store holding the values, like in the backend flag of ssmeb, which
+	// defaults to `ssm`
+	Backend string `json:"backend"`
+	// Output is the s3:// url where the elastic beanstalk options are written
+	Output string `json:"output"`
+	// OutputKMSKey is the KMS key encrypting the output, which defaults to SSE-S3
+	OutputKMSKey string `json:"output_kms_key"`
+	// EBEnvironment is the name of an elastic beanstalk environment to apply the options to
+	EBEnvironment string `json:"eb_environment"`
+}
+
+// Result is the response of an invocation
+type Result struct {
+	// Options is the number of options resolved
+	Options int `json:"options"`
+	// Output is where the options were written, if anywhere
+	Output string `json:"output,omitempty"`
+	// EBEnvironment is the environment the options were applied to, if any
+	EBEnvironment string `json:"eb_environment,omitempty"`
+}
+
+func main() {
+	lambda.Start(handle)
+}
+
+// handle resolves the parameters and writes or applies them as configured in event
+func handle(ctx context.Context, event Event) (Result, error) {
+	event = withDefaults(event)
+	if event.Input == "" {
+		return Result{}, fmt.Errorf("Missing mandatory argument: `input`")
+	}
+	if event.Output == "" && event.EBEnvironment == "" {
+		return Result{}, fmt.Errorf("Missing destination: set `output`, `eb_environment` or both")
+	}
+
+	session := session.Must(session.NewSession())
+
+	data, err := readInput(session, event.Input)
+	if err != nil {
+		return Result{}, fmt.Errorf("Error reading file `%s`: %v", event.Input, err)
+	}
+	parameters, err := loadParameters(data, event)
+	if err != nil {
+		return Result{}, err
+	}
+	parameters, err = stores.ExpandPlaceholders(session, parameters)
+	if err != nil {
+		return Result{}, err
+	}
+
+	s, err := stores.New(session, event.Backend, event.Environment)
+	if err != nil {
+		return Result{}, err
+	}
+	options, err := resolver.New(s).Options(parameters)
+	if err != nil {
+		return Result{}, fmt.Errorf("Error getting values: %v", err)
+	}
+	result := Result{Options: len(options)}
+
+	if event.Output != "" {
+		ebYaml, err := render.EBYAML(options)
+		if err != nil {
+			return result, fmt.Errorf("Error marshaling beanstalk options: %v", err)
+		}
+		if err := s3io.WriteWithKey(s3.New(session), event.Output, ebYaml, event.OutputKMSKey); err != nil {
+			return result, fmt.Errorf("Error writing to `%s`: %v", event.Output, err)
+		}
+		log.Printf("%d options written to `%s`", len(options), event.Output)
+		result.Output = event.Output
+	}
+
+	if event.EBEnvironment != "" {
+		if err := applyOptions(ctx, session, event.EBEnvironment, options); err != nil {
+			return result, fmt.Errorf("Error updating environment `%s`: %v", event.EBEnvironment, err)
+		}
+		log.Printf("%d options applied to environment `%s`", len(options), event.EBEnvironment)
+		result.EBEnvironment = event.EBEnvironment
+	}
+
+	return result, nil
+}
+
+// loadParameters parses the parameters file and selects the component and environment
+// of event, like ssmeb does with its input
+func loadParameters(data []byte, event Event) (config.Parameters, error) {
+	parameters, err := config.Parse(data)
+	if err != nil {
+		return parameters, fmt.Errorf("Error reading file `%s`: %v", event.Input, err)
+	}
+	if len(parameters.Include) > 0 {
+		return parameters, fmt.Errorf("Error reading file `%s`: include is not supported by the lambda function", event.Input)
+	}
+	switch {
+	case event.Component != "":
+		parameters, err = parameters.ForComponent(event.Component)
+		if err != nil {
+			return parameters, fmt.Errorf("Error selecting the component: %v", err)
+		}
+	case len(parameters.Components) > 0:
+		return parameters, fmt.Errorf("The input has components, select one of %s with `component`", strings.Join(parameters.ComponentNames(), ", "))
+	}
+	parameters = parameters.WithEnvironment(event.Environment)
+	if parameters.Uses(config.PlaceholderEnvironment) {
+		return parameters, fmt.Errorf("Missing mandatory argument: `environment`, used as placeholder in the input")
+	}
+	return parameters, nil
+}
+
+// withDefaults fills the empty fields of event from the SSMEB_* environment variables
+func withDefaults(event Event) Event {
+	if event.Input == "" {
+		event.Input = os.Getenv("SSMEB_INPUT")
+	}
+	if event.Environment == "" {
+		event.Environment = os.Getenv("SSMEB_ENVIRONMENT")
+	}
+	if event.Component == "" {
+		event.Component = os.Getenv("SSMEB_COMPONENT")
+	}
+	if event.Backend == "" {
+		event.Backend = os.Getenv("SSMEB_BACKEND")
+	}
+	if event.Backend == "" {
+		event.Backend = "ssm"
+	}
+	if event.Output == "" {
+		event.Output = os.Getenv("SSMEB_OUTPUT")
+	}
+	if event.OutputKMSKey == "" {
+		event.OutputKMSKey = os.Getenv("SSMEB_OUTPUT_KMS_KEY")
+	}
+	if event.EBEnvironment == "" {
+		event.EBEnvironment = os.Getenv("SSMEB_EB_ENVIRONMENT")
+	}
+	return event
+}
+
+// readInput reads the parameters file from S3 or from the function package
+func readInput(session *session.Session, input string) ([]byte, error) {
+	if s3io.IsURL(input) {
+		return s3io.Read(s3.New(session), input)
+	}
+	return ioutil.ReadFile(input)
+}
+
+// applyOptions sets the options as environment properties of an elastic beanstalk environment
+func applyOptions(ctx context.Context, session *session.Session, ebEnvironment string, options []render.Option) error {
+	var settings []*elasticbeanstalk.ConfigurationOptionSetting
+	for _, option := range options {
+		settings = append(settings, &elasticbeanstalk.ConfigurationOptionSetting{
+			Namespace:  aws.String(environmentNamespace),
+			OptionName: aws.String(option.Name),
+			Value:      aws.String(option.Value),
+		})
+	}
+
+	_, err := elasticbeanstalk.New(session).UpdateEnvironmentWithContext(ctx, &elasticbeanstalk.UpdateEnvironmentInput{
+		EnvironmentName: aws.String(ebEnvironment),
+		OptionSettings:  settings,
+	})
+	return err
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const componentsInput = `
+component:
+  - option_name: LOG_LEVEL
+    path: /shared/log-level
+components:
+  api:
+    component:
+      - option_name: PORT
+        path: /api/port
+    environments:
+      production:
+        parameters:
+          - option_name: PORT
+            path: /api/production-port
+  worker:
+    component:
+      - option_name: QUEUE
+        path: /worker/queue
+`
+
+func TestLoadParameters(t *testing.T) {
+	parameters, err := loadParameters([]byte(componentsInput), Event{Input: "params.yaml", Environment: "production", Component: "api"})
+	if err != nil {
+		t.Fatalf("loadParameters() error = %v", err)
+	}
+	var paths []string
+	for _, par := range parameters.Component {
+		paths = append(paths, par.Path)
+	}
+	want := []string{"/production/shared/log-level", "/production/api/production-port"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+}
+
+func TestLoadParametersWithoutComponent(t *testing.T) {
+	_, err := loadParameters([]byte(componentsInput), Event{Input: "params.yaml", Environment: "production"})
+	if err == nil || !strings.Contains(err.Error(), "api, worker") {
+		t.Errorf("loadParameters() error = %v, want one asking for the component", err)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/resolver"
+	"github.com/codacy/ssmeb/pkg/s3io"
+	"github.com/codacy/ssmeb/pkg/store"
+)
+
+// componentName selects the component used from inputs with a components section
+var componentName string
+
+// componentOutput is the output written for each component when none is given, relative
+// to the directory of the component
+const componentOutput = ".ebextensions/env_variables.config"
+
+// sharedValues remembers the parameters got while writing the outputs of several
+// components, by credentials and path, so the ones they share are only got once
+var sharedValues map[string]store.Parameter
+
+// sharedValuesStore gets the parameters remembered in sharedValues, and remembers the
+// ones it gets from the store it wraps
+type sharedValuesStore struct {
+	store.Store
+	credentials string
+}
+
+// Get returns the parameter in path, getting it from the wrapped store the first time
+func (s sharedValuesStore) Get(path string) (store.Parameter, error) {
+	key := s.credentials + "\x00" + path
+	if par, ok := sharedValues[key]; ok {
+		return par, nil
+	}
+	par, err := s.Store.Get(path)
+	if err == nil {
+		sharedValues[key] = par
+	}
+	return par, err
+}
+
+// withSharedValues wraps the store, got with the given credentials, so it shares the
+// values with the other components while they're being written
+func withSharedValues(s store.Store, credentials string) store.Store {
+	if sharedValues == nil {
+		return s
+	}
+	return sharedValuesStore{Store: s, credentials: credentials}
+}
+
+// readComponentParameters reads the input files like readParameters and, when they have
+// components, keeps the parameters of the one selected by the component flag
+func readComponentParameters() (config.Parameters, error) {
+	parameters, err := readParameters()
+	if err != nil {
+		return parameters, err
+	}
+	if componentName == "" {
+		if len(parameters.Components) > 0 {
+			return parameters, fmt.Errorf("The input has components, select one of %s with --component", strings.Join(parameters.ComponentNames(), ", "))
+		}
+		return parameters, nil
+	}
+	selected, err := parameters.ForComponent(componentName)
+	if err != nil {
+		return selected, fmt.Errorf("Error selecting the component: %v", err)
+	}
+	return selected, nil
+}
+
+// getComponents writes the outputs of every component of the input, with their paths
+// relative to a directory named after the component under dir, or to componentOutput if
+// they're the default one. The parameters shared by several components are got once.
+func getComponents(dir string, specs []outputSpec, defaultOutput bool, templateText string) error {
+	if getSum || getCheckSum {
+		return fmt.Errorf("--sum and --check-sum can't be used with --output-dir")
+	}
+	if defaultOutput {
+		specs[0].Path = componentOutput
+	}
+	for _, spec := range specs {
+		if spec.Path == "" || filepath.IsAbs(spec.Path) || s3io.IsURL(spec.Path) {
+			return fmt.Errorf("With --output-dir, every output needs a path relative to the directory of each component, e.g. `format=dotenv,path=.env`")
+		}
+	}
+	raw, err := readParameters()
+	if err != nil {
+		return err
+	}
+	names := raw.ComponentNames()
+	if len(names) == 0 {
+		return fmt.Errorf("--output-dir needs an input with a components section")
+	}
+
+	sharedValues = map[string]store.Parameter{}
+	defer func() { sharedValues = nil }()
+	var total resolver.Summary
+	defer func() { resolveSummary = total }()
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "* Component `%s`\n", name)
+		parameters, err := raw.ForComponent(name)
+		if err == nil {
+			parameters, err = parameters.WithEnvironment(environment).Select(only, except)
+		}
+		if err != nil {
+			return fmt.Errorf("Error selecting parameters of component `%s`: %v", name, err)
+		}
+		values, err := resolveParameters(parameters)
+		total.OK += resolveSummary.OK
+		total.Skipped += resolveSummary.Skipped
+		total.Defaulted += resolveSummary.Defaulted
+		total.Failed += resolveSummary.Failed
+		if err != nil {
+			return fmt.Errorf("Component `%s`: %v", name, err)
+		}
+
+		componentSpecs := make([]outputSpec, len(specs))
+		for i, spec := range specs {
+			spec.Path = filepath.Join(dir, name, spec.Path)
+			if err := os.MkdirAll(filepath.Dir(spec.Path), 0755); err != nil {
+				return err
+			}
+			componentSpecs[i] = spec
+		}
+		if err := writeOutputs(componentSpecs, resolver.Options(values), templateText); err != nil {
+			return err
+		}
+	}
+	return nil
+}
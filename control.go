@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/render"
+	"github.com/codacy/ssmeb/pkg/snapshot"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// controlServer implements the ssmeb.v1.Control gRPC service described in
+// proto/ssmeb/v1/control.proto. Every method takes and returns a
+// google.protobuf.Struct, so clients don't need generated code for custom messages.
+type controlServer struct {
+	config *configServer
+}
+
+// controlMethod is a method of the control service
+type controlMethod func(s *controlServer, request *structpb.Struct) (interface{}, error)
+
+// controlServiceDesc describes the control service to gRPC, in place of generated code
+var controlServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ssmeb.v1.Control",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Resolve", Handler: controlHandler((*controlServer).resolve)},
+		{MethodName: "Diff", Handler: controlHandler((*controlServer).diff)},
+		{MethodName: "Apply", Handler: controlHandler((*controlServer).apply)},
+		{MethodName: "Snapshot", Handler: controlHandler((*controlServer).snapshot)},
+	},
+	Metadata: "proto/ssmeb/v1/control.proto",
+}
+
+// runControl serves the control service on listen, over TLS with the cert and key files
+// if given, sharing the values resolved by the config server
+func runControl(listen string, cert string, key string, config *configServer) error {
+	var options []grpc.ServerOption
+	if cert != "" {
+		creds, err := credentials.NewServerTLSFromFile(cert, key)
+		if err != nil {
+			return fmt.Errorf("Error loading the TLS certificate: %v", err)
+		}
+		options = append(options, grpc.Creds(creds))
+	}
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+	server := grpc.NewServer(options...)
+	server.RegisterService(&controlServiceDesc, &controlServer{config: config})
+	fmt.Fprintf(os.Stderr, "Serving gRPC on %s\n", listen)
+	return server.Serve(listener)
+}
+
+// controlHandler adapts a method to gRPC, checking the token sent by the client as
+// "authorization: Bearer <token>" metadata and converting the response into a Struct
+func controlHandler(method controlMethod) grpc.MethodHandler {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		request := &structpb.Struct{}
+		if err := dec(request); err != nil {
+			return nil, err
+		}
+		s := srv.(*controlServer)
+		var token string
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("authorization")) > 0 {
+			token = strings.TrimPrefix(md.Get("authorization")[0], "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.config.token)) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "unauthorized")
+		}
+
+		// the calls and the refreshes share the global flags, so they run one at a time
+		s.config.resolving.Lock()
+		defer s.config.resolving.Unlock()
+		response, err := method(s, request)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return toStruct(response)
+	}
+}
+
+// resolve resolves the parameters again, also refreshing the values served over HTTP,
+// and returns the options by name
+func (s *controlServer) resolve(request *structpb.Struct) (interface{}, error) {
+	if err := s.config.update(); err != nil {
+		return nil, err
+	}
+	s.config.mutex.RLock()
+	defer s.config.mutex.RUnlock()
+	return map[string]interface{}{
+		"options":     render.Map(s.config.options),
+		"resolved_at": s.config.updated.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// diff returns the parameters missing from the store or whose values differ from the
+// input, like the diff command
+func (s *controlServer) diff(request *structpb.Struct) (interface{}, error) {
+	parameters, err := loadParameters()
+	if err != nil {
+		return nil, err
+	}
+	parameters, err = expandPlaceholders(parameters)
+	if err != nil {
+		return nil, err
+	}
+	target, err := newStore()
+	if err != nil {
+		return nil, err
+	}
+	differences, err := parameterDifferences(target, parameters)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting values: %v", err)
+	}
+	return map[string]interface{}{"differences": differences}, nil
+}
+
+// apply sets the component parameters with a value in the input, like the set command.
+// The ones without a value are left alone, as there's nobody to ask for them.
+func (s *controlServer) apply(request *structpb.Struct) (interface{}, error) {
+	parameters, err := loadParameters()
+	if err != nil {
+		return nil, err
+	}
+	parameters, err = expandPlaceholders(parameters)
+	if err != nil {
+		return nil, err
+	}
+	var withValues []config.Parameter
+	for _, par := range parameters.Component {
+		if par.Value != "" && !par.IsWildcard() {
+			withValues = append(withValues, par)
+		}
+	}
+	target, err := newStore()
+	if err != nil {
+		return nil, err
+	}
+	err = setParameters(target, config.Parameters{Component: withValues}, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error setting values: %v", err)
+	}
+	return map[string]interface{}{"set": len(withValues)}, nil
+}
+
+// snapshot resolves the parameters and returns them as a snapshot, like the snapshot
+// command writes it
+func (s *controlServer) snapshot(request *structpb.Struct) (interface{}, error) {
+	values, err := resolveValues()
+	if err != nil {
+		return nil, err
+	}
+	recorded := snapshot.New(environment, values)
+	recorded.Created, _, err = generationTime()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"snapshot": recorded}, nil
+}
+
+// toStruct converts a value marshaling into a JSON object into a Struct
+func toStruct(value interface{}) (*structpb.Struct, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return structpb.NewStruct(fields)
+}
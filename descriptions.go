@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/store"
+)
+
+// descriptionDifference is a component parameter whose description in SSM differs from
+// the one in the input
+type descriptionDifference struct {
+	Name   string `json:"option_name"`
+	Path   string `json:"path"`
+	Stored string `json:"stored"`
+	Wanted string `json:"wanted"`
+	// keyID is the KMS key of the SecureString values, kept when updating the description
+	keyID *string
+	// tier, dataType, allowedPattern and policies are kept too, since SSM resets them
+	// when a parameter is overwritten without them
+	tier           *string
+	dataType       *string
+	allowedPattern *string
+	policies       *string
+}
+
+// canDescribe reports whether the descriptions of the parameters can be compared, which
+// needs the `ssm` backend
+func canDescribe() bool {
+	name, _ := splitBackend(backend)
+	return name == "ssm" && !offline
+}
+
+// descriptionDifferences returns the component parameters with a description in the input
+// that differs from the one stored in SSM, describing them in batches without getting
+// their values. Parameters missing from SSM, under wildcard paths, in other stores or
+// got with other credentials are not checked.
+func descriptionDifferences(client ssmiface.SSMAPI, parameters config.Parameters) ([]descriptionDifference, error) {
+	var paths []string
+	wanted := map[string]config.Parameter{}
+	for _, par := range parameters.Component {
+		scheme, _ := store.SplitScheme(par.Path)
+		if par.Description == "" || par.IsWildcard() || scheme != "" || par.Credentials() != "" {
+			continue
+		}
+		paths = append(paths, par.Path)
+		wanted[par.Path] = par
+	}
+	paths = unique(paths)
+
+	var differences []descriptionDifference
+	for start := 0; start < len(paths); start += preflightBatch {
+		batch := paths[start:]
+		if len(batch) > preflightBatch {
+			batch = batch[:preflightBatch]
+		}
+		input := &ssm.DescribeParametersInput{
+			ParameterFilters: []*ssm.ParameterStringFilter{{
+				Key:    aws.String("Name"),
+				Option: aws.String("Equals"),
+				Values: aws.StringSlice(batch),
+			}},
+		}
+		err := client.DescribeParametersPages(input, func(page *ssm.DescribeParametersOutput, lastPage bool) bool {
+			for _, stored := range page.Parameters {
+				par := wanted[aws.StringValue(stored.Name)]
+				if description := aws.StringValue(stored.Description); description != par.Description {
+					differences = append(differences, descriptionDifference{
+						Name:           par.Name,
+						Path:           par.Path,
+						Stored:         description,
+						Wanted:         par.Description,
+						keyID:          stored.KeyId,
+						tier:           stored.Tier,
+						dataType:       stored.DataType,
+						allowedPattern: stored.AllowedPattern,
+						policies:       inlinePolicies(stored.Policies),
+					})
+				}
+			}
+			return true
+		})
+		if err != nil {
+			return differences, err
+		}
+	}
+	return differences, nil
+}
+
+// syncDescriptions updates the descriptions in SSM that differ from the input, keeping
+// the values. SSM only changes descriptions when putting a value, so every update adds
+// a version with the same value.
+func syncDescriptions(client ssmiface.SSMAPI, parameters config.Parameters) error {
+	differences, err := descriptionDifferences(client, parameters)
+	if err != nil {
+		return fmt.Errorf("Error describing the parameters: %v", err)
+	}
+	for _, difference := range differences {
+		fmt.Printf("* Updating the description of `%s`... ", difference.Path)
+		got, err := client.GetParameter(&ssm.GetParameterInput{Name: aws.String(difference.Path), WithDecryption: aws.Bool(true)})
+		if err != nil {
+			fmt.Println("FAILED")
+			return fmt.Errorf("Error getting `%s`: %v", difference.Path, err)
+		}
+		output, err := client.PutParameter(&ssm.PutParameterInput{
+			Name:           got.Parameter.Name,
+			Value:          got.Parameter.Value,
+			Type:           got.Parameter.Type,
+			Description:    aws.String(difference.Wanted),
+			KeyId:          difference.keyID,
+			Tier:           difference.tier,
+			DataType:       difference.dataType,
+			AllowedPattern: difference.allowedPattern,
+			Policies:       difference.policies,
+			Overwrite:      aws.Bool(true),
+		})
+		if err != nil {
+			fmt.Println("FAILED")
+			return fmt.Errorf("Error updating the description of `%s`: %v", difference.Path, err)
+		}
+		fmt.Printf("OK (version %d)\n", aws.Int64Value(output.Version))
+	}
+	fmt.Printf("%d description(s) updated\n", len(differences))
+	return nil
+}
+
+// inlinePolicies returns the policies of a described parameter as the JSON array taken by
+// PutParameter, or nil if it has none
+func inlinePolicies(policies []*ssm.ParameterInlinePolicy) *string {
+	if len(policies) == 0 {
+		return nil
+	}
+	var texts []string
+	for _, policy := range policies {
+		texts = append(texts, aws.StringValue(policy.PolicyText))
+	}
+	return aws.String("[" + strings.Join(texts, ",") + "]")
+}
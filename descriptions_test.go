@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/ssmstore/ssmfake"
+)
+
+// expiration is an expiration policy, which needs the Advanced tier
+const expiration = `{"Type":"Expiration","Version":"1.0","Attributes":{"Timestamp":"2030-01-01T00:00:00.000Z"}}`
+
+// describe returns the metadata of the parameter in path
+func describe(t *testing.T, client *ssmfake.Client, path string) *ssm.ParameterMetadata {
+	t.Helper()
+	var metadata *ssm.ParameterMetadata
+	input := &ssm.DescribeParametersInput{
+		ParameterFilters: []*ssm.ParameterStringFilter{{Key: aws.String("Name"), Option: aws.String("Equals"), Values: aws.StringSlice([]string{path})}},
+	}
+	err := client.DescribeParametersPages(input, func(page *ssm.DescribeParametersOutput, lastPage bool) bool {
+		for _, par := range page.Parameters {
+			metadata = par
+		}
+		return true
+	})
+	if err != nil || metadata == nil {
+		t.Fatalf("describing %s = %v (%v), want its metadata", path, metadata, err)
+	}
+	return metadata
+}
+
+func TestSyncDescriptionsKeepsSettings(t *testing.T) {
+	client := ssmfake.New()
+	_, err := client.PutParameter(&ssm.PutParameterInput{
+		Name:           aws.String("/staging/ami"),
+		Value:          aws.String("ami-12345678"),
+		Type:           aws.String(ssm.ParameterTypeString),
+		Description:    aws.String("old"),
+		Tier:           aws.String(ssm.ParameterTierAdvanced),
+		DataType:       aws.String("aws:ec2:image"),
+		AllowedPattern: aws.String("^ami-[0-9a-f]+$"),
+		Policies:       aws.String("[" + expiration + "]"),
+	})
+	if err != nil {
+		t.Fatalf("PutParameter() error = %v", err)
+	}
+	parameters := config.Parameters{
+		Component: []config.Parameter{{Name: "AMI", Path: "/staging/ami", Description: "base image"}},
+	}
+
+	if err := syncDescriptions(client, parameters); err != nil {
+		t.Fatalf("syncDescriptions() error = %v", err)
+	}
+	metadata := describe(t, client, "/staging/ami")
+	if got := aws.StringValue(metadata.Description); got != "base image" {
+		t.Errorf("description = %q, want %q", got, "base image")
+	}
+	if got := aws.StringValue(metadata.Tier); got != ssm.ParameterTierAdvanced {
+		t.Errorf("tier = %q, want %q", got, ssm.ParameterTierAdvanced)
+	}
+	if got := aws.StringValue(metadata.DataType); got != "aws:ec2:image" {
+		t.Errorf("data type = %q, want %q", got, "aws:ec2:image")
+	}
+	if got := aws.StringValue(metadata.AllowedPattern); got != "^ami-[0-9a-f]+$" {
+		t.Errorf("allowed pattern = %q, want %q", got, "^ami-[0-9a-f]+$")
+	}
+	if len(metadata.Policies) != 1 || aws.StringValue(metadata.Policies[0].PolicyText) != expiration {
+		t.Errorf("policies = %v, want the expiration policy", metadata.Policies)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+var diffShowValues bool
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show the differences between the input and the values stored in SSM",
+	Long: `Show the differences between the input and the values stored in SSM.
+
+Component parameters with a value in the input are compared with the value
+stored in SSM. Every other parameter is only checked for existence. With the
+ssm backend, the descriptions of the component parameters in the input are
+compared too, and set --descriptions-only updates the ones that differ.
+Exits with an error if any difference is found.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return forEachEnvironment(cmd, func() error {
+			printSettings("input", inputName(), "environment", environment)
+			return runDiff(diffShowValues)
+		})
+	},
+}
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffShowValues, "show-values", false, "print the differing values (they may contain secrets)")
+	addEnvironmentsFlag(diffCmd)
+	rootCmd.AddCommand(diffCmd)
+}
+
+// runDiff compares the parameters in the input file with SSM and reports every difference
+func runDiff(showValues bool) error {
+	parameters, err := loadParameters()
+	if err != nil {
+		return err
+	}
+	parameters, err = expandPlaceholders(parameters)
+	if err != nil {
+		return err
+	}
+
+	s, err := newStore()
+	if err != nil {
+		return err
+	}
+	differences, err := diffParameters(s, parameters, showValues)
+	if err != nil {
+		return fmt.Errorf("Error getting values: %v", err)
+	}
+	if canDescribe() {
+		drift, err := descriptionDifferences(ssm.New(newSession()), parameters)
+		if err != nil {
+			return fmt.Errorf("Error describing the parameters: %v", err)
+		}
+		for _, difference := range drift {
+			fmt.Printf("* `%s` (%s): description differs\n    - %s\n    + %s\n", difference.Name, difference.Path, difference.Stored, difference.Wanted)
+		}
+		differences += len(drift)
+	}
+	if differences > 0 {
+		return fmt.Errorf("%d parameter(s) differ from SSM", differences)
+	}
+	fmt.Fprintln(os.Stderr, "No differences found")
+	return nil
+}
+
+// parameterDifference is a parameter missing from the store or whose value differs
+// from the one in the input
+type parameterDifference struct {
+	Name string `json:"option_name"`
+	Path string `json:"path"`
+	// Missing is true when the parameter isn't in the store, otherwise its value differs
+	Missing bool `json:"missing"`
+	// Stored and Wanted are the values in the store and in the input, when they differ
+	Stored string `json:"-"`
+	Wanted string `json:"-"`
+}
+
+// diffParameters prints a line for each parameter that is missing from the store or whose
+// value differs from the one in the input, and returns how many were found
+func diffParameters(s store.Store, parameters config.Parameters, showValues bool) (int, error) {
+	differences, err := parameterDifferences(s, parameters)
+	for _, difference := range differences {
+		if difference.Missing {
+			fmt.Printf("* `%s` (%s): missing in SSM\n", difference.Name, difference.Path)
+			continue
+		}
+		fmt.Printf("* `%s` (%s): value differs\n", difference.Name, difference.Path)
+		if showValues {
+			fmt.Printf("    - %s\n    + %s\n", difference.Stored, difference.Wanted)
+		}
+	}
+	return len(differences), err
+}
+
+// parameterDifferences returns the parameters that are missing from the store or whose
+// value differs from the one in the input, up to the first error
+func parameterDifferences(s store.Store, parameters config.Parameters) ([]parameterDifference, error) {
+	var differences []parameterDifference
+	for i, par := range parameters.All() {
+		if par.IsWildcard() {
+			continue
+		}
+		stored, found, err := store.Lookup(s, par.Path)
+		if err != nil {
+			return differences, err
+		}
+		if !found {
+			differences = append(differences, parameterDifference{Name: par.Name, Path: par.Path, Missing: true})
+			continue
+		}
+		isComponent := i < len(parameters.Component)
+		if isComponent && par.Value != "" && par.Value != stored.Value {
+			differences = append(differences, parameterDifference{Name: par.Name, Path: par.Path, Stored: stored.Value, Wanted: par.Value})
+		}
+	}
+	return differences, nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffEnvLeft  string
+	diffEnvRight string
+	diffEnvAll   bool
+)
+
+var diffEnvCmd = &cobra.Command{
+	Use:   "diff-env",
+	Short: "Show the differences between the values of the parameters in two environments",
+	Long: `Show the differences between the values of the parameters in two environments.
+
+The parameters in the input are got from the store in both environments, with
+their overrides applied, and the options whose values differ, or which are
+missing or unused in either environment, are listed. Secret values are masked,
+so only the fact that they differ is shown. Derived options are not compared.`,
+	Example: `  ssmeb diff-env -i params.yaml --left staging --right production`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("input", inputName(), "left", diffEnvLeft, "right", diffEnvRight)
+		return runDiffEnv(diffEnvLeft, diffEnvRight)
+	},
+}
+
+func init() {
+	diffEnvCmd.Flags().StringVar(&diffEnvLeft, "left", "", "first environment compared (required)")
+	diffEnvCmd.Flags().StringVar(&diffEnvRight, "right", "", "second environment compared (required)")
+	diffEnvCmd.Flags().BoolVar(&diffEnvAll, "all", false, "also list the options with the same value")
+	rootCmd.AddCommand(diffEnvCmd)
+}
+
+// envValue is the value of an option in an environment
+type envValue struct {
+	Path   string
+	Value  string
+	Secret bool
+	// Found is false when the parameter is missing from the store
+	Found bool
+}
+
+// runDiffEnv prints the differences between the values of the parameters in the
+// left and right environments
+func runDiffEnv(left string, right string) error {
+	if left == "" || right == "" || left == right {
+		return fmt.Errorf("Expected two different environments in `left` and `right`")
+	}
+	s, err := newStore()
+	if err != nil {
+		return err
+	}
+	leftValues, err := environmentValues(s, left)
+	if err != nil {
+		return err
+	}
+	rightValues, err := environmentValues(s, right)
+	if err != nil {
+		return err
+	}
+
+	differences := printEnvironmentDiff(os.Stdout, left, right, leftValues, rightValues, diffEnvAll)
+	fmt.Fprintf(os.Stderr, "%d option(s) differ between `%s` and `%s`\n", differences, left, right)
+	return nil
+}
+
+// environmentValues gets the values of the parameters in the environment, by option
+// name, including the children of the wildcard parameters
+func environmentValues(s store.Store, environment string) (map[string]envValue, error) {
+	parameters, err := loadParametersIn(environment)
+	if err != nil {
+		return nil, err
+	}
+	parameters, err = expandPlaceholders(parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]envValue{}
+	for _, par := range parameters.All() {
+		if par.IsWildcard() {
+			children, err := childPaths(par, s)
+			if err != nil {
+				return nil, fmt.Errorf("Error listing `%s` in `%s`: %v", par.Path, environment, err)
+			}
+			for _, path := range children {
+				name := par.ExpandName(strings.TrimPrefix(path, par.WildcardPrefix()+"/"))
+				if !parameters.Selects(name) {
+					continue
+				}
+				stored, err := s.Get(path)
+				if err != nil {
+					return nil, fmt.Errorf("Error getting `%s`: %v", path, err)
+				}
+				values[name] = envValue{Path: path, Value: stored.Value, Secret: stored.Secret, Found: true}
+			}
+			continue
+		}
+		stored, found, err := store.Lookup(s, par.Path)
+		if err != nil {
+			return nil, fmt.Errorf("Error getting `%s`: %v", par.Path, err)
+		}
+		values[par.Name] = envValue{
+			Path:   par.Path,
+			Value:  stored.Value,
+			Secret: stored.Secret || par.Type == config.TypeSecureString,
+			Found:  found,
+		}
+	}
+	return values, nil
+}
+
+// printEnvironmentDiff prints a table with the options whose values differ between
+// the environments, or every option if all is set, and returns how many differ
+func printEnvironmentDiff(out io.Writer, left string, right string, leftValues map[string]envValue, rightValues map[string]envValue, all bool) int {
+	var names []string
+	for name := range leftValues {
+		names = append(names, name)
+	}
+	for name := range rightValues {
+		if _, ok := leftValues[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	display := func(value envValue, ok bool) string {
+		switch {
+		case !ok:
+			return "(not used)"
+		case !value.Found:
+			return "(missing)"
+		case value.Secret:
+			return "********"
+		}
+		return value.Value
+	}
+
+	differences := 0
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "OPTION\t%s\t%s\tSTATUS\n", strings.ToUpper(left), strings.ToUpper(right))
+	for _, name := range names {
+		leftValue, inLeft := leftValues[name]
+		rightValue, inRight := rightValues[name]
+		status := "same"
+		switch {
+		case !inLeft || !inRight:
+			status = "unused"
+		case !leftValue.Found || !rightValue.Found:
+			status = "missing"
+		case leftValue.Value != rightValue.Value:
+			status = "differs"
+		}
+		if status != "same" {
+			differences++
+		} else if !all {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, display(leftValue, inLeft), display(rightValue, inRight), status)
+	}
+	w.Flush()
+	return differences
+}
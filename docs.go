@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var docsOutput string
+
+var docsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Document the parameters as a Markdown table, without contacting AWS",
+	Long: `Document the parameters as a Markdown table, without contacting AWS.
+
+The table lists every parameter of the input as written, with its path,
+description, type, whether it's required and the environments using it. It's
+surrounded by comments marking it, so when the output file already exists and
+holds them, like a runbook the table was added to, only the table is replaced
+and the rest of the file is kept.`,
+	Example: `  ssmeb docs -i params.yaml -o RUNBOOK.md`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		parameters, err := readComponentParameters()
+		if err != nil {
+			return err
+		}
+		table := documentParameters(parameters)
+		if docsOutput == "" {
+			_, err = os.Stdout.Write(table)
+			return err
+		}
+		return updateDocs(docsOutput, table)
+	},
+}
+
+func init() {
+	docsCmd.Flags().StringVarP(&docsOutput, "output", "o", "", "Markdown file the table is written to, replacing the previous table if it has one (defaults to stdout)")
+	rootCmd.AddCommand(docsCmd)
+}
+
+// Comments surrounding the table in the generated Markdown
+const (
+	docsBegin = "<!-- ssmeb docs begin, generated from the parameters file -->"
+	docsEnd   = "<!-- ssmeb docs end -->"
+)
+
+// documentParameters renders a Markdown table describing the parameters, between
+// the docsBegin and docsEnd comments
+func documentParameters(parameters config.Parameters) []byte {
+	var doc bytes.Buffer
+	fmt.Fprintln(&doc, docsBegin)
+	fmt.Fprintln(&doc, "| Name | Path | Description | Type | Required | Environments |")
+	fmt.Fprintln(&doc, "| ---- | ---- | ----------- | ---- | -------- | ------------ |")
+	row := func(par config.Parameter, path string, parType string) {
+		name := par.Name
+		if !par.IsWildcard() {
+			name = parameters.Naming.Apply(name)
+		}
+		required := "yes"
+		if par.Default != "" {
+			required = "no, defaults to " + markdownCell("`"+par.Default+"`")
+		} else if par.Optional {
+			required = "no"
+		}
+		fmt.Fprintf(&doc, "| `%s` | %s | %s | %s | %s | %s |\n",
+			name, path, markdownCell(par.Description), parType, required, describeEnvironments(par))
+	}
+	for _, par := range parameters.Component {
+		parType := par.Type
+		if parType == "" {
+			parType = config.TypeString
+		}
+		row(par, "`"+par.Path+"`", parType)
+	}
+	// the type of external parameters is up to their owners
+	for _, par := range parameters.External {
+		row(par, "`"+par.Path+"`", "external")
+	}
+	for _, par := range parameters.Derived {
+		row(par, "", "derived")
+	}
+	fmt.Fprintln(&doc, docsEnd)
+	return doc.Bytes()
+}
+
+// markdownCell escapes text so it fits in a cell of a Markdown table
+func markdownCell(text string) string {
+	text = strings.Replace(strings.TrimSpace(text), "|", `\|`, -1)
+	return strings.Replace(text, "\n", "<br>", -1)
+}
+
+// describeEnvironments summarizes the environments using the parameter
+func describeEnvironments(par config.Parameter) string {
+	if len(par.OnlyEnvironments) > 0 {
+		return strings.Join(par.OnlyEnvironments, ", ")
+	}
+	if len(par.ExceptEnvironments) > 0 {
+		return "all but " + strings.Join(par.ExceptEnvironments, ", ")
+	}
+	return "all"
+}
+
+// updateDocs writes the table to the file with name filename, replacing the previous
+// table if the file has one. Existing files without a table are not overwritten.
+func updateDocs(filename string, table []byte) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Error reading file `%s`: %v", filename, err)
+	}
+	if err == nil {
+		begin := bytes.Index(data, []byte(docsBegin))
+		end := bytes.Index(data, []byte(docsEnd))
+		if begin < 0 || end < begin {
+			return fmt.Errorf("`%s` has no table generated by ssmeb to replace, add the line `%s` followed by `%s` where it goes", filename, docsBegin, docsEnd)
+		}
+		end += len(docsEnd)
+		if end < len(data) && data[end] == '\n' {
+			end++
+		}
+		table = append(append(append([]byte{}, data[:begin]...), table...), data[end:]...)
+	}
+
+	err = writeToFile(filename, table, 0644)
+	if err != nil {
+		return fmt.Errorf("Error writing to file `%s`: %v", filename, err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/spf13/cobra"
+)
+
+var doctorCommands []string
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that the AWS credentials allow getting or setting the parameters",
+	Long: `Check that the AWS credentials allow getting or setting the parameters.
+
+The credentials are resolved and their identity printed. Then the actions in
+the policy printed by the policy command are simulated for the identity, on
+each parameter of the environment. When the identity can't run simulations,
+the values are got from the store instead, which checks the get command only.`,
+	Example: `  ssmeb doctor -i params.yaml -e production
+  ssmeb doctor -i params.yaml -e production --commands get`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("input", inputName(), "environment", environment)
+		return runDoctor(doctorCommands)
+	},
+}
+
+func init() {
+	doctorCmd.Flags().StringSliceVar(&doctorCommands, "commands", []string{"get", "set"}, "commands to check, get and set")
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// runDoctor checks the credentials and whether they allow running the commands
+func runDoctor(commands []string) error {
+	session := newSession()
+	fmt.Printf("* Resolving the AWS credentials... ")
+	identity, err := sts.New(session).GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		fmt.Println("FAILED")
+		return fmt.Errorf("Error resolving the AWS credentials: %v", err)
+	}
+	fmt.Println("OK")
+	fmt.Printf("  account: %s\n  arn:     %s\n", aws.StringValue(identity.Account), aws.StringValue(identity.Arn))
+
+	policy, err := buildPolicy(commands, "", aws.StringValue(identity.Account))
+	if err != nil {
+		return err
+	}
+	principal, err := principalARN(session, aws.StringValue(identity.Arn))
+	if err == nil {
+		var denied int
+		denied, err = simulatePolicy(session, principal, policy)
+		if err == nil {
+			if denied > 0 {
+				return fmt.Errorf("%d actions are not allowed", denied)
+			}
+			return nil
+		}
+	}
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != "AccessDenied" {
+		return err
+	}
+
+	fmt.Printf("* Can't simulate the policy of `%s`, getting the values instead\n", aws.StringValue(identity.Arn))
+	_, err = resolveValues()
+	return err
+}
+
+// principalARN returns the ARN of the IAM user or role with the identity whose ARN is
+// given, as expected by the policy simulator. Assumed roles are looked up by name, as
+// their identity doesn't tell the path of the role.
+func principalARN(session *session.Session, identity string) (string, error) {
+	parts := strings.Split(identity, ":")
+	if len(parts) != 6 || !strings.HasPrefix(parts[5], "assumed-role/") {
+		return identity, nil
+	}
+	name := strings.Split(parts[5], "/")[1]
+	role, err := iam.New(session).GetRole(&iam.GetRoleInput{RoleName: aws.String(name)})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(role.Role.Arn), nil
+}
+
+// simulatePolicy prints whether the principal is allowed each action on each resource
+// of the policy, returning the number of denied ones
+func simulatePolicy(session *session.Session, principal string, policy iamPolicy) (int, error) {
+	client := iam.New(session)
+	denied := 0
+	for _, statement := range policy.Statement {
+		input := &iam.SimulatePrincipalPolicyInput{
+			PolicySourceArn: aws.String(principal),
+			ActionNames:     aws.StringSlice(statement.Action),
+		}
+		if len(statement.Resource) != 1 || statement.Resource[0] != "*" {
+			input.ResourceArns = aws.StringSlice(statement.Resource)
+		}
+		// string keys take a single value, the condition allowing any of them
+		for _, conditions := range statement.Condition {
+			for key, values := range conditions {
+				input.ContextEntries = append(input.ContextEntries, &iam.ContextEntry{
+					ContextKeyName:   aws.String(key),
+					ContextKeyType:   aws.String(iam.ContextKeyTypeEnumString),
+					ContextKeyValues: aws.StringSlice(values[:1]),
+				})
+			}
+		}
+
+		err := client.SimulatePrincipalPolicyPages(input, func(page *iam.SimulatePolicyResponse, lastPage bool) bool {
+			for _, result := range page.EvaluationResults {
+				decision := aws.StringValue(result.EvalDecision)
+				fmt.Printf("* %s on `%s`... ", aws.StringValue(result.EvalActionName), aws.StringValue(result.EvalResourceName))
+				if decision == iam.PolicyEvaluationDecisionTypeAllowed {
+					fmt.Println("OK")
+					continue
+				}
+				fmt.Printf("DENIED (%s)\n", decision)
+				denied++
+			}
+			return true
+		})
+		if err != nil {
+			return denied, err
+		}
+	}
+	return denied, nil
+}
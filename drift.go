@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elasticbeanstalk"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/codacy/ssmeb/pkg/render"
+	"github.com/spf13/cobra"
+)
+
+// environmentNamespace is the elastic beanstalk namespace of the environment variables
+const environmentNamespace = "aws:elasticbeanstalk:application:environment"
+
+var (
+	driftEBEnvironment string
+	driftInterval      time.Duration
+	driftOnce          bool
+	driftSNSTopic      string
+	driftSlackWebhook  string
+)
+
+var driftCmd = &cobra.Command{
+	Use:   "drift",
+	Short: "Periodically compare the store with a baseline, alerting when values change out-of-band",
+	Long: `Periodically compare the store with a baseline, alerting when values change out-of-band.
+
+The baseline is either a snapshot recorded with the snapshot command, given
+with --snapshot, or the
+environment properties of a deployed elastic beanstalk environment. Alerts
+name the drifted options but never include their values, and are only sent
+when the set of drifted options changes.`,
+	Example: `  ssmeb drift -i params.yaml -e production --snapshot production.json --sns-topic arn:aws:sns:eu-west-1:123456789012:config-drift
+  ssmeb drift -i params.yaml -e production --eb-environment myapp-production --once`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("input", inputName(), "environment", environment, "snapshot", snapshotIn, "eb environment", driftEBEnvironment)
+		return runDrift()
+	},
+}
+
+func init() {
+	driftCmd.Flags().StringVar(&driftEBEnvironment, "eb-environment", "", "elastic beanstalk environment whose properties are used as baseline")
+	driftCmd.Flags().DurationVar(&driftInterval, "interval", 5*time.Minute, "interval between checks")
+	driftCmd.Flags().BoolVar(&driftOnce, "once", false, "check once and exit with an error if there's drift")
+	driftCmd.Flags().StringVar(&driftSNSTopic, "sns-topic", getEnv("SSMEB_SNS_TOPIC", ""), "ARN of an SNS topic to publish alerts to")
+	driftCmd.Flags().StringVar(&driftSlackWebhook, "slack-webhook", getEnv("SSMEB_SLACK_WEBHOOK", ""), "Slack incoming webhook url to post alerts to")
+	rootCmd.AddCommand(driftCmd)
+}
+
+// runDrift checks for drift every interval, or once if requested
+func runDrift() error {
+	if (snapshotIn == "") == (driftEBEnvironment == "") {
+		return fmt.Errorf("Exactly one baseline is required: `snapshot` or `eb-environment`")
+	}
+
+	if driftOnce {
+		drifted, err := checkDrift()
+		if err != nil {
+			return err
+		}
+		if len(drifted) > 0 {
+			if err := sendAlert(drifted); err != nil {
+				return err
+			}
+			return fmt.Errorf("%d option(s) drifted from the baseline", len(drifted))
+		}
+		fmt.Fprintln(os.Stderr, "No drift found")
+		return nil
+	}
+
+	var lastReport string
+	for ; ; time.Sleep(driftInterval) {
+		drifted, err := checkDrift()
+		if err != nil {
+			log.Printf("Error checking drift: %v", err)
+			continue
+		}
+		report := strings.Join(drifted, "\n")
+		if report == lastReport {
+			continue
+		}
+		lastReport = report
+
+		if len(drifted) == 0 {
+			log.Print("No drift found")
+			continue
+		}
+		if err := sendAlert(drifted); err != nil {
+			log.Printf("Error sending alert: %v", err)
+		}
+	}
+}
+
+// checkDrift returns a description of every option whose current value differs from the baseline
+func checkDrift() ([]string, error) {
+	baseline, err := driftBaseline()
+	if err != nil {
+		return nil, err
+	}
+	options, err := resolveOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	var drifted []string
+	for _, option := range options {
+		value, ok := baseline[option.Name]
+		if !ok {
+			drifted = append(drifted, fmt.Sprintf("`%s` is missing from the baseline", option.Name))
+		} else if value != option.Value {
+			drifted = append(drifted, fmt.Sprintf("`%s` changed", option.Name))
+		}
+	}
+	sort.Strings(drifted)
+	return drifted, nil
+}
+
+// driftBaseline returns the baseline values by option name
+func driftBaseline() (map[string]string, error) {
+	if snapshotIn != "" {
+		baseline, err := readSnapshot(snapshotIn)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading file `%s`: %v", snapshotIn, err)
+		}
+		return render.Map(baseline.Options()), nil
+	}
+
+	values, err := ebEnvironmentProperties(driftEBEnvironment)
+	if err != nil {
+		return nil, fmt.Errorf("Error describing environment `%s`: %v", driftEBEnvironment, err)
+	}
+	return values, nil
+}
+
+// ebEnvironmentProperties returns the environment properties of an elastic beanstalk environment
+func ebEnvironmentProperties(ebEnvironment string) (map[string]string, error) {
+	client := elasticbeanstalk.New(newSession())
+
+	environments, err := client.DescribeEnvironments(&elasticbeanstalk.DescribeEnvironmentsInput{
+		EnvironmentNames: []*string{aws.String(ebEnvironment)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(environments.Environments) == 0 {
+		return nil, fmt.Errorf("environment not found")
+	}
+
+	settings, err := client.DescribeConfigurationSettings(&elasticbeanstalk.DescribeConfigurationSettingsInput{
+		ApplicationName: environments.Environments[0].ApplicationName,
+		EnvironmentName: aws.String(ebEnvironment),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	for _, configuration := range settings.ConfigurationSettings {
+		for _, setting := range configuration.OptionSettings {
+			if aws.StringValue(setting.Namespace) == environmentNamespace {
+				values[aws.StringValue(setting.OptionName)] = aws.StringValue(setting.Value)
+			}
+		}
+	}
+	return values, nil
+}
+
+// sendAlert logs the drifted options and sends them to the configured SNS topic and Slack webhook
+func sendAlert(drifted []string) error {
+	subject := fmt.Sprintf("ssmeb: %d option(s) of `%s` drifted", len(drifted), inputName())
+	if environment != "" {
+		subject = fmt.Sprintf("ssmeb: %d option(s) of `%s` drifted in %s", len(drifted), inputName(), environment)
+	}
+	message := subject + "\n\n* " + strings.Join(drifted, "\n* ")
+	log.Print(message)
+
+	if driftSNSTopic != "" {
+		_, err := sns.New(newSession()).Publish(&sns.PublishInput{
+			TopicArn: aws.String(driftSNSTopic),
+			// SNS subjects are limited to 100 characters
+			Subject: aws.String(truncate(subject, 100)),
+			Message: aws.String(message),
+		})
+		if err != nil {
+			return fmt.Errorf("Error publishing to `%s`: %v", driftSNSTopic, err)
+		}
+	}
+
+	if driftSlackWebhook != "" {
+		payload, _ := json.Marshal(map[string]string{"text": message})
+		response, err := http.Post(driftSlackWebhook, "application/json", bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("Error posting to Slack: %v", err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return fmt.Errorf("Error posting to Slack: %s", response.Status)
+		}
+	}
+	return nil
+}
+
+// truncate shortens s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
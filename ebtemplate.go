@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elasticbeanstalk"
+	"github.com/codacy/ssmeb/pkg/render"
+	"github.com/spf13/cobra"
+)
+
+var (
+	templateSolutionStack     string
+	templateSourceEnvironment string
+	templatePrune             bool
+)
+
+var saveTemplateCmd = &cobra.Command{
+	Use:   "save-template <application> <template>",
+	Short: "Store the options as an elastic beanstalk saved configuration",
+	Long: `Store the options as an elastic beanstalk saved configuration.
+
+The options are set as environment properties of the saved configuration of
+the application, so environments can be created or rebuilt from it instead of
+from a committed file. Existing saved configurations are updated, keeping their
+other settings, while new ones are created for the platform given with
+--solution-stack or copying the settings of --source-environment. --prune also
+removes the environment properties that aren't options anymore.
+
+Saved configurations hold the values in plain text, readable by anyone allowed
+to describe the application, so secrets are reported before storing them.`,
+	Example: `  ssmeb save-template myapp production-config -i params.yaml -e production
+  ssmeb save-template myapp production-config -i params.yaml -e production --source-environment myapp-production`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("input", inputName(), "environment", environment, "application", args[0], "template", args[1])
+		return runSaveTemplate(args[0], args[1])
+	},
+}
+
+func init() {
+	saveTemplateCmd.Flags().StringVar(&templateSolutionStack, "solution-stack", "", "solution stack of a new saved configuration, e.g. \"64bit Amazon Linux 2 v3.1.0 running Go 1\"")
+	saveTemplateCmd.Flags().StringVar(&templateSourceEnvironment, "source-environment", "", "elastic beanstalk environment whose settings a new saved configuration starts from")
+	saveTemplateCmd.Flags().BoolVar(&templatePrune, "prune", false, "remove the environment properties of the saved configuration that aren't options")
+	rootCmd.AddCommand(saveTemplateCmd)
+}
+
+// runSaveTemplate sets the options as the environment properties of the saved
+// configuration of the application, creating it if it doesn't exist
+func runSaveTemplate(application string, template string) error {
+	if templateSolutionStack != "" && templateSourceEnvironment != "" {
+		return fmt.Errorf("Only one of `solution-stack` and `source-environment` can be given")
+	}
+	options, err := resolveOptions()
+	if err != nil {
+		return err
+	}
+	secrets := 0
+	for _, option := range options {
+		if option.Secret {
+			secrets++
+		}
+	}
+	if secrets > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d secret option(s) will be readable in plain text in the saved configuration\n", secrets)
+	}
+
+	client := elasticbeanstalk.New(newSession())
+	applications, err := client.DescribeApplications(&elasticbeanstalk.DescribeApplicationsInput{
+		ApplicationNames: []*string{aws.String(application)},
+	})
+	if err != nil {
+		return fmt.Errorf("Error describing application `%s`: %v", application, err)
+	}
+	if len(applications.Applications) == 0 {
+		return fmt.Errorf("Application `%s` not found", application)
+	}
+	exists := false
+	for _, name := range applications.Applications[0].ConfigurationTemplates {
+		exists = exists || aws.StringValue(name) == template
+	}
+
+	if exists {
+		return updateTemplate(client, application, template, options)
+	}
+	return createTemplate(client, application, template, options)
+}
+
+// createTemplate creates the saved configuration with the options as environment properties
+func createTemplate(client *elasticbeanstalk.ElasticBeanstalk, application string, template string, options []render.Option) error {
+	input := &elasticbeanstalk.CreateConfigurationTemplateInput{
+		ApplicationName: aws.String(application),
+		TemplateName:    aws.String(template),
+		OptionSettings:  environmentSettings(options),
+	}
+	switch {
+	case templateSolutionStack != "":
+		input.SolutionStackName = aws.String(templateSolutionStack)
+	case templateSourceEnvironment != "":
+		environments, err := client.DescribeEnvironments(&elasticbeanstalk.DescribeEnvironmentsInput{
+			ApplicationName:  aws.String(application),
+			EnvironmentNames: []*string{aws.String(templateSourceEnvironment)},
+		})
+		if err != nil {
+			return fmt.Errorf("Error describing environment `%s`: %v", templateSourceEnvironment, err)
+		}
+		if len(environments.Environments) == 0 {
+			return fmt.Errorf("Environment `%s` not found in application `%s`", templateSourceEnvironment, application)
+		}
+		input.EnvironmentId = environments.Environments[0].EnvironmentId
+	default:
+		return fmt.Errorf("Saved configuration `%s` doesn't exist, give `solution-stack` or `source-environment` to create it", template)
+	}
+
+	fmt.Fprintf(os.Stderr, "* Creating saved configuration `%s` of `%s` with %d option(s)... ", template, application, len(options))
+	if _, err := client.CreateConfigurationTemplate(input); err != nil {
+		fmt.Fprintln(os.Stderr, "FAILED")
+		return fmt.Errorf("Error creating saved configuration `%s`: %v", template, err)
+	}
+	fmt.Fprintln(os.Stderr, "OK")
+	return nil
+}
+
+// updateTemplate sets the options as environment properties of the saved configuration,
+// removing the other ones if pruning
+func updateTemplate(client *elasticbeanstalk.ElasticBeanstalk, application string, template string, options []render.Option) error {
+	input := &elasticbeanstalk.UpdateConfigurationTemplateInput{
+		ApplicationName: aws.String(application),
+		TemplateName:    aws.String(template),
+		OptionSettings:  environmentSettings(options),
+	}
+	if templatePrune {
+		settings, err := client.DescribeConfigurationSettings(&elasticbeanstalk.DescribeConfigurationSettingsInput{
+			ApplicationName: aws.String(application),
+			TemplateName:    aws.String(template),
+		})
+		if err != nil {
+			return fmt.Errorf("Error describing saved configuration `%s`: %v", template, err)
+		}
+		names := render.Map(options)
+		for _, configuration := range settings.ConfigurationSettings {
+			for _, setting := range configuration.OptionSettings {
+				if _, ok := names[aws.StringValue(setting.OptionName)]; ok || aws.StringValue(setting.Namespace) != environmentNamespace {
+					continue
+				}
+				input.OptionsToRemove = append(input.OptionsToRemove, &elasticbeanstalk.OptionSpecification{
+					Namespace:  setting.Namespace,
+					OptionName: setting.OptionName,
+				})
+			}
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "* Updating saved configuration `%s` of `%s` with %d option(s), removing %d... ", template, application, len(options), len(input.OptionsToRemove))
+	if _, err := client.UpdateConfigurationTemplate(input); err != nil {
+		fmt.Fprintln(os.Stderr, "FAILED")
+		return fmt.Errorf("Error updating saved configuration `%s`: %v", template, err)
+	}
+	fmt.Fprintln(os.Stderr, "OK")
+	return nil
+}
+
+// environmentSettings returns the options as elastic beanstalk environment properties
+func environmentSettings(options []render.Option) []*elasticbeanstalk.ConfigurationOptionSetting {
+	settings := make([]*elasticbeanstalk.ConfigurationOptionSetting, 0, len(options))
+	for _, option := range options {
+		settings = append(settings, &elasticbeanstalk.ConfigurationOptionSetting{
+			Namespace:  aws.String(environmentNamespace),
+			OptionName: aws.String(option.Name),
+			Value:      aws.String(option.Value),
+		})
+	}
+	return settings
+}
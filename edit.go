@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/store"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v3"
+)
+
+var editYes bool
+
+var editCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit the values of the component parameters in $EDITOR, writing the changed ones",
+	Long: `Edit the values of the component parameters in $EDITOR, writing the changed ones.
+
+The values in the store are written to a temporary yaml file, from option name
+to value, which is opened in the editor given in $VISUAL or $EDITOR. Once it's
+closed, the options whose value changed are listed and, after confirming it,
+only those are written to the store. Options removed from the file are left
+unchanged, and nothing is written if any of the changed ones was modified in
+the store while editing.
+
+The temporary file, only readable by its owner, holds the values of the
+secrets while editing, and is removed afterwards.`,
+	Example: `  ssmeb edit -i params.yaml -e staging
+  EDITOR="code --wait" ssmeb edit -i params.yaml -e staging --yes`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("input", inputName(), "environment", environment)
+		return runEdit(&prompter{reader: bufio.NewReader(os.Stdin), out: os.Stderr})
+	},
+}
+
+func init() {
+	editCmd.Flags().BoolVar(&editYes, "yes", false, "write the changed values without asking for confirmation")
+	rootCmd.AddCommand(editCmd)
+}
+
+// runEdit opens the values of the component parameters in the editor and writes the
+// ones changed to the store once confirmed
+func runEdit(p *prompter) error {
+	parameters, err := loadParameters()
+	if err != nil {
+		return err
+	}
+	parameters, err = expandPlaceholders(parameters)
+	if err != nil {
+		return err
+	}
+	s, err := newStore()
+	if err != nil {
+		return err
+	}
+
+	var editable []config.Parameter
+	stored := map[string]string{}
+	document := &yaml.Node{Kind: yaml.MappingNode}
+	for _, par := range parameters.Component {
+		if par.IsWildcard() {
+			continue
+		}
+		current, found, err := store.Lookup(s, par.Path)
+		if err != nil {
+			return fmt.Errorf("Error getting `%s`: %v", par.Path, err)
+		}
+		comment := par.Path
+		if !found {
+			comment += " (missing from the store, left empty to keep it missing)"
+		}
+		editable = append(editable, par)
+		stored[par.Name] = current.Value
+		document.Content = append(document.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: par.Name, HeadComment: comment},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: current.Value, Style: valueStyle(current.Value)})
+	}
+	if len(editable) == 0 {
+		return fmt.Errorf("The input has no component parameters to edit")
+	}
+	document.HeadComment = "Values of the component parameters in the store. Change the ones to write,\noptions removed from this file are left unchanged."
+	data, err := yaml.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	text, err := editValue("edit-"+environment, string(data))
+	if err != nil {
+		return err
+	}
+	var edited map[string]*string
+	if err := yaml.Unmarshal([]byte(text), &edited); err != nil {
+		return fmt.Errorf("Error parsing the edited values, nothing was written: %v", err)
+	}
+
+	var changed []config.Parameter
+	for name, value := range edited {
+		if _, ok := stored[name]; !ok {
+			return fmt.Errorf("Unknown option `%s` in the edited values, nothing was written", name)
+		}
+		if (value == nil || *value == "") && stored[name] != "" {
+			return fmt.Errorf("Empty value for `%s`, nothing was written", name)
+		}
+	}
+	for _, par := range editable {
+		if value, ok := edited[par.Name]; ok && value != nil && *value != stored[par.Name] {
+			if par.Value != "" && par.Value != *value {
+				fmt.Fprintf(os.Stderr, "Note: the input holds another value for `%s`, which `ssmeb set` would write back\n", par.Name)
+			}
+			par.Value = *value
+			changed = append(changed, par)
+		}
+	}
+	if len(changed) == 0 {
+		fmt.Fprintln(os.Stderr, "No values changed, nothing was written")
+		return nil
+	}
+
+	for _, par := range changed {
+		fmt.Fprintf(os.Stderr, "* `%s` (%s): value changed\n", par.Name, par.Path)
+	}
+	if !editYes {
+		confirm, err := p.ask(fmt.Sprintf("Write %d parameter(s)? (y/N)", len(changed)), "")
+		if err != nil || !strings.EqualFold(confirm, "y") {
+			fmt.Fprintln(os.Stderr, "Cancelled, nothing was written")
+			return err
+		}
+	}
+	for _, par := range changed {
+		current, _, err := store.Lookup(s, par.Path)
+		if err != nil {
+			return fmt.Errorf("Error getting `%s`: %v", par.Path, err)
+		}
+		if current.Value != stored[par.Name] {
+			return fmt.Errorf("`%s` was changed in the store while editing, nothing was written", par.Name)
+		}
+	}
+
+	parameters.Component = changed
+	if err := setParameters(s, parameters, nil, nil); err != nil {
+		return fmt.Errorf("Error setting values: %v", err)
+	}
+	return nil
+}
+
+// valueStyle returns the style of the yaml node of the value, which is literal for the
+// ones with several lines so they're edited as they are
+func valueStyle(value string) yaml.Style {
+	if strings.Contains(value, "\n") {
+		return yaml.LiteralStyle
+	}
+	return 0
+}
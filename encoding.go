@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// normalizeValues makes set strip byte order marks and convert line endings to LF
+var normalizeValues bool
+
+// byteOrderMark is added by some editors at the start of UTF-8 files
+const byteOrderMark = "\ufeff"
+
+// checkEncoding fails when the value of the parameter in path isn't valid UTF-8, which
+// SSM rejects, and returns it normalized when the normalize flag is set. It warns about
+// the characters elastic beanstalk or the shells reading the variables would mangle.
+func checkEncoding(path string, value string) (string, error) {
+	if !utf8.ValidString(value) {
+		return "", fmt.Errorf("The value for `%s` isn't valid UTF-8, convert it before setting it", path)
+	}
+	if normalizeValues {
+		value = strings.TrimPrefix(value, byteOrderMark)
+		value = strings.Replace(value, "\r\n", "\n", -1)
+		value = strings.Replace(value, "\r", "\n", -1)
+	}
+
+	var warnings []string
+	if strings.HasPrefix(value, byteOrderMark) {
+		warnings = append(warnings, "starts with a byte order mark")
+	}
+	if strings.Contains(value, "\r") {
+		warnings = append(warnings, "has CR line endings")
+	}
+	var controls []string
+	seen := map[rune]bool{}
+	for _, r := range value {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' && r != '\r' && !seen[r] {
+			seen[r] = true
+			controls = append(controls, fmt.Sprintf("%U", r))
+		}
+	}
+	if len(controls) > 0 {
+		warnings = append(warnings, "has control characters ("+strings.Join(controls, ", ")+")")
+	}
+	if len(warnings) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: the value for `%s` %s, which elastic beanstalk or shells may mangle\n", path, strings.Join(warnings, ", "))
+		if !normalizeValues && (strings.HasPrefix(value, byteOrderMark) || strings.Contains(value, "\r")) {
+			fmt.Fprintln(os.Stderr, "  Set it with --normalize to strip the byte order mark and use LF line endings")
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// encryptOutputUsage is the usage of the encrypt-output flag
+const encryptOutputUsage = "encrypt the outputs before writing them, with `age:<recipient>[,<recipient>...]` or with `sops` following the creation rules in .sops.yaml"
+
+// sopsTypes are the sops input and output types of the formats it can encrypt by value.
+// The other formats are encrypted as a whole.
+var sopsTypes = map[string]string{
+	"ebyaml": "yaml",
+	"json":   "json",
+	"dotenv": "dotenv",
+}
+
+// encryptOutput encrypts the data rendered in format for the file path, which may be
+// empty for stdout, with the method given in the encrypt-output flag. It runs the age
+// or sops binaries, which must be installed.
+func encryptOutput(method string, data []byte, format string, path string) ([]byte, error) {
+	var cmd *exec.Cmd
+	switch {
+	case strings.HasPrefix(method, "age:"):
+		args := []string{"--encrypt", "--armor"}
+		for _, recipient := range strings.Split(strings.TrimPrefix(method, "age:"), ",") {
+			if recipient == "" {
+				return nil, fmt.Errorf("Invalid encryption `%s`: empty age recipient", method)
+			}
+			args = append(args, "--recipient", recipient)
+		}
+		cmd = exec.Command("age", args...)
+	case method == "sops":
+		fileType, ok := sopsTypes[format]
+		if !ok {
+			fileType = "binary"
+		}
+		args := []string{"--encrypt", "--input-type", fileType, "--output-type", fileType}
+		if path != "" {
+			// the creation rules are matched against the name of the output
+			args = append(args, "--filename-override", path)
+		}
+		cmd = exec.Command("sops", append(args, "/dev/stdin")...)
+	default:
+		return nil, fmt.Errorf("Invalid encryption `%s`, expected `age:<recipient>` or `sops`", method)
+	}
+
+	var stdout bytes.Buffer
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("Error encrypting the %s output with %s: %v", format, cmd.Args[0], err)
+	}
+	return stdout.Bytes(), nil
+}
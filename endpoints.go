@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+// useFIPS selects the FIPS endpoints of the AWS services
+var useFIPS bool
+
+// configureEndpoints makes the sessions created with the options use the FIPS
+// endpoints when the fips flag is set. The partition, e.g. GovCloud or China, is
+// picked by the SDK from the region.
+func configureEndpoints(options *session.Options) {
+	if useFIPS {
+		options.Config.EndpointResolver = endpoints.ResolverFunc(fipsEndpoint)
+	}
+}
+
+// fipsEndpoint resolves the FIPS endpoint of the service in the region. The ones known
+// to the SDK are used when there are, otherwise they're derived from the standard
+// endpoint by appending `-fips` to the service, like `ssm-fips.us-east-1.amazonaws.com`.
+func fipsEndpoint(service string, region string, opts ...func(*endpoints.Options)) (endpoints.ResolvedEndpoint, error) {
+	resolver := endpoints.DefaultResolver()
+	if partition, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), region); ok && partition.ID() == endpoints.AwsCnPartitionID {
+		return endpoints.ResolvedEndpoint{}, fmt.Errorf("FIPS endpoints aren't available in region `%s`", region)
+	}
+	known, err := resolver.EndpointFor(service, "fips-"+region, append(opts, endpoints.StrictMatchingOption)...)
+	if err == nil {
+		return known, nil
+	}
+
+	resolved, err := resolver.EndpointFor(service, region, opts...)
+	if err != nil {
+		return resolved, err
+	}
+	u, err := url.Parse(resolved.URL)
+	if err != nil {
+		return resolved, err
+	}
+	labels := strings.SplitN(u.Host, ".", 2)
+	if len(labels) != 2 {
+		return resolved, fmt.Errorf("can't derive the FIPS endpoint of `%s`", resolved.URL)
+	}
+	// global endpoints like sts.amazonaws.com have regional FIPS ones, except IAM's
+	if !strings.HasPrefix(labels[1], region+".") && service != iam.EndpointsID {
+		labels[1] = region + "." + labels[1]
+	}
+	u.Host = labels[0] + "-fips." + labels[1]
+	resolved.URL = u.String()
+	return resolved, nil
+}
+
+// partitionOf returns the partition of the region used in ARNs, e.g. `aws-us-gov`,
+// which is `aws` for unknown regions
+func partitionOf(region string) string {
+	if partition, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), region); ok {
+		return partition.ID()
+	}
+	return endpoints.AwsPartitionID
+}
+
+// serviceHost returns the host name of the endpoint of the service in the region, as
+// used by the kms:ViaService condition, e.g. `ssm.cn-north-1.amazonaws.com.cn`
+func serviceHost(service string, region string) string {
+	resolved, err := endpoints.DefaultResolver().EndpointFor(service, region)
+	if err == nil {
+		if u, err := url.Parse(resolved.URL); err == nil && u.Host != "" {
+			return u.Host
+		}
+	}
+	return service + "." + region + ".amazonaws.com"
+}
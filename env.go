@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/codacy/ssmeb/pkg/render"
+	"github.com/spf13/cobra"
+)
+
+var envUnset bool
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Print shell export statements for the parameters, to be evaluated by the shell",
+	Long: `Print shell export statements for the parameters, to be evaluated by the shell.
+
+With --unset, print the statements removing the same variables instead,
+which doesn't require access to the store.`,
+	Example: `  eval "$(ssmeb env -i params.yaml -e staging)"
+  eval "$(ssmeb env -i params.yaml --unset)"`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("input", inputName(), "environment", environment)
+		return runEnv(envUnset)
+	},
+}
+
+func init() {
+	envCmd.Flags().BoolVar(&envUnset, "unset", false, "print unset statements for the parameters instead")
+	rootCmd.AddCommand(envCmd)
+}
+
+// runEnv prints the export statements for the resolved parameters, or the unset ones if unset is true
+func runEnv(unset bool) error {
+	var statements []byte
+	if unset {
+		parameters, err := loadParameters()
+		if err != nil {
+			return err
+		}
+		var options []render.Option
+		for _, par := range append(parameters.All(), parameters.Derived...) {
+			if par.IsWildcard() {
+				// the names of its children are only known by the store
+				continue
+			}
+			options = append(options, render.Option{Name: parameters.Naming.Apply(par.Name)})
+		}
+		if statements, err = render.Unsets(options); err != nil {
+			return err
+		}
+	} else {
+		options, err := resolveOptions()
+		if err != nil {
+			return err
+		}
+		if statements, err = render.Exports(options); err != nil {
+			return err
+		}
+	}
+
+	_, err := os.Stdout.Write(statements)
+	if err != nil {
+		return fmt.Errorf("Error writing statements: %v", err)
+	}
+	return nil
+}
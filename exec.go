@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec -- command [args...]",
+	Short: "Run a command with the parameters injected as environment variables",
+	Long: `Run a command with the parameters injected as environment variables.
+
+The values are never written to disk: they are only passed to the environment
+of the command, on top of the current one. The exit code of the command is
+returned by ssmeb.`,
+	Example: "  ssmeb exec -i params.yaml -e staging -- ./myapp --port 8080",
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("input", inputName(), "environment", environment, "command", args[0])
+		return runExec(args[0], args[1:])
+	},
+}
+
+func init() {
+	// flags after the command name belong to the command
+	execCmd.Flags().SetInterspersed(false)
+	rootCmd.AddCommand(execCmd)
+}
+
+// runExec runs name with the resolved parameters in its environment, exiting with its exit code
+func runExec(name string, args []string) error {
+	options, err := resolveOptions()
+	if err != nil {
+		return err
+	}
+
+	env := os.Environ()
+	for _, option := range options {
+		env = append(env, option.Name+"="+option.Value)
+	}
+
+	child := exec.Command(name, args...)
+	child.Env = env
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	// forward the signals received, so the command can shutdown gracefully
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+	defer signal.Stop(signals)
+
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("Error starting `%s`: %v", name, err)
+	}
+	go func() {
+		for sig := range signals {
+			child.Process.Signal(sig)
+		}
+	}()
+
+	err = child.Wait()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		os.Exit(exitErr.ExitCode())
+	}
+	if err != nil {
+		return fmt.Errorf("Error running `%s`: %v", name, err)
+	}
+	return nil
+}
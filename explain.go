@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/resolver"
+	"github.com/codacy/ssmeb/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+var explainShowValues bool
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <option_name>",
+	Short: "Explain where the value of a single option comes from",
+	Long: `Explain where the value of a single option comes from.
+
+The entry of the option in the input is printed along with the file defining
+it, its path as written and as effective in the environment, the environment
+overrides applied, the version and label got from the store, who last modified
+it in SSM, and the options of the output it feeds. The name is the
+option_name in the input, or the one given by its naming. Secret values are
+left out, unless --show-values prints them.`,
+	Example: `  ssmeb explain -i params.yaml -e production DB_HOST`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runExplain(args[0])
+	},
+}
+
+func init() {
+	explainCmd.Flags().BoolVar(&explainShowValues, "show-values", false, "print the value even if it's a secret")
+	rootCmd.AddCommand(explainCmd)
+}
+
+// runExplain prints where the option with the given name, in the input or after its
+// naming, comes from
+func runExplain(name string) error {
+	parameters, err := loadParameters()
+	if err != nil {
+		return err
+	}
+	parameters, err = expandPlaceholders(parameters)
+	if err != nil {
+		return err
+	}
+	var par config.Parameter
+	section := ""
+	for i, candidate := range append(parameters.All(), parameters.Derived...) {
+		if candidate.IsWildcard() || candidate.Name != name && parameters.Naming.Apply(candidate.Name) != name {
+			continue
+		}
+		par = candidate
+		switch {
+		case i < len(parameters.Component):
+			section = "component"
+		case i < len(parameters.All()):
+			section = "external"
+		default:
+			section = "derived"
+		}
+		break
+	}
+	if section == "" {
+		return fmt.Errorf("Unknown option `%s`", name)
+	}
+
+	explain := func(field string, value string) {
+		fmt.Printf("%-13s %s\n", field+":", value)
+	}
+	explain("option", fmt.Sprintf("%s, named %s in the output", par.Name, parameters.Naming.Apply(par.Name)))
+	file, err := definingFile(par.Name)
+	if err != nil {
+		return err
+	}
+	explain("entry", fmt.Sprintf("%s parameter in %s", section, file))
+
+	raw, err := readComponentParameters()
+	if err != nil {
+		return err
+	}
+	explanations, err := raw.Explain(environment)
+	if err != nil {
+		return err
+	}
+	for _, explanation := range explanations {
+		if explanation.Parameter.Name != par.Name {
+			continue
+		}
+		explain("fields", describeOrigins(explanation.Origins))
+		if section != "derived" {
+			for _, written := range raw.All() {
+				if written.Name == par.Name {
+					explain("path", fmt.Sprintf("%s as written, %s effective", written.Path, par.Path))
+					break
+				}
+			}
+		}
+	}
+	if section == "derived" {
+		explain("composed of", strings.Join(config.References(par.Value), ", "))
+	}
+	if len(par.Transforms) > 0 {
+		explain("transforms", strings.Join(par.Transforms, ", then "))
+	}
+
+	selected, err := parameters.Select([]string{par.Name}, nil)
+	if err != nil {
+		return err
+	}
+	values, err := resolveParameters(selected)
+	if err != nil {
+		return err
+	}
+	var value resolver.Value
+	found := false
+	for _, candidate := range values {
+		if candidate.Parameter.Name == parameters.Naming.Apply(par.Name) {
+			value, found = candidate, true
+		}
+	}
+	switch {
+	case !found:
+		explain("value", "missing from the store, left out as optional")
+	case value.Stored.Secret && !explainShowValues:
+		explain("value", "(secret, shown with --show-values)")
+	default:
+		explain("value", strconv.Quote(value.Stored.Value))
+	}
+	if found && section != "derived" && resolveSummary.Defaulted > 0 {
+		explain("got", "the default, missing from the store")
+	} else if found && section != "derived" {
+		got := "latest version"
+		if value.Stored.Version > 0 {
+			got = fmt.Sprintf("version %d", value.Stored.Version)
+		}
+		if scheme, _ := store.SplitScheme(par.Path); getLabel != "" && section == "component" && scheme == "" {
+			got += fmt.Sprintf(", label %s", getLabel)
+		}
+		explain("got", got)
+		if modified := lastModified(par, value.Stored.Version); modified != "" {
+			explain("modified", modified)
+		}
+	}
+
+	feeds := []string{parameters.Naming.Apply(par.Name)}
+	for _, derived := range parameters.Derived {
+		for _, reference := range config.References(derived.Value) {
+			if reference == par.Name {
+				feeds = append(feeds, parameters.Naming.Apply(derived.Name))
+				break
+			}
+		}
+	}
+	explain("feeds", strings.Join(feeds, ", "))
+	return nil
+}
+
+// definingFile returns the input file defining the option with the given name, along
+// with the files it includes
+func definingFile(name string) (string, error) {
+	filenames, err := inputFiles()
+	if err != nil {
+		return "", err
+	}
+	for _, filename := range filenames {
+		parameters, err := readInputFile(filename)
+		if err != nil {
+			return "", err
+		}
+		for _, par := range append(parameters.All(), parameters.Derived...) {
+			if par.Name == name {
+				return filename, nil
+			}
+		}
+	}
+	return inputName(), nil
+}
+
+// lastModified tells who wrote the version of the parameter in SSM and when, or is
+// empty when it can't be known, e.g. for parameters in other stores
+func lastModified(par config.Parameter, version int64) string {
+	if scheme, _ := store.SplitScheme(par.Path); !canDescribe() || scheme != "" || par.Credentials() != "" {
+		return ""
+	}
+	var modified string
+	input := &ssm.GetParameterHistoryInput{Name: aws.String(par.Path)}
+	err := ssm.New(newSession()).GetParameterHistoryPages(input, func(page *ssm.GetParameterHistoryOutput, lastPage bool) bool {
+		for _, history := range page.Parameters {
+			if version == 0 || aws.Int64Value(history.Version) == version {
+				modified = fmt.Sprintf("%s by %s", aws.TimeValue(history.LastModifiedDate).UTC().Format("2006-01-02 15:04:05 MST"), aws.StringValue(history.LastModifiedUser))
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return ""
+	}
+	return modified
+}
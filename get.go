@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/render"
+	"github.com/codacy/ssmeb/pkg/resolver"
+	"github.com/codacy/ssmeb/pkg/s3io"
+	"github.com/spf13/cobra"
+)
+
+var (
+	getOutputs   []string
+	getWatch     bool
+	sortOutput   bool
+	outputMode   string
+	getHeader    bool
+	getTemplate  string
+	getComments  bool
+	keepGoing    bool
+	skipMissing  bool
+	getLabel     string
+	getSum       bool
+	getCheckSum  bool
+	getSumFile   string
+	getKMSKey    string
+	getEncrypt   string
+	getOutputDir string
+)
+
+var getCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Get the parameters from SSM and render them as elastic beanstalk options",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(matrixEnvironments) > 0 {
+			if getWatch {
+				return fmt.Errorf("Can't watch the input with --environments")
+			}
+			specs, err := parseOutputs(getOutputs, "")
+			if err != nil {
+				return err
+			}
+			paths := []string{getOutputDir}
+			for _, spec := range specs {
+				if spec.Format != githubEnvFormat {
+					paths = append(paths, spec.Path)
+				}
+			}
+			if getSum || getCheckSum {
+				paths = append(paths, getSumFile)
+			}
+			if err := checkEnvironmentPaths("output", paths...); err != nil {
+				return err
+			}
+		}
+		return forEachEnvironment(cmd, func() error {
+			printSettings("input", inputName(), "output", strings.Join(getOutputs, " "), "environment", environment)
+			if getWatch {
+				for _, input := range inputs {
+					if input == stdinInput {
+						return fmt.Errorf("Can't watch the input read from stdin")
+					}
+					if isRemoteInput(input) {
+						return fmt.Errorf("Can't watch the remote input `%s`", input)
+					}
+				}
+				return watchGet(getOutputs)
+			}
+			return runGet(getOutputs)
+		})
+	},
+}
+
+func init() {
+	var defaultOutputs []string
+	if output := getEnv("SSMEB_OUTPUT", ""); output != "" {
+		defaultOutputs = []string{output}
+	}
+	getCmd.Flags().StringArrayVarP(&getOutputs, "output", "o", defaultOutputs, "destination of the resulting elastic beanstalk data (defaults to stdout), or `format=<format>,path=<file>` to write another format, with `target=<name>` to write the options with that target, can be repeated")
+	getCmd.Flags().StringVar(&getOutputDir, "output-dir", "", "write the outputs of every component of the input under this directory, in a directory named after each one, with the output paths relative to it")
+	getCmd.Flags().BoolVarP(&getWatch, "watch", "w", false, "keep running, regenerating the output whenever an input file, or one it includes, changes")
+	getCmd.Flags().BoolVar(&sortOutput, "sort", false, "sort the options by name, so the output doesn't depend on the input order")
+	getCmd.Flags().StringVar(&getTemplate, "template", "", "Go template file rendering the outputs without a format, with the sprig functions available")
+	getCmd.Flags().BoolVar(&keepGoing, "keep-going", false, "try every parameter after one fails, reporting all the failures at the end")
+	getCmd.Flags().BoolVar(&skipMissing, "skip-missing", false, "leave out the external parameters missing from the store, with a warning, instead of failing")
+	getCmd.Flags().StringVar(&getLabel, "label", "", "get the component parameters at the versions with this label in SSM, e.g. live")
+	getCmd.Flags().BoolVar(&getComments, "descriptions", false, "write the description of each parameter as a comment above its option in the elastic beanstalk output")
+	getCmd.Flags().BoolVar(&getSum, "sum", false, "write a digest of the values, not the values themselves, to the sum file")
+	getCmd.Flags().BoolVar(&getCheckSum, "check-sum", false, "fail if the values differ from the digest in the sum file, without writing any output")
+	getCmd.Flags().StringVar(&getSumFile, "sum-file", defaultSumFile, "file recording the digest of the values")
+	getCmd.Flags().BoolVar(&getHeader, "header", false, "prepend a comment with the version, input hash, environment, time and checksum of the output")
+	getCmd.Flags().StringVar(&getKMSKey, "s3-kms-key", getEnv("SSMEB_S3_KMS_KEY", ""), "id, ARN or alias of the KMS key encrypting the outputs written to s3:// urls (defaults to SSE-S3)")
+	getCmd.Flags().StringVar(&getEncrypt, "encrypt-output", "", encryptOutputUsage)
+	addEnvironmentsFlag(getCmd)
+	getCmd.Flags().StringVar(&outputMode, "output-mode", getEnv("SSMEB_OUTPUT_MODE", ""), outputModeUsage)
+	rootCmd.AddCommand(getCmd)
+}
+
+// runGet fetches the parameters in the input file once and writes them to each of
+// the outputs, which default to beanstalk options written to stdout
+func runGet(outputs []string) error {
+	defaultFormat := "ebyaml"
+	var templateText string
+	if getTemplate != "" {
+		data, err := ioutil.ReadFile(getTemplate)
+		if err != nil {
+			return fmt.Errorf("Error reading file `%s`: %v", getTemplate, err)
+		}
+		defaultFormat, templateText = templateFormat, string(data)
+	}
+
+	specs, err := parseOutputs(outputs, defaultFormat)
+	if err != nil {
+		return err
+	}
+	for i, spec := range specs {
+		specs[i].Path = withEnvironment(spec.Path)
+		if spec.Format == templateFormat && getTemplate == "" {
+			return fmt.Errorf("Missing argument for the `%s` format: `template`", templateFormat)
+		}
+		if spec.Format != templateFormat && spec.Format != githubEnvFormat && !render.HasFormat(spec.Format) {
+			return fmt.Errorf("Invalid output format `%s`, expected one of %v", spec.Format, render.Formats())
+		}
+	}
+
+	resolveSummary = resolver.Summary{}
+	defer func() { printSummary(resolveSummary) }()
+	if getOutputDir != "" {
+		return getComponents(withEnvironment(getOutputDir), specs, len(outputs) == 0, templateText)
+	}
+	options, err := resolveOptions()
+	if err != nil {
+		return err
+	}
+	if getCheckSum {
+		return checkSum(withEnvironment(getSumFile), options)
+	}
+	if err := writeOutputs(specs, options, templateText); err != nil {
+		return err
+	}
+	if getSum {
+		return writeSum(withEnvironment(getSumFile), options)
+	}
+	return nil
+}
+
+// writeOutputs renders the options of each output in its format and writes them to its
+// file, or to stdout
+func writeOutputs(specs []outputSpec, options []render.Option, templateText string) error {
+	mode, err := outputFileMode(options)
+	if err != nil {
+		return err
+	}
+
+	warnUnwrittenTargets(specs, options)
+	for _, spec := range specs {
+		if spec.Format == githubEnvFormat {
+			if err := writeGitHubEnv(spec.Path, optionsFor(spec, options)); err != nil {
+				return err
+			}
+			continue
+		}
+		var data []byte
+		if spec.Format == templateFormat {
+			data, err = renderTemplate(templateText, optionsFor(spec, options))
+		} else {
+			data, err = renderFormat(spec.Format, optionsFor(spec, options))
+		}
+		if err != nil {
+			return err
+		}
+		if getHeader && hasComments(spec.Format) {
+			data, err = generationHeader(data)
+			if err != nil {
+				return err
+			}
+		}
+		if getEncrypt != "" {
+			data, err = encryptOutput(getEncrypt, data, spec.Format, spec.Path)
+			if err != nil {
+				return err
+			}
+		}
+		if spec.Path == "" {
+			fmt.Println(string(data))
+			continue
+		}
+		if s3io.IsURL(spec.Path) {
+			err = writeToS3(spec.Path, data, getKMSKey)
+		} else {
+			err = writeToFile(spec.Path, data, mode)
+		}
+		if err != nil {
+			return fmt.Errorf("Error writing to file `%s`: %v", spec.Path, err)
+		}
+	}
+	return nil
+}
+
+// renderOutput renders the options as an elastic beanstalk extensions config file,
+// sorting them first if requested
+func renderOutput(options []render.Option) ([]byte, error) {
+	return renderFormat("ebyaml", options)
+}
+
+// renderFormat renders the options in the given format, sorting them first if requested.
+// Elastic beanstalk options are documented with the descriptions if requested.
+func renderFormat(format string, options []render.Option) ([]byte, error) {
+	if sortOutput {
+		render.Sort(options)
+	}
+	var data []byte
+	var err error
+	if getComments && format == "ebyaml" {
+		data, err = render.DocumentedEBYAML(options)
+	} else {
+		data, err = render.Render(format, options)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error rendering %s output: %v", format, err)
+	}
+	return data, nil
+}
+
+// renderTemplate renders the options through the template given in the template flag,
+// sorting them first if requested
+func renderTemplate(text string, options []render.Option) ([]byte, error) {
+	if sortOutput {
+		render.Sort(options)
+	}
+	data, err := render.Template(filepath.Base(getTemplate), text, options)
+	if err != nil {
+		return nil, fmt.Errorf("Error rendering template `%s`: %v", getTemplate, err)
+	}
+	return data, nil
+}
+
+// watchInterval is how often the input files are checked for changes in watch mode
+const watchInterval = time.Second
+
+// watchGet runs get every time an input file, or a file it includes, is modified, until
+// interrupted. Errors are logged, so a mistake while editing a file, or a file missing
+// for a moment while an editor saves it, doesn't stop the watch.
+func watchGet(outputs []string) error {
+	lastModified := map[string]time.Time{}
+	var lastErr string
+	for ; ; time.Sleep(watchInterval) {
+		current, err := watchedFiles()
+		if err != nil {
+			// the error is logged once, and the files checked again until it's gone
+			if err.Error() != lastErr {
+				log.Print(err)
+				lastErr = err.Error()
+			}
+			continue
+		}
+		lastErr = ""
+		modified := len(current) != len(lastModified)
+		for filename, modTime := range current {
+			if !modTime.Equal(lastModified[filename]) {
+				modified = true
+			}
+		}
+		if !modified {
+			continue
+		}
+		lastModified = current
+
+		if err := runGet(outputs); err != nil {
+			log.Print(err)
+		}
+		fmt.Fprintf(os.Stderr, "Watching `%s` for changes...\n", inputName())
+	}
+}
+
+// watchedFiles returns the modification time of the input files and of the files they
+// include. Files whose includes can't be read are watched with the ones found, so fixing
+// them is noticed.
+func watchedFiles() (map[string]time.Time, error) {
+	filenames, err := inputFiles()
+	if err != nil {
+		return nil, err
+	}
+	modified := map[string]time.Time{}
+	for _, filename := range filenames {
+		files, _ := config.Files(filename, inputFormat)
+		for _, file := range files {
+			info, err := os.Stat(file)
+			if err != nil {
+				return nil, fmt.Errorf("Error reading file `%s`: %v", file, err)
+			}
+			modified[file] = info.ModTime()
+		}
+	}
+	return modified, nil
+}
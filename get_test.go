@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWatchedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssmeb-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	params := filepath.Join(dir, "params.yaml")
+	shared := filepath.Join(dir, "shared.yaml")
+	if err := ioutil.WriteFile(params, []byte("include: [shared.yaml]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(i []string) { inputs = i }(inputs)
+	inputs = []string{params}
+
+	if _, err := watchedFiles(); err == nil {
+		t.Errorf("watchedFiles() error = nil, want the error of the missing include")
+	}
+
+	if err := ioutil.WriteFile(shared, []byte("component: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files, err := watchedFiles()
+	if err != nil {
+		t.Fatalf("watchedFiles() error = %v", err)
+	}
+	for _, file := range []string{params, shared} {
+		if _, ok := files[file]; !ok {
+			t.Errorf("watchedFiles() = %v, want %s watched", files, file)
+		}
+	}
+}
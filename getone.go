@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/codacy/ssmeb/pkg/render"
+	"github.com/codacy/ssmeb/pkg/resolver"
+	"github.com/spf13/cobra"
+)
+
+var (
+	getOneRaw  bool
+	getOneCopy bool
+)
+
+var getOneCmd = &cobra.Command{
+	Use:   "get-one <option_name>",
+	Short: "Get a single parameter of the input and print its value",
+	Long: `Get a single parameter of the input and print its value.
+
+Only the parameter named is got from the store, plus the ones it references if
+it's derived, so quick lookups don't fetch the whole file. The name is the
+option_name in the input, or the one given by its naming. The option is printed
+as a .env line, or only its value with --raw, for use in shell substitutions.
+With --copy, the value is put on the system clipboard instead of printed.`,
+	Example: `  ssmeb get-one -i params.yaml -e production DB_HOST
+  psql -h "$(ssmeb get-one -i params.yaml -e production DB_HOST --raw)"
+  ssmeb get-one -i params.yaml -e production API_KEY --copy`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		option, err := getOne(args[0])
+		if err != nil {
+			return err
+		}
+		if getOneCopy {
+			if err := copyToClipboard(option.Value); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Copied the value of `%s` to the clipboard\n", option.Name)
+			return nil
+		}
+		if getOneRaw {
+			_, err = os.Stdout.WriteString(option.Value)
+			return err
+		}
+		data, err := render.Dotenv([]render.Option{option})
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	},
+}
+
+func init() {
+	getOneCmd.Flags().BoolVar(&getOneRaw, "raw", false, "print only the value, without a trailing newline")
+	getOneCmd.Flags().BoolVar(&getOneCopy, "copy", false, "put the value on the system clipboard instead of printing it")
+	rootCmd.AddCommand(getOneCmd)
+}
+
+// getOne gets the option with the given name, in the input or after its naming, from the store
+func getOne(name string) (render.Option, error) {
+	parameters, err := loadParameters()
+	if err != nil {
+		return render.Option{}, err
+	}
+	selected := name
+	for _, par := range append(parameters.All(), parameters.Derived...) {
+		if !par.IsWildcard() && parameters.Naming.Apply(par.Name) == name {
+			selected = par.Name
+			break
+		}
+	}
+	parameters, err = parameters.Select([]string{selected}, nil)
+	if err != nil {
+		return render.Option{}, err
+	}
+	known := false
+	for _, par := range append(parameters.All(), parameters.Derived...) {
+		// wildcards are kept by Select, whatever the names of their children
+		known = known || !par.IsWildcard() || par.MayExpandTo(selected)
+	}
+	if !known {
+		return render.Option{}, fmt.Errorf("Unknown option `%s`", name)
+	}
+
+	values, err := resolveParameters(parameters)
+	if err != nil {
+		return render.Option{}, err
+	}
+	for _, option := range resolver.Options(values) {
+		if option.Name == name || option.Name == parameters.Naming.Apply(name) {
+			return option, nil
+		}
+	}
+	return render.Option{}, fmt.Errorf("Option `%s` not found, it may be optional and missing from the store", name)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/codacy/ssmeb/pkg/render"
+)
+
+// githubEnvFormat is the format of the outputs appended to the file in $GITHUB_ENV,
+// setting the options as environment variables of the next steps of a GitHub Actions job
+const githubEnvFormat = "gha"
+
+// writeGitHubEnv masks the secret options in the logs of GitHub Actions and appends the
+// other ones to the file, which defaults to the one in $GITHUB_ENV. The secret ones
+// aren't appended, as the environment is readable by every later step.
+func writeGitHubEnv(path string, options []render.Option) error {
+	if path == "" {
+		path = os.Getenv("GITHUB_ENV")
+	}
+	if path == "" {
+		return fmt.Errorf("The `%s` format needs a path, or GITHUB_ENV set as in GitHub Actions", githubEnvFormat)
+	}
+	if _, err := os.Stdout.Write(render.GitHubMasks(options)); err != nil {
+		return fmt.Errorf("Error masking the secrets: %v", err)
+	}
+
+	var exported []render.Option
+	for _, option := range options {
+		if !option.Secret {
+			exported = append(exported, option)
+		}
+	}
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return err
+	}
+	data, err := render.GitHubEnv(exported, "ssmeb_"+hex.EncodeToString(random))
+	if err != nil {
+		return fmt.Errorf("Error rendering %s output: %v", githubEnvFormat, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err == nil {
+		_, err = file.Write(data)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("Error appending to file `%s`: %v", path, err)
+	}
+	fmt.Fprintf(os.Stderr, "%d option(s) appended successfully to `%s`", len(exported), path)
+	if secrets := len(options) - len(exported); secrets > 0 {
+		fmt.Fprintf(os.Stderr, ", %d secret one(s) only masked", secrets)
+	}
+	fmt.Fprintln(os.Stderr)
+	return nil
+}
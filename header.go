@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// generationHeader prepends to body a comment describing how it was generated, so a
+// deployed file can be traced back to the inputs that produced it. The checksum
+// covers the body only, allowing it to be verified after stripping the header.
+func generationHeader(body []byte) ([]byte, error) {
+	filenames, err := inputFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var header bytes.Buffer
+	fmt.Fprintf(&header, "# Generated by ssmeb %s (commit %s), do not edit\n", version, commit)
+	for _, filename := range filenames {
+		data, err := readInput(filename)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading file `%s`: %v", filename, err)
+		}
+		fmt.Fprintf(&header, "# input: %s (sha256:%x)\n", filename, sha256.Sum256(data))
+	}
+	fmt.Fprintf(&header, "# environment: %s\n", environment)
+	generated, ok, err := generationTime()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		fmt.Fprintf(&header, "# generated: %s\n", generated.Format(time.RFC3339))
+	}
+	fmt.Fprintf(&header, "# checksum: sha256:%x\n", sha256.Sum256(body))
+	return append(header.Bytes(), body...), nil
+}
+
+// stripHeader returns data without the header added by generationHeader, if it has one
+func stripHeader(data []byte) []byte {
+	if !bytes.HasPrefix(data, []byte("# Generated by ssmeb ")) {
+		return data
+	}
+	for bytes.HasPrefix(data, []byte("#")) {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			return nil
+		}
+		data = data[end+1:]
+	}
+	return data
+}
+
+// generationTime returns the time recorded in generated files, which is the current
+// time. In reproducible mode it's read from SOURCE_DATE_EPOCH instead, and ok is false
+// if that's unset, meaning no time should be recorded.
+func generationTime() (t time.Time, ok bool, err error) {
+	if !reproducible {
+		return time.Now().UTC(), true, nil
+	}
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Time{}, false, nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("Invalid SOURCE_DATE_EPOCH `%s`: %v", epoch, err)
+	}
+	return time.Unix(seconds, 0).UTC(), true, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+var importEBCmd = &cobra.Command{
+	Use:   "import-eb <eb-environment>",
+	Short: "Convert the environment properties of an elastic beanstalk environment into a parameters file",
+	Long: `Convert the environment properties of an elastic beanstalk environment into a
+parameters file, optionally storing their values.
+
+Each property of the running environment becomes a component parameter stored
+under the path prefix, in a path named after the property in lower case.
+
+` + migrationHelp,
+	Example: `  ssmeb import-eb myapp-production --path-prefix /myservice -o params.yaml -e production --script put-parameters.sh
+  ssmeb import-eb myapp-production --path-prefix /myservice -o params.yaml -e production --set`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("eb environment", args[0], "output", migrateOutput, "environment", environment)
+		return runImportEB(args[0])
+	},
+}
+
+func init() {
+	addMigrationFlags(importEBCmd)
+	rootCmd.AddCommand(importEBCmd)
+}
+
+// runImportEB adds the environment properties of the elastic beanstalk environment
+// as parameters to the output, storing their values if requested
+func runImportEB(ebEnvironment string) error {
+	properties, err := ebEnvironmentProperties(ebEnvironment)
+	if err != nil {
+		return fmt.Errorf("Error describing environment `%s`: %v", ebEnvironment, err)
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	variables := make([]envVariable, 0, len(names))
+	for _, name := range names {
+		variables = append(variables, envVariable{Name: name, Value: properties[name]})
+	}
+	return migrateVariables(variables)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	initOutput string
+	initForce  bool
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create a parameters file, asking for the component, its environments and parameters",
+	Long: `Create a parameters file, asking for the component, its environments and parameters.
+
+The parameters are stored under a path named after the component, with the
+environment either before it, as added by --environment, or after it, through
+the {environment} placeholder. Parameters used in some environments only list
+them in only_environments.`,
+	Example: `  ssmeb init -o params.yaml`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runInit(&prompter{reader: bufio.NewReader(os.Stdin), out: os.Stderr})
+	},
+}
+
+func init() {
+	initCmd.Flags().StringVarP(&initOutput, "output", "o", "params.yaml", "parameters file to create")
+	initCmd.Flags().BoolVar(&initForce, "force", false, "overwrite the output file if it exists")
+	rootCmd.AddCommand(initCmd)
+}
+
+// runInit asks for the contents of a new parameters file and writes it
+func runInit(p *prompter) error {
+	if _, err := os.Stat(initOutput); err == nil && !initForce {
+		return fmt.Errorf("`%s` already exists, use --force to overwrite it", initOutput)
+	}
+
+	component, err := p.ask("Component name, e.g. myservice", "")
+	if err != nil {
+		return err
+	}
+	if component == "" {
+		return fmt.Errorf("A component name is required")
+	}
+	environments, err := p.askList("Environments, comma separated (blank for none)")
+	if err != nil {
+		return err
+	}
+	prefix := "/" + strings.Trim(component, "/")
+	if len(environments) > 0 {
+		layout, err := p.ask(fmt.Sprintf("Environment in paths: before (/staging%s/...) or after (%s/staging/...)", prefix, prefix), "before")
+		if err != nil {
+			return err
+		}
+		switch layout {
+		case "before":
+		case "after":
+			prefix += "/{" + config.PlaceholderEnvironment + "}"
+		default:
+			return fmt.Errorf("Invalid layout `%s`, expected `before` or `after`", layout)
+		}
+	}
+
+	document, err := config.ParseDocument(nil)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(p.out, "Add the parameters of the component, leaving the name blank to finish.")
+	for {
+		par, err := p.askParameter(prefix, environments)
+		if err != nil {
+			return err
+		}
+		if par.Name == "" {
+			break
+		}
+		if err := document.Add("component", par); err != nil {
+			return err
+		}
+	}
+
+	parameters, err := document.Parameters()
+	if err != nil {
+		return err
+	}
+	if problems := parameters.Validate(); len(problems) > 0 {
+		return fmt.Errorf("The parameters are not valid: %s", strings.Join(problems, ", "))
+	}
+	data, err := document.Bytes()
+	if err != nil {
+		return err
+	}
+	err = writeToFile(initOutput, data, 0644)
+	if err != nil {
+		return fmt.Errorf("Error writing to file `%s`: %v", initOutput, err)
+	}
+	return nil
+}
+
+// askParameter asks for the fields of a component parameter, whose path defaults to
+// one under prefix named after it. An empty name means there are no more parameters.
+func (p *prompter) askParameter(prefix string, environments []string) (config.Parameter, error) {
+	var par config.Parameter
+	var err error
+	par.Name, err = p.ask("Option name", "")
+	if err == io.EOF || par.Name == "" {
+		// the end of the input also finishes the parameters
+		return config.Parameter{}, nil
+	}
+	if err != nil {
+		return par, err
+	}
+	if par.Description, err = p.ask("Description", ""); err != nil {
+		return par, err
+	}
+	if par.Path, err = p.ask("Path", prefix+"/"+strings.ToLower(par.Name)); err != nil {
+		return par, err
+	}
+	secret, err := p.ask("Secret, stored as a SecureString (y/n)", "n")
+	if err != nil {
+		return par, err
+	}
+	if strings.HasPrefix(strings.ToLower(secret), "y") {
+		par.Type = config.TypeSecureString
+	}
+	if len(environments) > 0 {
+		if par.OnlyEnvironments, err = p.askList("Only in the environments, comma separated (blank for all)"); err != nil {
+			return par, err
+		}
+	}
+	return par, nil
+}
+
+// prompter asks the user questions, reading the answers line by line
+type prompter struct {
+	reader *bufio.Reader
+	out    io.Writer
+}
+
+// ask prints the question and returns the answer, or fallback if it's blank
+func (p *prompter) ask(question string, fallback string) (string, error) {
+	if fallback != "" {
+		fmt.Fprintf(p.out, "* %s [%s]: ", question, fallback)
+	} else {
+		fmt.Fprintf(p.out, "* %s: ", question)
+	}
+	text, err := p.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || text == "") {
+		return "", err
+	}
+	if answer := strings.TrimSpace(text); answer != "" {
+		return answer, nil
+	}
+	return fallback, nil
+}
+
+// askList is like ask for answers holding a comma separated list
+func (p *prompter) askList(question string) ([]string, error) {
+	answer, err := p.ask(question, "")
+	if err != nil || answer == "" {
+		return nil, err
+	}
+	var list []string
+	for _, item := range strings.Split(answer, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list, nil
+}
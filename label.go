@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	promoteFrom string
+	promoteTo   string
+)
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote",
+	Short: "Move a label of the component parameters to the versions with another label",
+	Long: `Move a label of the component parameters to the versions with another label.
+
+This is a blue/green workflow for the configuration: new values are written
+with ` + "`set --label staging`" + `, checked with ` + "`get --label staging`" + `, and then
+made live by moving the live label to them, which the environments read with
+` + "`get --label live`" + `. Every parameter is checked to have a version with the
+--from label before any label is moved, so a missing one leaves them all as
+they were. If moving a label fails, the ones already moved are moved back to
+their previous versions. Parameters that had no version with the --to label keep
+it on the promoted version, as labels can't be removed, and are listed in the error.`,
+	Example: `  ssmeb promote -i params.yaml -e production --from staging --to live`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("input", inputName(), "environment", environment, "from", promoteFrom, "to", promoteTo)
+		return runPromote(promoteFrom, promoteTo)
+	},
+}
+
+func init() {
+	promoteCmd.Flags().StringVar(&promoteFrom, "from", "staging", "label of the versions to promote")
+	promoteCmd.Flags().StringVar(&promoteTo, "to", "live", "label moved to the promoted versions")
+	rootCmd.AddCommand(promoteCmd)
+}
+
+// runPromote moves the `to` label of the component parameters in the input to their
+// versions with the `from` label
+func runPromote(from string, to string) error {
+	if from == "" || to == "" || from == to {
+		return fmt.Errorf("Expected two different labels to promote from and to")
+	}
+	if name, _ := splitBackend(backend); name != "ssm" || offline {
+		return fmt.Errorf("Labels can only be promoted with the `ssm` backend")
+	}
+	parameters, err := loadParameters()
+	if err != nil {
+		return err
+	}
+	parameters, err = expandPlaceholders(parameters)
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	for _, par := range parameters.Component {
+		if scheme, _ := store.SplitScheme(par.Path); scheme != "" || par.IsWildcard() {
+			fmt.Printf("* Skipping `%s`, only the parameters in SSM have labels\n", par.Path)
+			continue
+		}
+		paths = append(paths, par.Path)
+	}
+	return promoteLabel(ssm.New(newSession()), paths, from, to)
+}
+
+// promoteLabel attaches the `to` label to the versions of the parameters in paths with
+// the `from` label, once every parameter is found to have one. If labelling one fails,
+// the label is moved back to the versions it was on before.
+func promoteLabel(client ssmiface.SSMAPI, paths []string, from string, to string) error {
+	versions := make([]int64, len(paths))
+	// previous holds the versions with the `to` label before promoting, 0 if none
+	previous := make([]int64, len(paths))
+	var missing []string
+	for i, path := range paths {
+		version, err := labelledVersion(client, path, to)
+		if err != nil {
+			return err
+		}
+		previous[i] = version
+		if versions[i], err = labelledVersion(client, path, from); err != nil {
+			return err
+		}
+		if versions[i] == 0 {
+			missing = append(missing, path)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%d parameter(s) have no version with label `%s`, no label was moved:\n  %s", len(missing), from, strings.Join(missing, "\n  "))
+	}
+
+	for i, path := range paths {
+		fmt.Printf("* Labelling version %d of `%s` as `%s`... ", versions[i], path, to)
+		if err := labelVersion(client, path, versions[i], to); err != nil {
+			fmt.Println("FAILED")
+			err = fmt.Errorf("Error labelling `%s`: %v", path, err)
+			if restoreErr := restoreLabel(client, paths[:i], previous[:i], to); restoreErr != nil {
+				return fmt.Errorf("%v\n%v", err, restoreErr)
+			}
+			return err
+		}
+		fmt.Println("OK")
+	}
+	return nil
+}
+
+// labelVersion attaches the label to the version of the parameter in path. SSM reports
+// the labels it rejects, like the ones starting with a digit, in the output instead of
+// failing, so they're turned into an error.
+func labelVersion(client ssmiface.SSMAPI, path string, version int64, label string) error {
+	output, err := client.LabelParameterVersion(&ssm.LabelParameterVersionInput{
+		Name:             aws.String(path),
+		ParameterVersion: aws.Int64(version),
+		Labels:           aws.StringSlice([]string{label}),
+	})
+	if err == nil && len(output.InvalidLabels) > 0 {
+		err = fmt.Errorf("invalid label %s", strings.Join(aws.StringValueSlice(output.InvalidLabels), ", "))
+	}
+	return err
+}
+
+// labelledVersion returns the version of the parameter in path with the label, or 0 if
+// none has it
+func labelledVersion(client ssmiface.SSMAPI, path string, label string) (int64, error) {
+	output, err := client.GetParameter(&ssm.GetParameterInput{Name: aws.String(path + ":" + label)})
+	if err == nil {
+		return aws.Int64Value(output.Parameter.Version), nil
+	}
+	if aerr, ok := err.(awserr.Error); ok && (aerr.Code() == ssm.ErrCodeParameterNotFound || aerr.Code() == ssm.ErrCodeParameterVersionNotFound) {
+		return 0, nil
+	}
+	return 0, fmt.Errorf("Error getting `%s` with label `%s`: %v", path, label, err)
+}
+
+// restoreLabel moves the label back to the previous versions of the parameters in paths
+// after a failed promotion. The parameters that had no version with it keep it on the
+// promoted one, as SSM labels can be moved but this version of the SDK can't remove them.
+func restoreLabel(client ssmiface.SSMAPI, paths []string, previous []int64, label string) error {
+	var unrestored []string
+	for i, path := range paths {
+		if previous[i] == 0 {
+			unrestored = append(unrestored, path)
+			continue
+		}
+		fmt.Printf("* Restoring `%s` on version %d of `%s`... ", label, previous[i], path)
+		if err := labelVersion(client, path, previous[i], label); err != nil {
+			fmt.Println("FAILED")
+			unrestored = append(unrestored, fmt.Sprintf("%s (%v)", path, err))
+			continue
+		}
+		fmt.Println("OK")
+	}
+	if len(unrestored) > 0 {
+		return fmt.Errorf("%d parameter(s) keep label `%s` on the promoted version, it couldn't be moved back:\n  %s", len(unrestored), label, strings.Join(unrestored, "\n  "))
+	}
+	return nil
+}
+
+// withLabel returns the parameters with the paths of the component ones in SSM
+// selecting the version with the label, if it's not empty
+func withLabel(parameters config.Parameters, label string) config.Parameters {
+	if label == "" {
+		return parameters
+	}
+	component := make([]config.Parameter, len(parameters.Component))
+	for i, par := range parameters.Component {
+		if scheme, _ := store.SplitScheme(par.Path); scheme == "" && !par.IsWildcard() {
+			par.Path += ":" + label
+		}
+		component[i] = par
+	}
+	parameters.Component = component
+	return parameters
+}
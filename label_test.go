@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/codacy/ssmeb/pkg/ssmstore/ssmfake"
+)
+
+// labelledFake returns a fake SSM client with two versions of each path, the first one
+// labelled live and the second one labelled staging
+func labelledFake(paths ...string) *ssmfake.Client {
+	client := ssmfake.New()
+	for _, path := range paths {
+		client.Set(path, "v1")
+		client.Set(path, "v2")
+		for label, version := range map[string]int64{"live": 1, "staging": 2} {
+			client.LabelParameterVersion(&ssm.LabelParameterVersionInput{
+				Name:             aws.String(path),
+				ParameterVersion: aws.Int64(version),
+				Labels:           aws.StringSlice([]string{label}),
+			})
+		}
+	}
+	return client
+}
+
+func TestPromoteLabel(t *testing.T) {
+	paths := []string{"/staging/db/host", "/staging/db/port"}
+	client := labelledFake(paths...)
+
+	if err := promoteLabel(client, paths, "staging", "live"); err != nil {
+		t.Fatalf("promoteLabel() error = %v", err)
+	}
+	want := map[string]int64{"live": 2, "staging": 2}
+	for _, path := range paths {
+		if labels := client.Labels(path); !reflect.DeepEqual(labels, want) {
+			t.Errorf("labels of %s = %v, want %v", path, labels, want)
+		}
+	}
+}
+
+func TestPromoteLabelMissing(t *testing.T) {
+	client := labelledFake("/staging/db/host")
+	client.Set("/staging/db/port", "v1")
+
+	err := promoteLabel(client, []string{"/staging/db/host", "/staging/db/port"}, "staging", "live")
+	if err == nil || !strings.Contains(err.Error(), "/staging/db/port") {
+		t.Fatalf("promoteLabel() error = %v, want one listing /staging/db/port", err)
+	}
+	if labels := client.Labels("/staging/db/host"); labels["live"] != 1 {
+		t.Errorf("labels of /staging/db/host = %v, want live left on version 1", labels)
+	}
+}
+
+// failingLabelClient is a fake SSM client failing to label the parameter in path
+type failingLabelClient struct {
+	*ssmfake.Client
+	path string
+}
+
+func (c failingLabelClient) LabelParameterVersion(input *ssm.LabelParameterVersionInput) (*ssm.LabelParameterVersionOutput, error) {
+	if aws.StringValue(input.Name) == c.path {
+		return nil, errors.New("throttled")
+	}
+	return c.Client.LabelParameterVersion(input)
+}
+
+func TestPromoteLabelRestores(t *testing.T) {
+	paths := []string{"/staging/db/host", "/staging/db/user", "/staging/db/port"}
+	client := labelledFake(paths[0], paths[2])
+	client.Set(paths[1], "v1")
+	client.Set(paths[1], "v2")
+	client.LabelParameterVersion(&ssm.LabelParameterVersionInput{
+		Name:             aws.String(paths[1]),
+		ParameterVersion: aws.Int64(2),
+		Labels:           aws.StringSlice([]string{"staging"}),
+	})
+
+	err := promoteLabel(failingLabelClient{client, paths[2]}, paths, "staging", "live")
+	if err == nil || !strings.Contains(err.Error(), "throttled") || !strings.Contains(err.Error(), paths[1]) {
+		t.Fatalf("promoteLabel() error = %v, want the failure and /staging/db/user left labelled", err)
+	}
+	if labels := client.Labels(paths[0]); labels["live"] != 1 {
+		t.Errorf("labels of %s = %v, want live moved back to version 1", paths[0], labels)
+	}
+	if labels := client.Labels(paths[1]); labels["live"] != 2 {
+		t.Errorf("labels of %s = %v, want live kept on version 2, it had none before", paths[1], labels)
+	}
+	if labels := client.Labels(paths[2]); labels["live"] != 1 {
+		t.Errorf("labels of %s = %v, want live left on version 1", paths[2], labels)
+	}
+}
+
+func TestPromoteLabelInvalid(t *testing.T) {
+	paths := []string{"/staging/db/host", "/staging/db/port"}
+	client := labelledFake(paths...)
+
+	for _, label := range []string{"1abc", "aws-live", "live!"} {
+		err := promoteLabel(client, paths, "staging", label)
+		if err == nil || !strings.Contains(err.Error(), "invalid label "+label) {
+			t.Errorf("promoteLabel() to %s error = %v, want the label reported as invalid", label, err)
+		}
+	}
+	want := map[string]int64{"live": 1, "staging": 2}
+	for _, path := range paths {
+		if labels := client.Labels(path); !reflect.DeepEqual(labels, want) {
+			t.Errorf("labels of %s = %v, want %v", path, labels, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// legacyFlags are the flags only understood by the pre-subcommand interface: single
+// dash long flags, which the subcommands parse as shorthands, and the mode flag
+var legacyFlags = []string{"-input", "-output", "-environment", "-mode", "-m", "--mode", "-against"}
+
+// isLegacyInvocation reports whether args use the deprecated interface without
+// subcommands (e.g. `ssmeb -i template.yaml -m set`), which is kept working for one release.
+// Without args, it's configured only through the SSMEB_* environment variables when
+// SSMEB_MODE or SSMEB_INPUT is set.
+func isLegacyInvocation(args []string) bool {
+	if len(args) == 0 {
+		return getEnv("SSMEB_MODE", "") != "" || getEnv("SSMEB_INPUT", "") != ""
+	}
+	if !strings.HasPrefix(args[0], "-") {
+		return false
+	}
+
+	hasSubcommand := false
+	for _, arg := range args {
+		switch arg {
+		case "-h", "-help", "--help", "-v", "--version":
+			return false
+		}
+		name := strings.SplitN(arg, "=", 2)[0]
+		for _, legacy := range legacyFlags {
+			if name == legacy {
+				return true
+			}
+		}
+		for _, cmd := range rootCmd.Commands() {
+			if arg == cmd.Name() {
+				hasSubcommand = true
+			}
+		}
+	}
+	return !hasSubcommand
+}
+
+// runLegacy runs the deprecated interface, where the action is selected through the `mode` flag
+func runLegacy(args []string) error {
+	flags := flag.NewFlagSet("ssmeb", flag.ExitOnError)
+
+	var input string
+	flags.StringVar(&input, "input", getEnv("SSMEB_INPUT", ""), "input template environment variables config")
+	flags.StringVar(&input, "i", getEnv("SSMEB_INPUT", ""), "`input` flag shorthand")
+
+	var output string
+	flags.StringVar(&output, "output", getEnv("SSMEB_OUTPUT", ""), "destination of the resulting elastic beanstalk data")
+	flags.StringVar(&output, "o", getEnv("SSMEB_OUTPUT", ""), "`output` flag shorthand")
+
+	flags.StringVar(&environment, "environment", getEnv("SSMEB_ENVIRONMENT", ""), "environment name used as prefix for the ssm parameters (e.g. codacy)")
+	flags.StringVar(&environment, "e", getEnv("SSMEB_ENVIRONMENT", ""), "`environment` flag shorthand")
+
+	var mode string
+	flags.StringVar(&mode, "mode", getEnv("SSMEB_MODE", "get"), "enable get, set or verify mode")
+	flags.StringVar(&mode, "m", getEnv("SSMEB_MODE", "get"), "`mode` flag shorthand")
+
+	var against string
+	flags.StringVar(&against, "against", "", "committed output file checked by the verify mode")
+
+	flags.Parse(args)
+	inputs = nil
+	if input != "" {
+		inputs = []string{input}
+	}
+
+	fmt.Fprintf(os.Stderr, "Warning: running without a subcommand is deprecated and will be removed in the next release, use `ssmeb %s` instead\n", mode)
+	printSettings("input", input, "output", output, "environment", environment, "mode", mode)
+
+	switch mode {
+	case "get":
+		var outputs []string
+		if output != "" {
+			outputs = []string{output}
+		}
+		return runGet(outputs)
+	case "set":
+		return runSet()
+	case "verify":
+		return runVerify(against, "ebyaml")
+	default:
+		return fmt.Errorf("Invalid mode: %s", mode)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestIsLegacyInvocation(t *testing.T) {
+	tests := []struct {
+		args []string
+		env  map[string]string
+		want bool
+	}{
+		{nil, nil, false},
+		{nil, map[string]string{"SSMEB_MODE": "set"}, true},
+		{nil, map[string]string{"SSMEB_INPUT": "params.yaml", "SSMEB_ENVIRONMENT": "staging"}, true},
+		{nil, map[string]string{"SSMEB_ENVIRONMENT": "staging"}, false},
+		{[]string{"-i", "params.yaml", "-m", "set"}, nil, true},
+		{[]string{"-input", "params.yaml"}, nil, true},
+		{[]string{"-i", "params.yaml"}, nil, true},
+		{[]string{"get", "-i", "params.yaml"}, map[string]string{"SSMEB_MODE": "set"}, false},
+		{[]string{"-i", "params.yaml", "get"}, nil, false},
+		{[]string{"--help"}, map[string]string{"SSMEB_MODE": "set"}, false},
+	}
+	names := []string{"SSMEB_MODE", "SSMEB_INPUT", "SSMEB_ENVIRONMENT"}
+	for _, name := range names {
+		defer os.Setenv(name, os.Getenv(name))
+	}
+	for _, test := range tests {
+		for _, name := range names {
+			os.Setenv(name, test.env[name])
+		}
+		if got := isLegacyInvocation(test.args); got != test.want {
+			t.Errorf("isLegacyInvocation(%q) with %v = %v, want %v", test.args, test.env, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// matrixEnvironments are the environments the command is run for, one after the other,
+// instead of the one in the environment flag
+var matrixEnvironments []string
+
+// environmentPlaceholder is replaced with the environment in the paths of the outputs
+const environmentPlaceholder = "{environment}"
+
+// addEnvironmentsFlag adds the flag running the command for several environments
+func addEnvironmentsFlag(cmd *cobra.Command) {
+	var defaultEnvironments []string
+	if environments := getEnv("SSMEB_ENVIRONMENTS", ""); environments != "" {
+		defaultEnvironments = strings.Split(environments, ",")
+	}
+	cmd.Flags().StringSliceVar(&matrixEnvironments, "environments", defaultEnvironments, "run for each of these environments instead of the one in --environment, e.g. staging,production, ending with a summary of all of them")
+}
+
+// forEachEnvironment runs the command once for each of the environments of the
+// environments flag, or once if it's not given. Every environment is run even if some
+// fail, and a summary of the results is printed at the end.
+func forEachEnvironment(cmd *cobra.Command, run func() error) error {
+	if len(matrixEnvironments) == 0 {
+		return run()
+	}
+	if cmd.Flags().Changed("environment") {
+		return fmt.Errorf("Only one of `environment` and `environments` can be given")
+	}
+
+	results := make([]error, len(matrixEnvironments))
+	failed := 0
+	for i, name := range matrixEnvironments {
+		fmt.Fprintf(os.Stderr, "* Environment `%s`\n", name)
+		environment = name
+		if results[i] = run(); results[i] != nil {
+			fmt.Fprintf(os.Stderr, "Error in environment `%s`: %v\n", name, results[i])
+			failed++
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ENVIRONMENT\tRESULT")
+	for i, name := range matrixEnvironments {
+		result := "OK"
+		if results[i] != nil {
+			result = "FAILED: " + strings.SplitN(results[i].Error(), "\n", 2)[0]
+		}
+		fmt.Fprintf(w, "%s\t%s\n", name, result)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d environment(s) failed", failed, len(matrixEnvironments))
+	}
+	return nil
+}
+
+// checkEnvironmentPaths checks that the paths written for each environment have the
+// environment placeholder when running for several, so they don't overwrite each other
+func checkEnvironmentPaths(flag string, paths ...string) error {
+	if len(matrixEnvironments) < 2 {
+		return nil
+	}
+	for _, path := range paths {
+		if path != "" && !strings.Contains(path, environmentPlaceholder) {
+			return fmt.Errorf("With several environments, the %s `%s` needs the %s placeholder, so each one is written to its own file", flag, path, environmentPlaceholder)
+		}
+	}
+	return nil
+}
+
+// withEnvironment replaces the environment placeholder in the path with the environment
+func withEnvironment(path string) string {
+	return strings.Replace(path, environmentPlaceholder, environment, -1)
+}
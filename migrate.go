@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/render"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migratePathPrefix string
+	migrateOutput     string
+	migrateSet        bool
+	migrateScript     string
+)
+
+var migrateDotenvCmd = &cobra.Command{
+	Use:   "migrate-dotenv <file>",
+	Short: "Convert a .env file into a parameters file, optionally storing its values",
+	Long: `Convert a .env file into a parameters file, optionally storing its values.
+
+Each variable becomes a component parameter stored under the path prefix, in a
+path named after the variable in lower case. The comment lines right above a
+variable become its description.
+
+` + migrationHelp,
+	Example: `  ssmeb migrate-dotenv .env --path-prefix /myservice -o params.yaml
+  ssmeb migrate-dotenv .env --path-prefix /myservice -o params.yaml -e staging --set`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("dotenv", args[0], "output", migrateOutput, "environment", environment)
+		return runMigrateDotenv(args[0])
+	},
+}
+
+// migrationHelp describes the flags shared by the commands migrating variables into parameters
+const migrationHelp = `The values are written to the parameters file, unless --set stores them in the
+store instead, or --script writes the aws commands storing them to a shell
+script. Stored values go to the paths prefixed with the environment, as in set.
+With --output, the parameters are appended to that file, keeping its contents.`
+
+func init() {
+	addMigrationFlags(migrateDotenvCmd)
+	rootCmd.AddCommand(migrateDotenvCmd)
+}
+
+// addMigrationFlags adds the flags shared by the commands migrating variables into parameters
+func addMigrationFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&migratePathPrefix, "path-prefix", "", "path under which the parameters are stored, e.g. /myservice")
+	cmd.Flags().StringVarP(&migrateOutput, "output", "o", "", "parameters file the parameters are added to, created if missing (defaults to stdout)")
+	cmd.Flags().BoolVar(&migrateSet, "set", false, "store the values instead of writing them to the parameters file")
+	cmd.Flags().StringVar(&migrateScript, "script", "", "write a shell script storing the values in SSM with the aws cli, instead of writing them to the parameters file")
+	cmd.MarkFlagRequired("path-prefix")
+}
+
+// runMigrateDotenv adds the variables of the .env file with name filename as
+// parameters to the output, storing their values if requested
+func runMigrateDotenv(filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("Error reading file `%s`: %v", filename, err)
+	}
+	variables, err := parseDotenv(data)
+	if err != nil {
+		return fmt.Errorf("Error parsing file `%s`: %v", filename, err)
+	}
+	return migrateVariables(variables)
+}
+
+// migrateVariables adds the variables as component parameters to the output, named
+// after them under the path prefix, storing their values as requested
+func migrateVariables(variables []envVariable) error {
+	if migrateSet && migrateScript != "" {
+		return fmt.Errorf("Only one of `set` and `script` can be given")
+	}
+
+	var document *config.Document
+	var err error
+	if migrateOutput != "" {
+		document, err = config.ReadDocument(migrateOutput)
+	} else {
+		document, err = config.ParseDocument(nil)
+	}
+	if err != nil {
+		return fmt.Errorf("Error reading file `%s`: %v", migrateOutput, err)
+	}
+	existing, err := document.Parameters()
+	if err != nil {
+		return fmt.Errorf("Error parsing file `%s`: %v", migrateOutput, err)
+	}
+	defined := map[string]bool{}
+	for _, par := range append(existing.All(), existing.Derived...) {
+		defined[par.Name] = true
+	}
+
+	var migrated config.Parameters
+	for _, variable := range variables {
+		if defined[variable.Name] {
+			return fmt.Errorf("Option `%s` is already defined in `%s`", variable.Name, migrateOutput)
+		}
+		defined[variable.Name] = true
+		migrated.Component = append(migrated.Component, config.Parameter{
+			Name:        variable.Name,
+			Description: variable.Comment,
+			Path:        strings.TrimRight(migratePathPrefix, "/") + "/" + strings.ToLower(variable.Name),
+			Value:       variable.Value,
+		})
+	}
+
+	storeValues := migrateSet || migrateScript != ""
+	if storeValues {
+		parameters, err := expandPlaceholders(migrated.WithEnvironment(environment))
+		if err != nil {
+			return err
+		}
+		if migrateScript != "" {
+			err = writeToFile(migrateScript, putParameterScript(parameters), 0700)
+			if err != nil {
+				return fmt.Errorf("Error writing to file `%s`: %v", migrateScript, err)
+			}
+		} else {
+			s, err := newStore()
+			if err != nil {
+				return err
+			}
+			err = setParameters(s, parameters, nil, nil)
+			if err != nil {
+				return fmt.Errorf("Error setting values: %v", err)
+			}
+		}
+	}
+
+	for _, par := range migrated.Component {
+		if storeValues {
+			// the values go to the store, keep them out of the file
+			par.Value = ""
+		}
+		if err := document.Add("component", par); err != nil {
+			return err
+		}
+	}
+	out, err := document.Bytes()
+	if err != nil {
+		return err
+	}
+	if migrateOutput == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	// the values may be secrets
+	mode := os.FileMode(0600)
+	if storeValues {
+		mode = 0644
+	}
+	err = writeToFile(migrateOutput, out, mode)
+	if err != nil {
+		return fmt.Errorf("Error writing to file `%s`: %v", migrateOutput, err)
+	}
+	fmt.Fprintf(os.Stderr, "Added %d parameters to `%s`\n", len(migrated.Component), migrateOutput)
+	return nil
+}
+
+// putParameterScript returns a shell script storing the values of the component
+// parameters in SSM with the aws cli. Parameters without a value are left out.
+func putParameterScript(parameters config.Parameters) []byte {
+	var script bytes.Buffer
+	script.WriteString("#!/bin/sh\nset -e\n")
+	for _, par := range parameters.Component {
+		if par.Value == "" {
+			fmt.Fprintf(&script, "# %s has no value\n", par.Path)
+			continue
+		}
+		parType := par.Type
+		if parType == "" {
+			parType = config.TypeString
+		}
+		fmt.Fprintf(&script, "aws ssm put-parameter --overwrite --type %s --name %s --value %s\n",
+			parType, render.ShellQuote(par.Path), render.ShellQuote(par.Value))
+	}
+	return script.Bytes()
+}
+
+// envVariable is an environment variable to migrate into a parameter
+type envVariable struct {
+	Name  string
+	Value string
+	// Comment holds the comment lines right above the variable, without the `#`
+	Comment string
+}
+
+// parseDotenv parses the variables defined in a .env file, in order. Lines may start
+// with `export`, and values may be single quoted, taken literally, or double quoted,
+// with backslash escapes. Unquoted values end at a ` #` comment.
+func parseDotenv(data []byte) ([]envVariable, error) {
+	var variables []envVariable
+	var comment []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			comment = nil
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			comment = append(comment, strings.TrimSpace(strings.TrimPrefix(line, "#")))
+			continue
+		}
+
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		parts := strings.SplitN(line, "=", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("line %d: expected `NAME=value`", number)
+		}
+		value, err := dotenvValue(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", number, err)
+		}
+		variables = append(variables, envVariable{Name: name, Value: value, Comment: strings.Join(comment, "\n")})
+		comment = nil
+	}
+	return variables, scanner.Err()
+}
+
+// dotenvUnescaper replaces the escape sequences of double quoted .env values
+var dotenvUnescaper = strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n", `\r`, "\r", `\$`, "$")
+
+// dotenvValue parses the value of a .env variable, as written after the `=`
+func dotenvValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+	switch quote := raw[0]; quote {
+	case '\'':
+		end := strings.IndexByte(raw[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single quoted value")
+		}
+		return raw[1 : end+1], nil
+	case '"':
+		for end := 1; end < len(raw); end++ {
+			if raw[end] == '\\' {
+				end++
+				continue
+			}
+			if raw[end] == '"' {
+				return dotenvUnescaper.Replace(raw[1:end]), nil
+			}
+		}
+		return "", fmt.Errorf("unterminated double quoted value")
+	}
+	if i := strings.Index(raw, " #"); i >= 0 {
+		raw = raw[:i]
+	}
+	return strings.TrimSpace(raw), nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/codacy/ssmeb/pkg/render"
+)
+
+// outputSpec is an output file requested with the output flag
+type outputSpec struct {
+	// Format is the name of the format of the file, as accepted by render.Render,
+	// or templateFormat to render it through the template flag
+	Format string
+	// Path is the name of the file, or empty to write to stdout
+	Path string
+	// Target selects the options written, the ones listing it in their targets. Without
+	// it, the options without targets are written.
+	Target string
+}
+
+// templateFormat is the format of outputs rendered through a user template
+const templateFormat = "template"
+
+// outputFields are the fields of the output flag, in `format=dotenv,path=.env,target=app`
+var outputFields = []string{"format", "path", "target"}
+
+// parseOutput parses the value of an output flag, which is either the path of a file
+// in the default format or a list of fields like `format=dotenv,path=.env,target=app`.
+// Values are fields only when they start with one, so paths holding `=` are read as
+// paths, and a path starting like a field can be given as `path=...`. Each field value
+// ends at the next `,` and may hold `=`.
+func parseOutput(value string, defaultFormat string) (outputSpec, error) {
+	spec := outputSpec{Format: defaultFormat}
+	if !isOutputFields(value) {
+		spec.Path = value
+		return spec, nil
+	}
+
+	for _, field := range strings.Split(value, ",") {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return spec, fmt.Errorf("Invalid output `%s`: expected fields like `format=dotenv,path=.env`", value)
+		}
+		switch parts[0] {
+		case "format":
+			spec.Format = parts[1]
+		case "path":
+			spec.Path = parts[1]
+		case "target":
+			spec.Target = parts[1]
+		default:
+			return spec, fmt.Errorf("Invalid output `%s`: unknown field `%s`", value, parts[0])
+		}
+	}
+	return spec, nil
+}
+
+// isOutputFields reports whether the value of an output flag is a list of fields,
+// starting with one like `format=`
+func isOutputFields(value string) bool {
+	for _, field := range outputFields {
+		if strings.HasPrefix(value, field+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseOutputs parses the values of a repeated output flag. Without any, the default
+// format is written to stdout.
+func parseOutputs(values []string, defaultFormat string) ([]outputSpec, error) {
+	if len(values) == 0 {
+		return []outputSpec{{Format: defaultFormat}}, nil
+	}
+	specs := make([]outputSpec, 0, len(values))
+	for _, value := range values {
+		spec, err := parseOutput(value, defaultFormat)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// hasComments reports whether files in the format can hold `#` comments, like the header
+func hasComments(format string) bool {
+	return format != "json" && format != "gitlab" && format != templateFormat
+}
+
+// optionsFor returns the options written to the output, the ones listing its target or,
+// if it has none, the ones without targets
+func optionsFor(spec outputSpec, options []render.Option) []render.Option {
+	selected := make([]render.Option, 0, len(options))
+	for _, option := range options {
+		if len(option.Targets) == 0 && spec.Target == "" {
+			selected = append(selected, option)
+			continue
+		}
+		for _, target := range option.Targets {
+			if target == spec.Target {
+				selected = append(selected, option)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// warnUnwrittenTargets warns about the targets of the options that none of the outputs has,
+// since those options aren't written anywhere
+func warnUnwrittenTargets(specs []outputSpec, options []render.Option) {
+	written := map[string]bool{}
+	for _, spec := range specs {
+		written[spec.Target] = true
+	}
+	warned := map[string]bool{}
+	for _, option := range options {
+		for _, target := range option.Targets {
+			if !written[target] && !warned[target] {
+				warned[target] = true
+				fmt.Fprintf(os.Stderr, "Warning: no output has `target=%s`, the options targeting it aren't written\n", target)
+			}
+		}
+	}
+}
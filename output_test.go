@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		value string
+		want  outputSpec
+		err   bool
+	}{
+		{".ebextensions/env.config", outputSpec{Format: "ebyaml", Path: ".ebextensions/env.config"}, false},
+		{"build/region=eu/env.config", outputSpec{Format: "ebyaml", Path: "build/region=eu/env.config"}, false},
+		{"format=dotenv,path=.env", outputSpec{Format: "dotenv", Path: ".env"}, false},
+		{"path=build/a=b.env,format=dotenv,target=app", outputSpec{Format: "dotenv", Path: "build/a=b.env", Target: "app"}, false},
+		{"path=format=x", outputSpec{Format: "ebyaml", Path: "format=x"}, false},
+		{"target=ci", outputSpec{Format: "ebyaml", Target: "ci"}, false},
+		{"format=dotenv,colour=red", outputSpec{}, true},
+		{"format=dotenv,.env", outputSpec{}, true},
+	}
+	for _, test := range tests {
+		got, err := parseOutput(test.value, "ebyaml")
+		if (err != nil) != test.err || (!test.err && got != test.want) {
+			t.Errorf("parseOutput(%s) = %+v, %v, want %+v (error: %v)", test.value, got, err, test.want, test.err)
+		}
+	}
+}
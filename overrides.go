@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/store"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// overridesFile is a yaml file mapping option names to the values used instead of the
+// ones in the store, e.g. to point some options at local services
+var overridesFile string
+
+// overrideStore gets the overridden paths from the overrides, and every other one from
+// the store it wraps
+type overrideStore struct {
+	store.Store
+	// values holds the overridden parameters by path
+	values map[string]store.Parameter
+}
+
+// Get returns the override of the path, if any, or the parameter in the wrapped store
+func (s overrideStore) Get(path string) (store.Parameter, error) {
+	if par, ok := s.values[path]; ok {
+		return par, nil
+	}
+	return s.Store.Get(path)
+}
+
+// readOverrides reads the overrides file, if given, returning the values of the
+// parameters overridden by path. The options are named as in the input or after its
+// naming, and the ones not in the parameters are reported and ignored.
+func readOverrides(parameters config.Parameters) (map[string]store.Parameter, error) {
+	if overridesFile == "" {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(overridesFile)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading the overrides: %v", err)
+	}
+	values := map[string]string{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("Error parsing the overrides `%s`, it must map option names to values: %v", overridesFile, err)
+	}
+
+	overrides := map[string]store.Parameter{}
+	used := map[string]bool{}
+	for _, par := range parameters.All() {
+		if par.IsWildcard() {
+			continue
+		}
+		for _, name := range []string{par.Name, parameters.Naming.Apply(par.Name)} {
+			if value, ok := values[name]; ok {
+				overrides[par.Path] = store.Parameter{Path: par.Path, Value: value, Secret: par.Type == config.TypeSecureString}
+				used[name] = true
+				break
+			}
+		}
+	}
+	var unknown []string
+	for name := range values {
+		if !used[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		fmt.Fprintf(os.Stderr, "Warning: `%s` in the overrides isn't a parameter of the input, it's ignored\n", name)
+	}
+	if len(overrides) > 0 {
+		fmt.Fprintf(os.Stderr, "* Using %d value(s) from the overrides `%s` instead of the store\n", len(overrides), overridesFile)
+	}
+	return overrides, nil
+}
+
+// withOverrides wraps the store so the overridden paths get their values from the
+// overrides, leaving it as is when there are none
+func withOverrides(s store.Store, overrides map[string]store.Parameter) store.Store {
+	if len(overrides) == 0 {
+		return s
+	}
+	return overrideStore{Store: s, values: overrides}
+}
@@ -0,0 +1,131 @@
+// Package azurekeyvaultstore implements a store.Store backed by an Azure Key Vault.
+//
+// Key Vault secret names can only hold alphanumeric characters and dashes, so paths
+// are converted into names by dropping the leading slash and replacing any other
+// character with a dash, e.g. `/codacy/db_host` is stored in the `codacy-db-host` secret.
+package azurekeyvaultstore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	"github.com/codacy/ssmeb/pkg/store"
+)
+
+// descriptionTag is the secret tag holding the description of the parameter
+const descriptionTag = "description"
+
+// Store reads and writes parameters in an Azure Key Vault
+type Store struct {
+	client *azsecrets.Client
+}
+
+// New creates a Store for the vault in vaultURL (e.g. https://myvault.vault.azure.net), using
+// the default Azure credential chain (environment, managed identity, Azure CLI...)
+func New(vaultURL string) (*Store, error) {
+	credential, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, err
+	}
+	client, err := azsecrets.NewClient(vaultURL, credential, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{client: client}, nil
+}
+
+// Get returns the latest version of the secret for path
+func (s *Store) Get(path string) (store.Parameter, error) {
+	response, err := s.client.GetSecret(context.Background(), SecretName(path), "", nil)
+	if err != nil {
+		return store.Parameter{}, convertError(err)
+	}
+	return store.Parameter{
+		Path:        path,
+		Value:       valueOf(response.Value),
+		Secret:      true,
+		Description: valueOf(response.Tags[descriptionTag]),
+	}, nil
+}
+
+// Put stores the parameter as a new version of the secret for its path. Key Vault
+// versions are not numeric, so the returned version is always 0.
+func (s *Store) Put(par store.Parameter) (int64, error) {
+	parameters := azsecrets.SetSecretParameters{Value: &par.Value}
+	if par.Description != "" {
+		parameters.Tags = map[string]*string{descriptionTag: &par.Description}
+	}
+	_, err := s.client.SetSecret(context.Background(), SecretName(par.Path), parameters, nil)
+	return 0, err
+}
+
+// Delete deletes the secret for path, which stays recoverable for the retention period of the vault
+func (s *Store) Delete(path string) error {
+	_, err := s.client.DeleteSecret(context.Background(), SecretName(path), nil)
+	return convertError(err)
+}
+
+// List returns every secret whose name starts with the one for prefix. The paths of
+// the returned parameters are the secret names, since the original paths can't be recovered.
+func (s *Store) List(prefix string) ([]store.Parameter, error) {
+	namePrefix := SecretName(prefix)
+
+	var names []string
+	pager := s.client.NewListSecretPropertiesPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, secret := range page.Value {
+			if secret.ID == nil {
+				continue
+			}
+			if name := secret.ID.Name(); strings.HasPrefix(name, namePrefix) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	var parameters []store.Parameter
+	for _, name := range names {
+		par, err := s.Get(name)
+		if err != nil {
+			return parameters, err
+		}
+		parameters = append(parameters, par)
+	}
+	return parameters, nil
+}
+
+// SecretName converts a parameter path into a valid Key Vault secret name
+func SecretName(path string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' {
+			return r
+		}
+		return '-'
+	}, strings.TrimPrefix(path, "/"))
+}
+
+// valueOf dereferences s, returning an empty string if it's nil
+func valueOf(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// convertError replaces the errors for missing secrets with store.ErrNotFound
+func convertError(err error) error {
+	var responseErr *azcore.ResponseError
+	if errors.As(err, &responseErr) && responseErr.StatusCode == http.StatusNotFound {
+		return store.ErrNotFound
+	}
+	return err
+}
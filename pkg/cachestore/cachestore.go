@@ -0,0 +1,190 @@
+// Package cachestore implements a store.Store caching the parameters got from
+// another store in a local directory for a limited time.
+//
+// Each cache entry records the path, value and version of a parameter, encrypted
+// with AES-256-GCM so values don't sit in plain text on disk. Writes and deletes
+// go straight to the underlying store and invalidate the cached entry.
+package cachestore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/codacy/ssmeb/pkg/store"
+)
+
+// KeySize is the size in bytes of the encryption keys
+const KeySize = 32
+
+// Store caches the parameters got from another store
+type Store struct {
+	inner     store.Store
+	dir       string
+	namespace string
+	ttl       time.Duration
+	aead      cipher.AEAD
+}
+
+// entry is the content of a cache file
+type entry struct {
+	Parameter store.Parameter `json:"parameter"`
+	Fetched   time.Time       `json:"fetched"`
+}
+
+// New creates a Store caching the parameters got from inner in dir for ttl, encrypted
+// with key. Entries are separated by namespace, which should identify the inner store
+// (e.g. the backend and region), so different stores never share entries.
+func New(inner store.Store, dir string, namespace string, ttl time.Duration, key []byte) (*Store, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("cache key must have %d bytes, not %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Store{inner: inner, dir: dir, namespace: namespace, ttl: ttl, aead: aead}, nil
+}
+
+// LoadOrCreateKey reads the encryption key from filename, generating a random one
+// and saving it there, only readable by its owner, if the file doesn't exist
+func LoadOrCreateKey(filename string) ([]byte, error) {
+	key, err := ioutil.ReadFile(filename)
+	if err == nil {
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key = make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return nil, err
+	}
+	return key, ioutil.WriteFile(filename, key, 0600)
+}
+
+// Get returns the cached parameter for path if it's younger than the ttl, or
+// gets it from the inner store and caches it otherwise
+func (s *Store) Get(path string) (store.Parameter, error) {
+	if cached, ok := s.read(path); ok && time.Now().Sub(cached.Fetched) < s.ttl {
+		return cached.Parameter, nil
+	}
+
+	par, err := s.inner.Get(path)
+	if err != nil {
+		return par, err
+	}
+	if err := s.write(path, entry{Parameter: par, Fetched: time.Now()}); err != nil {
+		return par, fmt.Errorf("error caching `%s`: %v", path, err)
+	}
+	return par, nil
+}
+
+// Put stores the parameter in the inner store, invalidating its cached entry
+func (s *Store) Put(par store.Parameter) (int64, error) {
+	s.invalidate(par.Path)
+	return s.inner.Put(par)
+}
+
+// Delete removes the parameter from the inner store, invalidating its cached entry
+func (s *Store) Delete(path string) error {
+	s.invalidate(path)
+	return s.inner.Delete(path)
+}
+
+// List returns the parameters under prefix from the inner store, which is never cached
+func (s *Store) List(prefix string) ([]store.Parameter, error) {
+	return s.inner.List(prefix)
+}
+
+// Clear removes every cached entry
+func (s *Store) Clear() error {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.entry"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filename returns the cache file for path, which doesn't reveal the path
+func (s *Store) filename(path string) string {
+	hash := sha256.Sum256([]byte(s.namespace + "\x00" + path))
+	return filepath.Join(s.dir, hex.EncodeToString(hash[:])+".entry")
+}
+
+// read returns the cached entry for path, if there's a valid one
+func (s *Store) read(path string) (entry, bool) {
+	var cached entry
+	data, err := ioutil.ReadFile(s.filename(path))
+	if err != nil || len(data) < s.aead.NonceSize() {
+		return cached, false
+	}
+
+	nonce, ciphertext := data[:s.aead.NonceSize()], data[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, []byte(path))
+	if err != nil {
+		// written with another key or corrupted, so it's just refreshed
+		return cached, false
+	}
+	if err := json.Unmarshal(plaintext, &cached); err != nil {
+		return cached, false
+	}
+	return cached, true
+}
+
+// write caches the entry for path, replacing the file atomically
+func (s *Store) write(path string, cached entry) error {
+	plaintext, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	data := s.aead.Seal(nonce, nonce, plaintext, []byte(path))
+
+	tmp, err := ioutil.TempFile(s.dir, "tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.filename(path))
+}
+
+// invalidate removes the cached entry for path, if any
+func (s *Store) invalidate(path string) {
+	os.Remove(s.filename(path))
+}
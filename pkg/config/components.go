@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ComponentNames returns the names of the components in the file, sorted
+func (p Parameters) ComponentNames() []string {
+	names := make([]string, 0, len(p.Components))
+	for name := range p.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ForComponent returns the parameters of the component with the given name, which are
+// its own ones merged with the ones shared by every component in the file
+func (p Parameters) ForComponent(name string) (Parameters, error) {
+	own, ok := p.Components[name]
+	if !ok {
+		return Parameters{}, fmt.Errorf("unknown component `%s`, expected one of %v", name, p.ComponentNames())
+	}
+	if len(own.Components) > 0 {
+		return Parameters{}, fmt.Errorf("component `%s` can't have components", name)
+	}
+	shared := p
+	shared.Components = nil
+	merged, err := Merge(shared, own)
+	if err != nil {
+		return Parameters{}, fmt.Errorf("component `%s`: %v", name, err)
+	}
+	return merged, nil
+}
+
+// validateComponents returns the problems found in the components, leaving out the
+// ones of the shared parameters already in problems
+func (p Parameters) validateComponents(problems []string) []string {
+	reported := map[string]bool{}
+	for _, problem := range problems {
+		reported[problem] = true
+	}
+	var found []string
+	for _, name := range p.ComponentNames() {
+		parameters, err := p.ForComponent(name)
+		if err != nil {
+			found = append(found, err.Error())
+			continue
+		}
+		for _, problem := range parameters.Validate() {
+			if !reported[problem] {
+				found = append(found, fmt.Sprintf("component `%s`: %s", name, problem))
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,448 @@
+// Package config reads and validates the parameters file used by ssmeb, which
+// lists the ssm parameters an application needs and the options they map to.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/codacy/ssmeb/pkg/store"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Parameters is the format of the parameters file.
+type Parameters struct {
+	// Include lists other parameters files merged into this one, relative to it
+	Include []string `yaml:"include" json:"include,omitempty"`
+	// Component holds parameters owned by this app
+	Component []Parameter `yaml:"component" json:"component,omitempty"`
+	// External holds parameters external to this app. They can't be set.
+	External []Parameter `yaml:"external" json:"external,omitempty"`
+	// Derived holds options without a path, whose value is composed from other options
+	// referenced as `{OPTION_NAME}`, e.g. `postgres://{DB_USER}@{DB_HOST}/app`
+	Derived []Parameter `yaml:"derived" json:"derived,omitempty"`
+	// Environments holds the overrides of each environment, by environment name
+	Environments map[string]Environment `yaml:"environments" json:"environments,omitempty"`
+	// Naming transforms the option names, e.g. to add a prefix
+	Naming Naming `yaml:"naming" json:"naming,omitempty"`
+	// Components holds the parameters of each of several components sharing the file, by
+	// name. The other sections are shared by all of them.
+	Components map[string]Parameters `yaml:"components" json:"components,omitempty"`
+
+	// selection filters the children of the wildcard parameters once expanded, if Select
+	// was called
+	selection *selection
+}
+
+// Parameter holds info about an ssm parameter
+type Parameter struct {
+	// Name is the name of environment variable stored in the ssm parameter (required)
+	Name string `yaml:"option_name" json:"option_name,omitempty"`
+	// Description is an optional string describing parameter
+	Description string `yaml:"description" json:"description,omitempty"`
+	// Path is key for the parameter on the Systems Manager
+	Path string `yaml:"path" json:"path,omitempty"`
+	// Value is the value stored on the Systems Manager. This is optional but useful when using the set mode
+	Value string `yaml:"value" json:"value,omitempty"`
+	// Source is the store holding the parameter, e.g. `secretsmanager`. It's the same as prefixing
+	// the path with `secretsmanager://`, and defaults to the Systems Manager.
+	Source string `yaml:"source" json:"source,omitempty"`
+	// Type is the type of the ssm parameter used by the set mode, `String` (default) or `SecureString`
+	Type string `yaml:"type" json:"type,omitempty"`
+	// OnlyEnvironments lists the only environments the parameter is used in, if not empty
+	OnlyEnvironments []string `yaml:"only_environments" json:"only_environments,omitempty"`
+	// ExceptEnvironments lists environments the parameter is not used in
+	ExceptEnvironments []string `yaml:"except_environments" json:"except_environments,omitempty"`
+	// Optional parameters missing from the store are left out instead of failing
+	Optional bool `yaml:"optional" json:"optional,omitempty"`
+	// Required set to false is the same as Optional. Parameters are required by default.
+	Required *bool `yaml:"required" json:"required,omitempty"`
+	// Default is the value used, with a warning, when the parameter is missing from the store
+	Default string `yaml:"default" json:"default,omitempty"`
+	// RoleARN is an IAM role assumed to get an external parameter, e.g. one owned by another account
+	RoleARN string `yaml:"role_arn" json:"role_arn,omitempty"`
+	// Profile is a profile of the AWS shared config used to get an external parameter. A RoleARN
+	// is assumed with the credentials of the profile.
+	Profile string `yaml:"profile" json:"profile,omitempty"`
+	// Targets lists the outputs of get the option is written to, the ones given `target=<name>`.
+	// Options without targets are written to the outputs without a target.
+	Targets []string `yaml:"targets" json:"targets,omitempty"`
+	// Group is the section of the elastic beanstalk output the option is written in, under
+	// a comment with its name, e.g. `Database`
+	Group string `yaml:"group" json:"group,omitempty"`
+	// Transforms are applied in order to the value got from the store before writing it,
+	// e.g. `trim` and `base64`, so the same value can be given in several encodings
+	Transforms []string `yaml:"transforms" json:"transforms,omitempty"`
+}
+
+// Credentials identifies the credentials the parameter is got with, which is empty for
+// the ones of the run, and is the same for parameters with the same role and profile
+func (par Parameter) Credentials() string {
+	if par.RoleARN == "" && par.Profile == "" {
+		return ""
+	}
+	return par.Profile + "|" + par.RoleARN
+}
+
+// Sources of the parameters
+const (
+	// SourceSSM is the Systems Manager Parameter Store, used by default
+	SourceSSM = "ssm"
+	// SourceSecretsManager is AWS Secrets Manager. Paths may end in `#key` to get a key of a JSON secret.
+	SourceSecretsManager = "secretsmanager"
+	// SourceExec is a plugin run to get the value. Paths are like `plugin/reference`, and
+	// aren't prefixed with the environment, which is given to the plugin instead.
+	SourceExec = "exec"
+)
+
+// Types of the ssm parameters
+const (
+	// TypeString is a plain text parameter, used by default
+	TypeString = "String"
+	// TypeSecureString is a parameter encrypted with KMS
+	TypeSecureString = "SecureString"
+)
+
+// types holds every valid Type
+var types = map[string]bool{TypeString: true, TypeSecureString: true}
+
+// Placeholders that can be used as `{name}` in paths and values, replaced by Interpolate
+const (
+	// PlaceholderEnvironment is the environment name. Paths using it are not prefixed with the environment.
+	PlaceholderEnvironment = "environment"
+	// PlaceholderRegion is the AWS region
+	PlaceholderRegion = "region"
+	// PlaceholderAccountID is the AWS account id
+	PlaceholderAccountID = "account_id"
+)
+
+// sources holds every valid Source
+var sources = map[string]bool{SourceSSM: true, SourceSecretsManager: true, SourceExec: true}
+
+// ReadFile reads parameters from a file with name filename and the files it includes,
+// and prepends `/environment` to their paths if the environment is not an empty string
+// (see WithEnvironment)
+func ReadFile(filename string, environment string) (Parameters, error) {
+	parameters, err := Load(filename, "")
+	if err != nil {
+		return parameters, err
+	}
+	return parameters.WithEnvironment(environment), nil
+}
+
+// Formats of the parameters files
+const (
+	// FormatYAML is the default format of parameters files
+	FormatYAML = "yaml"
+	// FormatJSON is the same structure as the yaml files, written in JSON
+	FormatJSON = "json"
+	// FormatCUE is a CUE file evaluated to the structure in JSON with the cue command.
+	// It's the format of the files with the .cue extension.
+	FormatCUE = "cue"
+	// FormatJsonnet is a Jsonnet file evaluated to the structure in JSON with the jsonnet
+	// command. It's the format of the files with the .jsonnet extension.
+	FormatJsonnet = "jsonnet"
+	// FormatHCL is the same structure written in HCL, with repeated blocks for the lists
+	// and maps of parameters. It's the format of the files with the .hcl extension.
+	FormatHCL = "hcl"
+)
+
+// DetectFormat returns the format of the contents of a parameters file, which is
+// FormatJSON if they hold an object, or FormatYAML otherwise
+func DetectFormat(data []byte) string {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// Parse parses the contents of a parameters file, in the format detected by DetectFormat.
+// The path of parameters with a source other than SSM is prefixed with it, e.g. `secretsmanager://name`.
+func Parse(data []byte) (Parameters, error) {
+	return ParseFormat(data, "")
+}
+
+// ParseFormat is like Parse for contents in the given format, or in the detected one if it's empty
+func ParseFormat(data []byte, format string) (Parameters, error) {
+	if format == "" {
+		format = DetectFormat(data)
+	}
+
+	var parameters Parameters
+	var err error
+	switch format {
+	case FormatYAML:
+		err = yaml.Unmarshal(data, &parameters)
+	case FormatJSON:
+		err = json.Unmarshal(data, &parameters)
+	case FormatHCL:
+		var converted []byte
+		if converted, err = hclToJSON(data); err == nil {
+			err = json.Unmarshal(converted, &parameters)
+		}
+	case FormatCUE, FormatJsonnet:
+		err = fmt.Errorf("%s files are evaluated, so they can only be read from the disk", format)
+	default:
+		err = fmt.Errorf("unknown format `%s`", format)
+	}
+	if err != nil {
+		return parameters, err
+	}
+	parameters.normalize()
+	return parameters, nil
+}
+
+// normalize prefixes the path of parameters with a source other than SSM with it, and
+// makes the ones not required optional
+func (p Parameters) normalize() {
+	lists := [][]Parameter{p.Component, p.External}
+	for _, env := range p.Environments {
+		lists = append(lists, env.Parameters)
+	}
+	for _, list := range lists {
+		for i, par := range list {
+			scheme, _ := store.SplitScheme(par.Path)
+			if scheme == "" && par.Source != "" && par.Source != SourceSSM {
+				list[i].Path = par.Source + "://" + par.Path
+			}
+			if par.Required != nil && !*par.Required {
+				list[i].Optional = true
+			}
+		}
+	}
+	for _, component := range p.Components {
+		component.normalize()
+	}
+}
+
+// WithEnvironment returns a copy of the parameters used in the environment, with the overrides
+// of the environment (and the environments it extends) applied and `/environment` prepended
+// to every path (after its source, if any). If environment is empty, only the parameters
+// restricted to some environments are left out. Paths placing the environment elsewhere
+// with an `{environment}` placeholder are not prefixed, and the placeholder is replaced
+// in every path and value instead. Neither are the paths of the exec source, nor ARNs,
+// which already name a single secret of an account.
+func (p Parameters) WithEnvironment(environment string) Parameters {
+	p = p.usedIn(environment)
+	if environment == "" {
+		return p
+	}
+
+	prefixed := p.withOverrides(environment)
+	for _, list := range [][]Parameter{prefixed.Component, prefixed.External} {
+		for i, par := range list {
+			scheme, _ := store.SplitScheme(par.Path)
+			if scheme != SourceExec && !strings.Contains(par.Path, placeholder(PlaceholderEnvironment)) {
+				list[i].Path = prefixPath(environment, par.Path)
+			}
+		}
+	}
+	return prefixed.Interpolate(map[string]string{PlaceholderEnvironment: environment})
+}
+
+// Interpolate returns a copy of the parameters where every `{name}` placeholder in
+// paths, values and defaults is replaced by values[name]. Other placeholders are kept as is.
+func (p Parameters) Interpolate(values map[string]string) Parameters {
+	var pairs []string
+	for name, value := range values {
+		pairs = append(pairs, placeholder(name), value)
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	interpolated := p.copy()
+	for _, list := range [][]Parameter{interpolated.Component, interpolated.External, interpolated.Derived} {
+		for i, par := range list {
+			list[i].Path = replacer.Replace(par.Path)
+			list[i].Value = replacer.Replace(par.Value)
+			list[i].Default = replacer.Replace(par.Default)
+		}
+	}
+	return interpolated
+}
+
+// Uses reports whether the `{name}` placeholder appears in any path, value or default
+func (p Parameters) Uses(name string) bool {
+	for _, par := range append(p.All(), p.Derived...) {
+		if strings.Contains(par.Path, placeholder(name)) || strings.Contains(par.Value, placeholder(name)) || strings.Contains(par.Default, placeholder(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// placeholder returns the placeholder for name, e.g. `{region}`
+func placeholder(name string) string {
+	return "{" + name + "}"
+}
+
+// copy returns a copy of the parameters that can be modified without changing p
+func (p Parameters) copy() Parameters {
+	// the other fields are only read, so they can be shared
+	copied := p
+	copied.Component = append([]Parameter{}, p.Component...)
+	copied.External = append([]Parameter{}, p.External...)
+	copied.Derived = append([]Parameter{}, p.Derived...)
+	return copied
+}
+
+// prefixPath prepends `/environment` to path, keeping its scheme at the start. Names
+// without a leading slash, usual in Secrets Manager, are prefixed with `environment/`,
+// except ARNs, which are left as they are.
+func prefixPath(environment string, path string) string {
+	scheme, name := store.SplitScheme(path)
+	if strings.HasPrefix(name, "arn:") {
+		return path
+	}
+	if strings.HasPrefix(name, "/") {
+		name = "/" + environment + name
+	} else {
+		name = environment + "/" + name
+	}
+	if scheme == "" {
+		return name
+	}
+	return scheme + "://" + name
+}
+
+// All returns the component parameters followed by the external ones, which are
+// the ones held by a store
+func (p Parameters) All() []Parameter {
+	return append(append([]Parameter{}, p.Component...), p.External...)
+}
+
+// Validate returns a description of every problem found in the parameters,
+// such as missing names or paths, or an empty slice if there are none
+func (p Parameters) Validate() []string {
+	var problems []string
+
+	names := map[string]bool{}
+	check := func(section string, list []Parameter) {
+		for i, par := range list {
+			if par.IsWildcard() {
+				if par.Name != "" && !strings.Contains(par.Name, leafPlaceholder) {
+					problems = append(problems, fmt.Sprintf("%s parameter `%s` has a wildcard path but no %s in its option_name", section, par.Name, leafPlaceholder))
+				}
+				if par.Default != "" {
+					problems = append(problems, fmt.Sprintf("%s parameter `%s` has a wildcard path and can't have a default", section, par.Name))
+				}
+			} else if par.Name == "" {
+				problems = append(problems, fmt.Sprintf("%s parameter #%d has no option_name", section, i+1))
+			} else if names[par.Name] {
+				problems = append(problems, fmt.Sprintf("option_name `%s` is used more than once", par.Name))
+			}
+			names[par.Name] = true
+
+			scheme, name := store.SplitScheme(par.Path)
+			if par.Source != "" && !sources[par.Source] {
+				problems = append(problems, fmt.Sprintf("%s parameter `%s` has an unknown source: %s", section, par.Name, par.Source))
+			}
+			if scheme != "" && !sources[scheme] {
+				problems = append(problems, fmt.Sprintf("%s parameter `%s` has a path with an unknown source: %s", section, par.Name, par.Path))
+			}
+			if name == "" {
+				problems = append(problems, fmt.Sprintf("%s parameter `%s` has no path", section, par.Name))
+			} else if i := strings.Index(name, "/"); scheme == SourceExec && (i <= 0 || i == len(name)-1) {
+				problems = append(problems, fmt.Sprintf("%s parameter `%s` has an exec path not like `plugin/reference`: %s", section, par.Name, par.Path))
+			} else if scheme == "" && !strings.HasPrefix(name, "/") {
+				problems = append(problems, fmt.Sprintf("%s parameter `%s` has a path not starting with `/`: %s", section, par.Name, par.Path))
+			} else if strings.HasPrefix(name, "arn:") && strings.Contains(name, placeholder(PlaceholderEnvironment)) {
+				problems = append(problems, fmt.Sprintf("%s parameter `%s` has an ARN path with the %s placeholder, ARNs aren't given the environment: %s", section, par.Name, placeholder(PlaceholderEnvironment), par.Path))
+			}
+			if par.Type != "" && !types[par.Type] {
+				problems = append(problems, fmt.Sprintf("%s parameter `%s` has an unknown type: %s", section, par.Name, par.Type))
+			}
+			if len(par.OnlyEnvironments) > 0 && len(par.ExceptEnvironments) > 0 {
+				problems = append(problems, fmt.Sprintf("%s parameter `%s` has both only_environments and except_environments", section, par.Name))
+			}
+			if par.Required != nil && *par.Required && par.Optional {
+				problems = append(problems, fmt.Sprintf("%s parameter `%s` is both required and optional", section, par.Name))
+			}
+			if section == "component" && scheme == SourceExec {
+				problems = append(problems, fmt.Sprintf("component parameter `%s` can't have the exec source, plugins are read-only", par.Name))
+			}
+			if section == "component" && par.Credentials() != "" {
+				problems = append(problems, fmt.Sprintf("component parameter `%s` can't have a role_arn or profile, only external ones can", par.Name))
+			}
+			if par.RoleARN != "" && !strings.HasPrefix(par.RoleARN, "arn:") {
+				problems = append(problems, fmt.Sprintf("%s parameter `%s` has a role_arn that is not an ARN: %s", section, par.Name, par.RoleARN))
+			}
+			problems = append(problems, outputProblems(section, par)...)
+			problems = append(problems, transformProblems(section, par)...)
+		}
+	}
+	check("component", p.Component)
+	check("external", p.External)
+
+	for i, par := range p.Derived {
+		if par.Name == "" {
+			problems = append(problems, fmt.Sprintf("derived parameter #%d has no option_name", i+1))
+		} else if names[par.Name] {
+			problems = append(problems, fmt.Sprintf("option_name `%s` is used more than once", par.Name))
+		}
+		names[par.Name] = true
+		if par.Path != "" || par.Source != "" || par.Credentials() != "" || par.Default != "" {
+			problems = append(problems, fmt.Sprintf("derived parameter `%s` can't have a path, source, role_arn, profile or default", par.Name))
+		}
+		if par.Value == "" {
+			problems = append(problems, fmt.Sprintf("derived parameter `%s` has no value", par.Name))
+		}
+		if len(par.OnlyEnvironments) > 0 && len(par.ExceptEnvironments) > 0 {
+			problems = append(problems, fmt.Sprintf("derived parameter `%s` has both only_environments and except_environments", par.Name))
+		}
+		problems = append(problems, outputProblems("derived", par)...)
+		problems = append(problems, transformProblems("derived", par)...)
+	}
+	if _, err := p.DerivedOrder(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	problems = append(problems, p.validateEnvironments(names)...)
+	if p.Naming.Case != "" && !cases[p.Naming.Case] {
+		problems = append(problems, fmt.Sprintf("naming has an unknown case: %s", p.Naming.Case))
+	}
+	problems = append(problems, p.validateComponents(problems)...)
+
+	return problems
+}
+
+// ValidateEnvironment returns a description of every path of the parameters used in the
+// environment that already starts with it, and would be prefixed with it twice by
+// WithEnvironment, or an empty slice if there are none
+func (p Parameters) ValidateEnvironment(environment string) []string {
+	var problems []string
+	if environment == "" {
+		return problems
+	}
+	used := p.usedIn(environment).withOverrides(environment)
+	check := func(section string, list []Parameter) {
+		for _, par := range list {
+			scheme, name := store.SplitScheme(par.Path)
+			if scheme == SourceExec || strings.HasPrefix(name, "arn:") || strings.Contains(par.Path, placeholder(PlaceholderEnvironment)) {
+				continue
+			}
+			if strings.HasPrefix(name, "/"+environment+"/") || strings.HasPrefix(name, environment+"/") {
+				problems = append(problems, fmt.Sprintf("%s parameter `%s` has a path already starting with the environment `%s`, it's prefixed with it again: %s", section, par.Name, environment, par.Path))
+			}
+		}
+	}
+	check("component", used.Component)
+	check("external", used.External)
+	return problems
+}
+
+// outputProblems returns the problems of the fields of the parameter choosing where it's
+// written. Targets can't be empty or hold the separators of the output flag, and groups
+// can't span several lines of the comment naming them.
+func outputProblems(section string, par Parameter) []string {
+	var problems []string
+	if strings.ContainsAny(par.Group, "\r\n") {
+		problems = append(problems, fmt.Sprintf("%s parameter `%s` has a group with line breaks", section, par.Name))
+	}
+	for _, target := range par.Targets {
+		if target == "" || strings.ContainsAny(target, ",=") {
+			problems = append(problems, fmt.Sprintf("%s parameter `%s` has an invalid target `%s`, expected a name without `,` or `=`", section, par.Name, target))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,223 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFormat(t *testing.T) {
+	want := Parameters{
+		Component: []Parameter{{Name: "DB_HOST", Path: "/myservice/db/host", Description: "database host"}},
+		External:  []Parameter{{Name: "API_KEY", Path: "secretsmanager://api#key", Source: SourceSecretsManager, Optional: true}},
+		Derived:   []Parameter{{Name: "DB_URL", Value: "postgres://{DB_HOST}/app"}},
+	}
+	tests := []struct {
+		name   string
+		format string
+		data   string
+	}{
+		{"yaml", FormatYAML, `
+component:
+  - option_name: DB_HOST
+    path: /myservice/db/host
+    description: database host
+external:
+  - option_name: API_KEY
+    path: api#key
+    source: secretsmanager
+    optional: true
+derived:
+  - option_name: DB_URL
+    value: "postgres://{DB_HOST}/app"
+`},
+		{"json", FormatJSON, `{
+  "component": [{"option_name": "DB_HOST", "path": "/myservice/db/host", "description": "database host"}],
+  "external": [{"option_name": "API_KEY", "path": "api#key", "source": "secretsmanager", "required": false}],
+  "derived": [{"option_name": "DB_URL", "value": "postgres://{DB_HOST}/app"}]
+}`},
+		{"detected json", "", `{
+  "component": [{"option_name": "DB_HOST", "path": "/myservice/db/host", "description": "database host"}],
+  "external": [{"option_name": "API_KEY", "path": "api#key", "source": "secretsmanager", "optional": true}],
+  "derived": [{"option_name": "DB_URL", "value": "postgres://{DB_HOST}/app"}]
+}`},
+		{"hcl", FormatHCL, `
+component "DB_HOST" {
+  path        = "/myservice/db/host"
+  description = "database host"
+}
+external "API_KEY" {
+  path     = "api#key"
+  source   = "secretsmanager"
+  optional = true
+}
+derived "DB_URL" {
+  value = "postgres://{DB_HOST}/app"
+}
+`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseFormat([]byte(test.data), test.format)
+			if err != nil {
+				t.Fatalf("ParseFormat() error = %v", err)
+			}
+			// required: false is kept along with the optional flag it sets
+			got.External[0].Required = nil
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ParseFormat() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseFormatErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		err    string
+	}{
+		{"invalid yaml", FormatYAML, "component: [", "did not find expected"},
+		{"invalid json", FormatJSON, `{"component": }`, "invalid character"},
+		{"unknown field type", FormatYAML, "component: 3", "cannot unmarshal"},
+		{"evaluated format", FormatCUE, "component: []", "can only be read from the disk"},
+		{"unknown format", "toml", "", "unknown format `toml`"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseFormat([]byte(test.data), test.format)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("ParseFormat() error = %v, want one containing %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestWithEnvironment(t *testing.T) {
+	parameters := Parameters{
+		Component: []Parameter{
+			{Name: "DB_HOST", Path: "/myservice/db/host"},
+			{Name: "BUCKET", Path: "/myservice/bucket", Value: "codacy-{environment}"},
+			{Name: "DEBUG", Path: "/myservice/debug", OnlyEnvironments: []string{"staging"}},
+		},
+		External: []Parameter{
+			{Name: "API_KEY", Path: "secretsmanager://api#key"},
+			{Name: "SHARED", Path: "/shared/{environment}/queue"},
+			{Name: "TOKEN", Path: "exec://onepassword/token"},
+			{Name: "LEGACY", Path: "/legacy", ExceptEnvironments: []string{"production"}},
+		},
+		Environments: map[string]Environment{
+			"production": {Parameters: []Parameter{{Name: "DB_HOST", Description: "primary"}}},
+		},
+	}
+
+	tests := []struct {
+		environment string
+		want        Parameters
+	}{
+		{"", Parameters{
+			Component: []Parameter{
+				{Name: "DB_HOST", Path: "/myservice/db/host"},
+				{Name: "BUCKET", Path: "/myservice/bucket", Value: "codacy-{environment}"},
+			},
+			External: []Parameter{
+				{Name: "API_KEY", Path: "secretsmanager://api#key"},
+				{Name: "SHARED", Path: "/shared/{environment}/queue"},
+				{Name: "TOKEN", Path: "exec://onepassword/token"},
+				{Name: "LEGACY", Path: "/legacy", ExceptEnvironments: []string{"production"}},
+			},
+		}},
+		{"production", Parameters{
+			Component: []Parameter{
+				{Name: "DB_HOST", Path: "/production/myservice/db/host", Description: "primary"},
+				{Name: "BUCKET", Path: "/production/myservice/bucket", Value: "codacy-production"},
+			},
+			External: []Parameter{
+				{Name: "API_KEY", Path: "secretsmanager://production/api#key"},
+				{Name: "SHARED", Path: "/shared/production/queue"},
+				{Name: "TOKEN", Path: "exec://onepassword/token"},
+			},
+		}},
+		{"staging", Parameters{
+			Component: []Parameter{
+				{Name: "DB_HOST", Path: "/staging/myservice/db/host"},
+				{Name: "BUCKET", Path: "/staging/myservice/bucket", Value: "codacy-staging"},
+				{Name: "DEBUG", Path: "/staging/myservice/debug", OnlyEnvironments: []string{"staging"}},
+			},
+			External: []Parameter{
+				{Name: "API_KEY", Path: "secretsmanager://staging/api#key"},
+				{Name: "SHARED", Path: "/shared/staging/queue"},
+				{Name: "TOKEN", Path: "exec://onepassword/token"},
+				{Name: "LEGACY", Path: "/staging/legacy", ExceptEnvironments: []string{"production"}},
+			},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.environment, func(t *testing.T) {
+			got := parameters.WithEnvironment(test.environment)
+			if !reflect.DeepEqual(got.Component, test.want.Component) {
+				t.Errorf("WithEnvironment().Component = %+v, want %+v", got.Component, test.want.Component)
+			}
+			if !reflect.DeepEqual(got.External, test.want.External) {
+				t.Errorf("WithEnvironment().External = %+v, want %+v", got.External, test.want.External)
+			}
+		})
+	}
+
+	if parameters.Component[0].Path != "/myservice/db/host" {
+		t.Errorf("WithEnvironment() changed the parameters it was called on, path = %s", parameters.Component[0].Path)
+	}
+}
+
+func TestWithEnvironmentARN(t *testing.T) {
+	arn := "secretsmanager://arn:aws:secretsmanager:eu-west-1:123456789012:secret:shared-AbCdEf#password"
+	parameters := Parameters{External: []Parameter{{Name: "SHARED_PASSWORD", Path: arn}}}
+	if got := parameters.WithEnvironment("production").External[0].Path; got != arn {
+		t.Errorf("WithEnvironment() path = %s, want the ARN unprefixed", got)
+	}
+
+	parameters.External[0].Path = "secretsmanager://arn:aws:secretsmanager:eu-west-1:123456789012:secret:{environment}-AbCdEf"
+	problems := parameters.Validate()
+	if len(problems) != 1 || !strings.Contains(problems[0], "ARN") {
+		t.Errorf("Validate() = %v, want a problem with the placeholder in the ARN", problems)
+	}
+}
+
+func TestValidateEnvironment(t *testing.T) {
+	parameters := Parameters{
+		Component: []Parameter{
+			{Name: "TWICE", Path: "/production/db/host"},
+			{Name: "SIMILAR", Path: "/productionfoo/db/host"},
+			{Name: "SAME_NAME", Path: "/production"},
+			{Name: "NESTED", Path: "/myservice/production/db/host"},
+			{Name: "PLACEHOLDER", Path: "/production/{environment}/host"},
+			{Name: "STAGING_ONLY", Path: "/production/flag", OnlyEnvironments: []string{"staging"}},
+		},
+		External: []Parameter{
+			{Name: "SECRET_TWICE", Path: "secretsmanager://production/api#key"},
+			{Name: "SECRET_SIMILAR", Path: "secretsmanager://productionfoo/api#key"},
+			{Name: "ARN", Path: "secretsmanager://arn:aws:secretsmanager:us-east-1:1:secret:production/api"},
+			{Name: "PLUGIN", Path: "exec://vault/production/api"},
+		},
+		Environments: map[string]Environment{
+			"production": {Parameters: []Parameter{{Name: "SIMILAR", Path: "/production/overridden"}}},
+		},
+	}
+
+	problems := parameters.ValidateEnvironment("production")
+	var names []string
+	for _, problem := range problems {
+		names = append(names, strings.Fields(problem)[2])
+	}
+	want := []string{"`TWICE`", "`SIMILAR`", "`SECRET_TWICE`"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("ValidateEnvironment(production) = %q, want problems for %v", problems, want)
+	}
+	if problems := parameters.ValidateEnvironment("productionfoo"); len(problems) != 2 || !strings.Contains(problems[0], "`SIMILAR`") || !strings.Contains(problems[1], "`SECRET_SIMILAR`") {
+		t.Errorf("ValidateEnvironment(productionfoo) = %q, want problems for SIMILAR and SECRET_SIMILAR", problems)
+	}
+	if problems := parameters.ValidateEnvironment(""); len(problems) != 0 {
+		t.Errorf("ValidateEnvironment(\"\") = %q, want none", problems)
+	}
+}
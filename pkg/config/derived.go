@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// reference matches the `{OPTION_NAME}` references in the value of derived parameters
+var reference = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// References returns the names of the options referenced in a derived value, leaving
+// out placeholders like `{region}`
+func References(value string) []string {
+	var names []string
+	for _, match := range reference.FindAllStringSubmatch(value, -1) {
+		switch match[1] {
+		case PlaceholderEnvironment, PlaceholderRegion, PlaceholderAccountID:
+			continue
+		}
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// Expand replaces the references in a derived value with the result of lookup
+func Expand(value string, lookup func(name string) string) string {
+	return reference.ReplaceAllStringFunc(value, func(match string) string {
+		name := match[1 : len(match)-1]
+		switch name {
+		case PlaceholderEnvironment, PlaceholderRegion, PlaceholderAccountID:
+			return match
+		}
+		return lookup(name)
+	})
+}
+
+// DerivedOrder returns the derived parameters sorted so each one comes after the
+// derived parameters it references. It fails if a value references an unknown option,
+// or if derived parameters reference each other in a cycle.
+func (p Parameters) DerivedOrder() ([]Parameter, error) {
+	known := map[string]bool{}
+	var wildcards []Parameter
+	for _, par := range p.All() {
+		known[par.Name] = true
+		if par.IsWildcard() {
+			wildcards = append(wildcards, par)
+		}
+	}
+	isKnown := func(name string) bool {
+		for _, par := range wildcards {
+			if par.MayExpandTo(name) {
+				return true
+			}
+		}
+		return known[name]
+	}
+	derived := map[string]Parameter{}
+	for _, par := range p.Derived {
+		derived[par.Name] = par
+	}
+
+	var ordered []Parameter
+	// visiting holds the chain of derived parameters being visited, to report cycles
+	var visiting []string
+	visited := map[string]bool{}
+	var visit func(par Parameter) error
+	visit = func(par Parameter) error {
+		if visited[par.Name] {
+			return nil
+		}
+		for i, name := range visiting {
+			if name == par.Name {
+				cycle := append(append([]string{}, visiting[i:]...), name)
+				return fmt.Errorf("derived parameters reference each other in a cycle: %s", strings.Join(cycle, " -> "))
+			}
+		}
+
+		visiting = append(visiting, par.Name)
+		for _, name := range References(par.Value) {
+			if dependency, ok := derived[name]; ok {
+				if err := visit(dependency); err != nil {
+					return err
+				}
+			} else if !isKnown(name) {
+				return fmt.Errorf("derived parameter `%s` references an unknown option: %s", par.Name, name)
+			}
+		}
+		visiting = visiting[:len(visiting)-1]
+
+		visited[par.Name] = true
+		ordered = append(ordered, par)
+		return nil
+	}
+
+	for _, par := range p.Derived {
+		if err := visit(par); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
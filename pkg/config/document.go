@@ -0,0 +1,184 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Document is a yaml parameters file that can be modified and written back keeping
+// its comments and the order of its keys, for commands updating parameters files
+type Document struct {
+	root yaml.Node
+}
+
+// ReadDocument reads the parameters file with name filename as a Document. A missing
+// file is read as an empty document, so it can be created.
+func ReadDocument(filename string) (*Document, error) {
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return ParseDocument(nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseDocument(data)
+}
+
+// ParseDocument parses the yaml contents of a parameters file as a Document
+func ParseDocument(data []byte) (*Document, error) {
+	d := &Document{}
+	if err := yaml.Unmarshal(data, &d.root); err != nil {
+		return nil, err
+	}
+	if d.root.Kind == 0 {
+		d.root = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if d.mapping().Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("a parameters file must hold a mapping")
+	}
+	return d, nil
+}
+
+// Parameters returns the parameters held by the document, as returned by Parse
+func (d *Document) Parameters() (Parameters, error) {
+	var parameters Parameters
+	if err := d.root.Decode(&parameters); err != nil {
+		return parameters, err
+	}
+	parameters.normalize()
+	return parameters, nil
+}
+
+// SetValue sets the value of the parameter with the given option_name in any section,
+// reporting whether it was found
+func (d *Document) SetValue(name string, value string) bool {
+	found := false
+	for _, section := range []string{"component", "external", "derived"} {
+		list := lookup(d.mapping(), section)
+		if list == nil || list.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, item := range list.Content {
+			if option := lookup(item, "option_name"); option != nil && option.Value == name {
+				setField(item, "value", value)
+				found = true
+			}
+		}
+	}
+	return found
+}
+
+// RenamePath replaces the path `from` with `to` in the parameters of every section and
+// in the overrides of the environments, returning how many were replaced
+func (d *Document) RenamePath(from string, to string) int {
+	var lists []*yaml.Node
+	for _, section := range []string{"component", "external"} {
+		lists = append(lists, lookup(d.mapping(), section))
+	}
+	if environments := lookup(d.mapping(), "environments"); environments != nil && environments.Kind == yaml.MappingNode {
+		for i := 1; i < len(environments.Content); i += 2 {
+			lists = append(lists, lookup(environments.Content[i], "parameters"))
+		}
+	}
+
+	renamed := 0
+	for _, list := range lists {
+		if list == nil || list.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, item := range list.Content {
+			if path := lookup(item, "path"); path != nil && path.Value == from {
+				setField(item, "path", to)
+				renamed++
+			}
+		}
+	}
+	return renamed
+}
+
+// Add appends the parameter to a section of the document, e.g. `component`, creating
+// it if needed. Empty fields are left out.
+func (d *Document) Add(section string, par Parameter) error {
+	var item yaml.Node
+	if err := item.Encode(par); err != nil {
+		return err
+	}
+	// drop the empty fields, which are not omitted by the encoder
+	var content []*yaml.Node
+	for i := 0; i+1 < len(item.Content); i += 2 {
+		value := item.Content[i+1]
+		if (value.Value == "" && len(value.Content) == 0) || (value.Tag == "!!bool" && value.Value == "false") || value.Tag == "!!null" {
+			continue
+		}
+		content = append(content, item.Content[i], value)
+	}
+	item.Content = content
+
+	list := lookup(d.mapping(), section)
+	if list == nil {
+		list = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		d.mapping().Content = append(d.mapping().Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: section}, list)
+	}
+	if list.Kind != yaml.SequenceNode {
+		return fmt.Errorf("`%s` is not a list", section)
+	}
+	list.Content = append(list.Content, &item)
+	return nil
+}
+
+// Bytes returns the document as yaml
+func (d *Document) Bytes() ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&d.root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// WriteFile saves the document to the file with name filename
+func (d *Document) WriteFile(filename string) error {
+	data, err := d.Bytes()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// mapping returns the top level mapping of the document
+func (d *Document) mapping() *yaml.Node {
+	return d.root.Content[0]
+}
+
+// lookup returns the value of key in the mapping node, or nil if there's none
+func lookup(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setField sets key to a string value in the mapping node, adding it if missing
+func setField(mapping *yaml.Node, key string, value string) {
+	if node := lookup(mapping, key); node != nil {
+		// keep the comments, but let the encoder choose the quoting again
+		node.Kind, node.Tag, node.Style, node.Value, node.Content = yaml.ScalarNode, "!!str", 0, value, nil
+		return
+	}
+	mapping.Content = append(mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+}
@@ -0,0 +1,230 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Environment holds the differences of an environment from the base parameters
+type Environment struct {
+	// Extends is the name of another environment whose overrides are applied first,
+	// so this one only needs to list its own differences
+	Extends string `yaml:"extends" json:"extends,omitempty"`
+	// Parameters override the fields they set in the base parameter with the same option_name
+	Parameters []Parameter `yaml:"parameters" json:"parameters,omitempty"`
+}
+
+// environmentChain returns the environments whose overrides apply to environment, in
+// order of precedence from lowest to highest: the environment it extends, recursively,
+// come before itself. It fails if an environment extends an unknown one or itself.
+func (p Parameters) environmentChain(environment string) ([]string, error) {
+	var chain []string
+	for name := environment; name != ""; name = p.Environments[name].Extends {
+		for i, seen := range chain {
+			if seen == name {
+				// start at the first name alphabetically, so the cycle reads the same from any of its environments
+				cycle := chain[i:]
+				first := 0
+				for j := range cycle {
+					if cycle[j] < cycle[first] {
+						first = j
+					}
+				}
+				cycle = append(append(append([]string{}, cycle[first:]...), cycle[:first]...), cycle[first])
+				return nil, fmt.Errorf("environments extend each other in a cycle: %s", strings.Join(cycle, " -> "))
+			}
+		}
+		if _, ok := p.Environments[name]; !ok && name != environment {
+			return nil, fmt.Errorf("environment `%s` extends an unknown environment: %s", chain[len(chain)-1], name)
+		}
+		chain = append(chain, name)
+	}
+
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, nil
+}
+
+// usedIn returns a copy of the parameters without the ones not used in the environment
+func (p Parameters) usedIn(environment string) Parameters {
+	used := p
+	filter := func(list []Parameter) []Parameter {
+		var kept []Parameter
+		for _, par := range list {
+			if par.usedIn(environment) {
+				kept = append(kept, par)
+			}
+		}
+		return kept
+	}
+	used.Component = filter(p.Component)
+	used.External = filter(p.External)
+	used.Derived = filter(p.Derived)
+	return used
+}
+
+// usedIn reports whether the parameter is used in the environment, which is the case
+// unless its only_environments doesn't list it, or its except_environments does
+func (par Parameter) usedIn(environment string) bool {
+	if len(par.OnlyEnvironments) > 0 && !contains(par.OnlyEnvironments, environment) {
+		return false
+	}
+	return !contains(par.ExceptEnvironments, environment)
+}
+
+// contains reports whether list holds s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// withOverrides returns a copy of the parameters where the parameters overridden by
+// the environment, or the ones it extends, have the fields set in the overrides replacing
+// their own. The overrides of an environment take precedence over the ones it extends.
+func (p Parameters) withOverrides(environment string) Parameters {
+	// an invalid chain is reported by Validate, so just apply no overrides
+	chain, _ := p.environmentChain(environment)
+
+	overridden := p.copy()
+	for _, name := range chain {
+		for _, override := range p.Environments[name].Parameters {
+			for _, list := range [][]Parameter{overridden.Component, overridden.External, overridden.Derived} {
+				for i, par := range list {
+					if par.Name == override.Name {
+						list[i], _ = par.override(override)
+					}
+				}
+			}
+		}
+	}
+	return overridden
+}
+
+// override returns the parameter with the non-empty fields of o replacing its own,
+// along with the yaml names of the fields replaced
+func (par Parameter) override(o Parameter) (Parameter, []string) {
+	var fields []string
+	if o.Description != "" {
+		par.Description = o.Description
+		fields = append(fields, "description")
+	}
+	if o.Path != "" {
+		par.Path = o.Path
+		fields = append(fields, "path")
+	}
+	if o.Value != "" {
+		par.Value = o.Value
+		fields = append(fields, "value")
+	}
+	if o.Source != "" {
+		par.Source = o.Source
+		fields = append(fields, "source")
+	}
+	if o.Type != "" {
+		par.Type = o.Type
+		fields = append(fields, "type")
+	}
+	if o.Required != nil {
+		par.Required = o.Required
+		par.Optional = !*o.Required
+		fields = append(fields, "required")
+	} else if o.Optional {
+		par.Optional = true
+		fields = append(fields, "optional")
+	}
+	if o.Default != "" {
+		par.Default = o.Default
+		fields = append(fields, "default")
+	}
+	if o.RoleARN != "" {
+		par.RoleARN = o.RoleARN
+		fields = append(fields, "role_arn")
+	}
+	if o.Profile != "" {
+		par.Profile = o.Profile
+		fields = append(fields, "profile")
+	}
+	return par, fields
+}
+
+// Explanation is a parameter as it's effective in an environment
+type Explanation struct {
+	// Parameter is the effective parameter, with the overrides and the environment prefix applied
+	Parameter Parameter
+	// Origins maps the yaml name of each field overridden, e.g. `path`, to the environment
+	// the effective value comes from. Fields missing from it come from the base parameter.
+	Origins map[string]string
+}
+
+// Explain returns the parameters effective in the environment, telling where each of
+// their fields comes from, in the order of All followed by the derived parameters
+func (p Parameters) Explain(environment string) ([]Explanation, error) {
+	chain, err := p.environmentChain(environment)
+	if err != nil {
+		return nil, err
+	}
+
+	origins := map[string]map[string]string{}
+	for _, name := range chain {
+		for _, override := range p.Environments[name].Parameters {
+			_, fields := Parameter{}.override(override)
+			if origins[override.Name] == nil {
+				origins[override.Name] = map[string]string{}
+			}
+			for _, field := range fields {
+				origins[override.Name][field] = name
+			}
+		}
+	}
+
+	effective := p.WithEnvironment(environment)
+	var explanations []Explanation
+	for _, par := range append(effective.All(), effective.Derived...) {
+		explanations = append(explanations, Explanation{Parameter: par, Origins: origins[par.Name]})
+	}
+	return explanations, nil
+}
+
+// validateEnvironments returns the problems found in the overrides of the environments,
+// given the option names of the base parameters
+func (p Parameters) validateEnvironments(names map[string]bool) []string {
+	var problems []string
+
+	environments := make([]string, 0, len(p.Environments))
+	for name := range p.Environments {
+		environments = append(environments, name)
+	}
+	sort.Strings(environments)
+
+	// environments in a cycle would report it once each otherwise
+	reported := map[string]bool{}
+	for _, environment := range environments {
+		if _, err := p.environmentChain(environment); err != nil && !reported[err.Error()] {
+			problems = append(problems, err.Error())
+			reported[err.Error()] = true
+		}
+		for i, par := range p.Environments[environment].Parameters {
+			if par.Name == "" {
+				problems = append(problems, fmt.Sprintf("environment `%s` parameter #%d has no option_name", environment, i+1))
+			} else if !names[par.Name] {
+				problems = append(problems, fmt.Sprintf("environment `%s` overrides an unknown option: %s", environment, par.Name))
+			}
+			if par.Source != "" && par.Path == "" {
+				problems = append(problems, fmt.Sprintf("environment `%s` parameter `%s` sets a source without a path", environment, par.Name))
+			}
+			if par.Type != "" && !types[par.Type] {
+				problems = append(problems, fmt.Sprintf("environment `%s` parameter `%s` has an unknown type: %s", environment, par.Name, par.Type))
+			}
+			if par.RoleARN != "" && !strings.HasPrefix(par.RoleARN, "arn:") {
+				problems = append(problems, fmt.Sprintf("environment `%s` parameter `%s` has a role_arn that is not an ARN: %s", environment, par.Name, par.RoleARN))
+			}
+		}
+	}
+	return problems
+}
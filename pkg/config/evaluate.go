@@ -0,0 +1,31 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// evaluators holds the commands evaluating the files in each of the formats of the
+// languages evaluated to JSON, which are given the name of the file after them
+var evaluators = map[string][]string{
+	FormatCUE:     {"cue", "export", "--out", "json"},
+	FormatJsonnet: {"jsonnet"},
+}
+
+// evaluate runs the evaluator of the format on the file and returns the JSON it writes
+func evaluate(filename string, format string) ([]byte, error) {
+	command := evaluators[format]
+	cmd := exec.Command(command[0], append(command[1:len(command):len(command)], filename)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	data, err := cmd.Output()
+	if execErr, ok := err.(*exec.Error); ok && execErr.Err == exec.ErrNotFound {
+		return nil, fmt.Errorf("evaluating %s files needs the `%s` command, which isn't in the PATH", format, command[0])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("evaluating with `%s`: %v: %s", command[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return data, nil
+}
@@ -0,0 +1,499 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// hclToJSON converts a parameters file written in HCL into the same structure in JSON.
+// Sections holding lists or maps of parameters are written as repeated blocks, with the
+// option_name or the map key as label, while the fields are attributes:
+//
+//	component "DB_HOST" {
+//	  path = "/myservice/db_host"
+//	}
+//	environments "staging" {
+//	  parameters "LOG_LEVEL" {
+//	    default = "debug"
+//	  }
+//	}
+//
+// Only literal values are supported, without interpolation or functions.
+func hclToJSON(data []byte) ([]byte, error) {
+	p := &hclParser{data: string(data), line: 1}
+	body, err := p.parseBody(false)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %v", p.line, err)
+	}
+	object, err := hclObject(body, reflect.TypeOf(Parameters{}))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(object)
+}
+
+// hclBody is the contents of an HCL file or block
+type hclBody struct {
+	attributes []hclAttribute
+	blocks     []hclBlock
+}
+
+// hclAttribute is an attribute of an HCL body, like `path = "/a"`
+type hclAttribute struct {
+	name  string
+	value interface{}
+	line  int
+}
+
+// hclBlock is a block of an HCL body, like `component "A" { ... }`
+type hclBlock struct {
+	kind   string
+	labels []string
+	body   hclBody
+	line   int
+}
+
+// hclObject converts the body into the JSON object of the struct type t, whose fields
+// are named after their json tags. Blocks of slices are appended, with their label as
+// option_name, blocks of maps are keyed by their label, and blocks of structs are set.
+func hclObject(body hclBody, t reflect.Type) (map[string]interface{}, error) {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = t.Field(i).Type
+		}
+	}
+
+	object := map[string]interface{}{}
+	for _, attribute := range body.attributes {
+		field, ok := fields[attribute.name]
+		if !ok {
+			return nil, fmt.Errorf("line %d: unknown attribute `%s`", attribute.line, attribute.name)
+		}
+		if _, ok := object[attribute.name]; ok {
+			return nil, fmt.Errorf("line %d: `%s` is set more than once", attribute.line, attribute.name)
+		}
+		value := attribute.value
+		if field.Kind() == reflect.String {
+			// like yaml, numbers and booleans are read as text in text fields
+			switch v := value.(type) {
+			case json.Number:
+				value = v.String()
+			case bool:
+				value = strconv.FormatBool(v)
+			}
+		}
+		object[attribute.name] = value
+	}
+
+	for _, block := range body.blocks {
+		field, ok := fields[block.kind]
+		if !ok {
+			return nil, fmt.Errorf("line %d: unknown block `%s`", block.line, block.kind)
+		}
+		switch {
+		case field.Kind() == reflect.Slice && field.Elem().Kind() == reflect.Struct:
+			if len(block.labels) > 1 {
+				return nil, fmt.Errorf("line %d: `%s` blocks take the option_name as their only label", block.line, block.kind)
+			}
+			element, err := hclObject(block.body, field.Elem())
+			if err != nil {
+				return nil, err
+			}
+			if len(block.labels) == 1 {
+				if _, ok := element["option_name"]; ok {
+					return nil, fmt.Errorf("line %d: the option_name is both the label and an attribute", block.line)
+				}
+				element["option_name"] = block.labels[0]
+			}
+			list, _ := object[block.kind].([]interface{})
+			object[block.kind] = append(list, element)
+		case field.Kind() == reflect.Map && field.Elem().Kind() == reflect.Struct:
+			if len(block.labels) != 1 {
+				return nil, fmt.Errorf("line %d: `%s` blocks take their name as their only label", block.line, block.kind)
+			}
+			entries, ok := object[block.kind].(map[string]interface{})
+			if !ok {
+				entries = map[string]interface{}{}
+				object[block.kind] = entries
+			}
+			if _, ok := entries[block.labels[0]]; ok {
+				return nil, fmt.Errorf("line %d: `%s \"%s\"` is defined more than once", block.line, block.kind, block.labels[0])
+			}
+			entry, err := hclObject(block.body, field.Elem())
+			if err != nil {
+				return nil, err
+			}
+			entries[block.labels[0]] = entry
+		case field.Kind() == reflect.Struct:
+			if len(block.labels) > 0 {
+				return nil, fmt.Errorf("line %d: `%s` blocks take no labels", block.line, block.kind)
+			}
+			if _, ok := object[block.kind]; ok {
+				return nil, fmt.Errorf("line %d: `%s` is defined more than once", block.line, block.kind)
+			}
+			entry, err := hclObject(block.body, field)
+			if err != nil {
+				return nil, err
+			}
+			object[block.kind] = entry
+		default:
+			return nil, fmt.Errorf("line %d: `%s` is an attribute, set like `%s = ...`", block.line, block.kind, block.kind)
+		}
+	}
+	return object, nil
+}
+
+// hclParser parses the subset of the HCL syntax holding literal values
+type hclParser struct {
+	data string
+	pos  int
+	// line is the line of pos, reported in the errors
+	line int
+}
+
+// parseBody parses attributes and blocks until the end of the data, or of the block if
+// inBlock, consuming its closing brace
+func (p *hclParser) parseBody(inBlock bool) (hclBody, error) {
+	var body hclBody
+	for {
+		p.skipSpace()
+		if p.pos == len(p.data) {
+			if inBlock {
+				return body, fmt.Errorf("missing `}` at the end of the block")
+			}
+			return body, nil
+		}
+		if p.data[p.pos] == '}' && inBlock {
+			p.pos++
+			return body, nil
+		}
+
+		line := p.line
+		name := p.identifier()
+		if name == "" && strings.HasPrefix(p.data[p.pos:], "/*") {
+			return body, fmt.Errorf("missing `*/` at the end of the comment")
+		}
+		if name == "" {
+			return body, fmt.Errorf("expected an attribute or a block, found `%s`", p.found())
+		}
+		p.skipSpace()
+		if p.consume('=') {
+			value, err := p.parseValue()
+			if err != nil {
+				return body, err
+			}
+			body.attributes = append(body.attributes, hclAttribute{name: name, value: value, line: line})
+			continue
+		}
+
+		block := hclBlock{kind: name, line: line}
+		for !p.consume('{') {
+			var label string
+			var err error
+			if p.pos < len(p.data) && p.data[p.pos] == '"' {
+				label, err = p.parseString()
+			} else if label = p.identifier(); label == "" {
+				err = fmt.Errorf("expected `=` or a block after `%s`, found `%s`", name, p.found())
+			}
+			if err != nil {
+				return body, err
+			}
+			block.labels = append(block.labels, label)
+			p.skipSpace()
+		}
+		var err error
+		if block.body, err = p.parseBody(true); err != nil {
+			return body, err
+		}
+		body.blocks = append(body.blocks, block)
+	}
+}
+
+// parseValue parses a literal: a string, a heredoc, a number, a boolean, null, or a
+// list or object of literals
+func (p *hclParser) parseValue() (interface{}, error) {
+	p.skipSpace()
+	switch {
+	case p.pos == len(p.data):
+		return nil, fmt.Errorf("missing value at the end of the file")
+	case p.data[p.pos] == '"':
+		return p.parseString()
+	case strings.HasPrefix(p.data[p.pos:], "<<"):
+		return p.parseHeredoc()
+	case p.consume('['):
+		list := []interface{}{}
+		for {
+			p.skipSpace()
+			if p.consume(']') {
+				return list, nil
+			}
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+			p.skipSpace()
+			if !p.consume(',') && !strings.HasPrefix(p.data[p.pos:], "]") {
+				return nil, fmt.Errorf("expected `,` or `]` in the list, found `%s`", p.found())
+			}
+		}
+	case p.consume('{'):
+		object := map[string]interface{}{}
+		for {
+			p.skipSpace()
+			if p.consume('}') {
+				return object, nil
+			}
+			var key string
+			var err error
+			if p.pos < len(p.data) && p.data[p.pos] == '"' {
+				key, err = p.parseString()
+			} else if key = p.identifier(); key == "" {
+				err = fmt.Errorf("expected a key in the object, found `%s`", p.found())
+			}
+			if err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			if !p.consume('=') && !p.consume(':') {
+				return nil, fmt.Errorf("expected `=` after `%s`, found `%s`", key, p.found())
+			}
+			if object[key], err = p.parseValue(); err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			p.consume(',')
+		}
+	}
+
+	word := p.identifier()
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	case "":
+		start := p.pos
+		for p.pos < len(p.data) && strings.IndexByte("+-.0123456789eE", p.data[p.pos]) >= 0 {
+			p.pos++
+		}
+		number := p.data[start:p.pos]
+		if _, err := strconv.ParseFloat(number, 64); err != nil {
+			p.pos = start
+			return nil, fmt.Errorf("expected a value, found `%s`", p.found())
+		}
+		return json.Number(number), nil
+	}
+	return nil, fmt.Errorf("`%s` isn't a literal value, only literals are supported", word)
+}
+
+// parseString parses a quoted string, unescaping it
+func (p *hclParser) parseString() (string, error) {
+	var text strings.Builder
+	p.pos++
+	for {
+		if p.pos == len(p.data) || p.data[p.pos] == '\n' {
+			return "", fmt.Errorf("missing `\"` at the end of the string")
+		}
+		c := p.data[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return text.String(), nil
+		case c == '\\' && p.pos+1 < len(p.data):
+			p.pos++
+			switch escaped := p.data[p.pos]; escaped {
+			case 'n':
+				text.WriteByte('\n')
+			case 'r':
+				text.WriteByte('\r')
+			case 't':
+				text.WriteByte('\t')
+			case '"', '\\':
+				text.WriteByte(escaped)
+			case 'u', 'U':
+				size := 4
+				if escaped == 'U' {
+					size = 8
+				}
+				if p.pos+size >= len(p.data) {
+					return "", fmt.Errorf("invalid escape `\\%c` in the string", escaped)
+				}
+				code, err := strconv.ParseUint(p.data[p.pos+1:p.pos+1+size], 16, 32)
+				if err != nil {
+					return "", fmt.Errorf("invalid escape `\\%s` in the string", p.data[p.pos:p.pos+1+size])
+				}
+				text.WriteRune(rune(code))
+				p.pos += size
+			default:
+				return "", fmt.Errorf("invalid escape `\\%c` in the string", escaped)
+			}
+			p.pos++
+		default:
+			if err := p.template(&text); err != nil {
+				return "", err
+			}
+		}
+	}
+}
+
+// parseHeredoc parses a `<<EOF` heredoc, or a `<<-EOF` one whose lines are unindented
+// by the indentation of the least indented one
+func (p *hclParser) parseHeredoc() (string, error) {
+	p.pos += 2
+	indented := p.consume('-')
+	marker := p.identifier()
+	end := strings.IndexByte(p.data[p.pos:], '\n')
+	if marker == "" || end < 0 || strings.TrimSpace(p.data[p.pos:p.pos+end]) != "" {
+		return "", fmt.Errorf("expected a heredoc like `<<EOF` on a line of its own")
+	}
+	p.pos += end + 1
+	p.line++
+
+	var lines []string
+	for {
+		if p.pos == len(p.data) {
+			return "", fmt.Errorf("missing `%s` at the end of the heredoc", marker)
+		}
+		end := strings.IndexByte(p.data[p.pos:], '\n')
+		if end < 0 {
+			end = len(p.data) - p.pos
+		}
+		line := p.data[p.pos : p.pos+end]
+		p.pos += end
+		if strings.TrimSpace(line) == marker {
+			break
+		}
+		lines = append(lines, line)
+		if p.pos < len(p.data) {
+			p.pos++
+			p.line++
+		}
+	}
+
+	if indented {
+		indentation := 0
+		first := true
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if n := len(line) - len(strings.TrimLeft(line, " \t")); first || n < indentation {
+				indentation, first = n, false
+			}
+		}
+		for i, line := range lines {
+			if len(line) >= indentation {
+				lines[i] = line[indentation:]
+			} else {
+				// only blank lines are shorter
+				lines[i] = ""
+			}
+		}
+	}
+	var text strings.Builder
+	heredoc := hclParser{data: strings.Join(lines, "\n"), line: p.line}
+	for heredoc.pos < len(heredoc.data) {
+		if err := heredoc.template(&text); err != nil {
+			return "", err
+		}
+	}
+	if len(lines) > 0 {
+		text.WriteByte('\n')
+	}
+	return text.String(), nil
+}
+
+// template copies the character at pos to text, rejecting the interpolations and
+// directives of the templates of HCL, and unescaping `$${` and `%%{` into `${` and `%{`
+func (p *hclParser) template(text *strings.Builder) error {
+	rest := p.data[p.pos:]
+	switch {
+	case strings.HasPrefix(rest, "$${"), strings.HasPrefix(rest, "%%{"):
+		text.WriteString(rest[1:3])
+		p.pos += 3
+	case strings.HasPrefix(rest, "${"), strings.HasPrefix(rest, "%{"):
+		return fmt.Errorf("templates aren't supported, escape `%s` as `%c%s`", rest[:2], rest[0], rest[:2])
+	default:
+		r, size := utf8.DecodeRuneInString(rest)
+		text.WriteRune(r)
+		p.pos += size
+	}
+	return nil
+}
+
+// identifier consumes and returns the identifier at pos, if any
+func (p *hclParser) identifier() string {
+	start := p.pos
+	for p.pos < len(p.data) {
+		r, size := utf8.DecodeRuneInString(p.data[p.pos:])
+		if !unicode.IsLetter(r) && r != '_' && (p.pos == start || !unicode.IsDigit(r) && r != '-') {
+			break
+		}
+		p.pos += size
+	}
+	return p.data[start:p.pos]
+}
+
+// consume consumes the character at pos if it's c, reporting whether it was
+func (p *hclParser) consume(c byte) bool {
+	if p.pos < len(p.data) && p.data[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// skipSpace skips the whitespace, line breaks and comments at pos
+func (p *hclParser) skipSpace() {
+	for p.pos < len(p.data) {
+		rest := p.data[p.pos:]
+		switch {
+		case rest[0] == '\n':
+			p.line++
+			p.pos++
+		case rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\r':
+			p.pos++
+		case rest[0] == '#' || strings.HasPrefix(rest, "//"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			p.pos += end
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				// left for the parser to report
+				return
+			}
+			p.line += strings.Count(rest[:end+4], "\n")
+			p.pos += end + 4
+		default:
+			return
+		}
+	}
+}
+
+// found returns the text at pos, for the errors
+func (p *hclParser) found() string {
+	rest := p.data[p.pos:]
+	if rest == "" {
+		return "the end of the file"
+	}
+	if end := strings.IndexAny(rest, " \t\r\n"); end > 0 {
+		rest = rest[:end]
+	}
+	if len(rest) > 20 {
+		rest = rest[:20]
+	}
+	return rest
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHCLToJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		hcl  string
+		want string
+	}{
+		{"empty", "", `{}`},
+		{"labelled blocks", `
+component "DB_HOST" {
+  path = "/myservice/db/host"
+}
+component "DB_PORT" {
+  path = "/myservice/db/port"
+}`, `{"component": [
+			{"option_name": "DB_HOST", "path": "/myservice/db/host"},
+			{"option_name": "DB_PORT", "path": "/myservice/db/port"}]}`},
+		{"unlabelled block", `
+external {
+  option_name = "QUEUE_URL"
+  path        = "/shared/queue"
+}`, `{"external": [{"option_name": "QUEUE_URL", "path": "/shared/queue"}]}`},
+		{"map and struct blocks", `
+naming {
+  prefix = "APP_"
+}
+environments "production" {
+  extends = "staging"
+  parameters "LOG_LEVEL" {
+    default = "warn"
+  }
+}`, `{"naming": {"prefix": "APP_"}, "environments": {"production": {
+			"extends": "staging", "parameters": [{"option_name": "LOG_LEVEL", "default": "warn"}]}}}`},
+		{"literals", `
+include = ["base.hcl", "shared.hcl",]
+component "PORT" {
+  value    = 8080
+  optional = true
+  required = false
+  targets  = []
+  group    = null
+}`, `{"include": ["base.hcl", "shared.hcl"], "component": [
+			{"option_name": "PORT", "value": "8080", "optional": true, "required": false, "targets": [], "group": null}]}`},
+		{"booleans in text fields", `
+component "DEBUG" {
+  value = true
+}`, `{"component": [{"option_name": "DEBUG", "value": "true"}]}`},
+		{"string escapes", `
+component "GREETING" {
+  value = "say \"hi\"\t\\ é\n$${HOME} %%{if}"
+}`, `{"component": [{"option_name": "GREETING", "value": "say \"hi\"\t\\ é\n${HOME} %{if}"}]}`},
+		{"heredoc", `
+component "CERT" {
+  value = <<EOF
+line one
+  line two
+EOF
+}`, `{"component": [{"option_name": "CERT", "value": "line one\n  line two\n"}]}`},
+		{"indented heredoc", `
+component "CERT" {
+  value = <<-EOF
+    line one
+
+      line two
+    EOF
+}`, `{"component": [{"option_name": "CERT", "value": "line one\n\n  line two\n"}]}`},
+		{"comments", `
+# a comment
+// another one
+/* a block
+   comment */
+component "A" { // trailing
+  path = "/a" # trailing
+}`, `{"component": [{"option_name": "A", "path": "/a"}]}`},
+		{"quoted and dashed labels", `
+components "my-service" {
+  component "A" {
+    path = "/a"
+  }
+}`, `{"components": {"my-service": {"component": [{"option_name": "A", "path": "/a"}]}}}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := hclToJSON([]byte(test.hcl))
+			if err != nil {
+				t.Fatalf("hclToJSON() error = %v", err)
+			}
+			var got, want interface{}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("hclToJSON() returned invalid JSON %s: %v", data, err)
+			}
+			if err := json.Unmarshal([]byte(test.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("hclToJSON() = %s, want %s", data, test.want)
+			}
+		})
+	}
+}
+
+func TestHCLToJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		hcl  string
+		err  string
+	}{
+		{"unknown attribute", `colour = "red"`, "line 1: unknown attribute `colour`"},
+		{"unknown block", "\nsecrets \"A\" {\n}", "line 2: unknown block `secrets`"},
+		{"attribute set twice", "component \"A\" {\n  path = \"/a\"\n  path = \"/b\"\n}", "line 3: `path` is set more than once"},
+		{"attribute as a block", "component \"A\" {\n  path {\n  }\n}", "line 2: `path` is an attribute"},
+		{"two labels", `component "A" "B" {}`, "take the option_name as their only label"},
+		{"label and option_name", "component \"A\" {\n  option_name = \"B\"\n}", "the option_name is both the label and an attribute"},
+		{"map block without label", `environments {}`, "take their name as their only label"},
+		{"map block defined twice", "environments \"a\" {}\nenvironments \"a\" {}", "line 2: `environments \"a\"` is defined more than once"},
+		{"struct block with label", `naming "a" {}`, "`naming` blocks take no labels"},
+		{"struct block defined twice", "naming {}\nnaming {}", "line 2: `naming` is defined more than once"},
+		{"unclosed block", "component \"A\" {\n  path = \"/a\"\n", "missing `}` at the end of the block"},
+		{"missing value", `include =`, "missing value at the end of the file"},
+		{"unclosed string", "component \"A\" {\n  path = \"/a\n}", "line 2: missing `\"` at the end of the string"},
+		{"invalid escape", `component "A" { value = "\q" }`, "invalid escape `\\q`"},
+		{"invalid unicode escape", `component "A" { value = "\u00zz" }`, "invalid escape `\\u00zz`"},
+		{"interpolation", `component "A" { value = "${var.a}" }`, "templates aren't supported, escape `${` as `$${`"},
+		{"directive in heredoc", "component \"A\" {\n  value = <<EOF\n%{if a}\nEOF\n}", "templates aren't supported"},
+		{"unclosed heredoc", "component \"A\" {\n  value = <<EOF\nline\n", "missing `EOF` at the end of the heredoc"},
+		{"heredoc without marker", `component "A" { value = <<EOF }`, "expected a heredoc like `<<EOF` on a line of its own"},
+		{"function call", `component "A" { value = file("a") }`, "`file` isn't a literal value"},
+		{"invalid number", `component "A" { value = 1-2 }`, "expected a value, found `1-2`"},
+		{"unclosed list", `include = ["a" "b"]`, "expected `,` or `]` in the list"},
+		{"object without equals", `include = {a "b"}`, "expected `=` after `a`"},
+		{"unclosed comment", "component \"A\" {}\n/* a comment", "line 2: missing `*/` at the end of the comment"},
+		{"stray brace", `}`, "expected an attribute or a block, found `}`"},
+		{"missing equals", `component "A" { path "/a" }`, "expected `=` or a block after `path`"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := hclToJSON([]byte(test.hcl))
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("hclToJSON() error = %v, want one containing %q", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Load reads the parameters file with name filename along with the files it includes,
+// recursively, merging them. Included files are relative to the file including them,
+// and are only read once even if included several times. The file is parsed in the
+// given format, or in the detected one if it's empty, like the included files.
+func Load(filename string, format string) (Parameters, error) {
+	loader := loader{loaded: map[string]bool{}, format: format}
+	if err := loader.loadFile(filename, nil); err != nil {
+		return Parameters{}, err
+	}
+	return Merge(loader.files...)
+}
+
+// LoadData is like Load for the contents of a parameters file read elsewhere, e.g.
+// from stdin, whose includes are relative to dir
+func LoadData(data []byte, dir string, format string) (Parameters, error) {
+	loader := loader{loaded: map[string]bool{}, format: format}
+	if err := loader.loadData(data, dir, nil); err != nil {
+		return Parameters{}, err
+	}
+	return Merge(loader.files...)
+}
+
+// Files returns the absolute names of the parameters file with name filename and of the
+// files it includes, recursively, sorted. If one of them can't be read, the files found
+// until then, including that one, are returned along with the error.
+func Files(filename string, format string) ([]string, error) {
+	loader := loader{loaded: map[string]bool{}, format: format}
+	err := loader.loadFile(filename, nil)
+	var files []string
+	for file := range loader.loaded {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files, err
+}
+
+// loader reads parameters files and the files they include
+type loader struct {
+	// files holds the parameters of every file read, in order
+	files []Parameters
+	// loaded holds the absolute names of the files already read
+	loaded map[string]bool
+	// format is the format of the first file, detected if empty
+	format string
+}
+
+// loadFile reads filename and the files it includes. Stack holds the absolute names of
+// the files including it, to detect cycles.
+func (l *loader) loadFile(filename string, stack []string) error {
+	absolute, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+	for _, including := range stack {
+		if including == absolute {
+			return fmt.Errorf("files include each other in a cycle: %s", strings.Join(append(stack, absolute), " -> "))
+		}
+	}
+	if l.loaded[absolute] {
+		return nil
+	}
+	l.loaded[absolute] = true
+
+	var parameters Parameters
+	if format := l.fileFormat(filename); evaluators[format] != nil {
+		var data []byte
+		data, err = evaluate(filename, format)
+		if err == nil {
+			parameters, err = ParseFormat(data, FormatJSON)
+		}
+	} else {
+		var data []byte
+		data, err = ioutil.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		parameters, err = ParseFormat(data, format)
+	}
+	if err != nil && len(stack) > 0 {
+		return fmt.Errorf("%s: %v", filename, err)
+	}
+	if err != nil {
+		// the caller reports the name of the top level file
+		return err
+	}
+	return l.add(parameters, filepath.Dir(filename), append(stack, absolute))
+}
+
+// loadData parses data and reads the files it includes, relative to dir
+func (l *loader) loadData(data []byte, dir string, stack []string) error {
+	parameters, err := l.parse(data)
+	if err != nil {
+		return err
+	}
+	return l.add(parameters, dir, stack)
+}
+
+// add appends the parameters and reads the files they include, relative to dir
+func (l *loader) add(parameters Parameters, dir string, stack []string) error {
+	l.files = append(l.files, parameters)
+
+	for _, include := range parameters.Include {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(dir, include)
+		}
+		if err := l.loadFile(include, stack); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileFormat returns the format of the file, which is the one of the loader for the first
+// file if given, or the one of its extension if it's cue, jsonnet or hcl, and empty to
+// detect it otherwise
+func (l *loader) fileFormat(filename string) string {
+	if len(l.files) == 0 && l.format != "" {
+		return l.format
+	}
+	return extensionFormat(filename)
+}
+
+// extensionFormat returns the format of the file given by its extension if it's cue,
+// jsonnet or hcl, or empty otherwise
+func extensionFormat(filename string) string {
+	switch filepath.Ext(filename) {
+	case ".cue":
+		return FormatCUE
+	case ".jsonnet":
+		return FormatJsonnet
+	case ".hcl":
+		return FormatHCL
+	}
+	return ""
+}
+
+// FileFormat returns the format the parameters file with the name and contents is read
+// in, which is the one of its extension if it's cue, jsonnet or hcl, or the one detected
+// in the contents otherwise
+func FileFormat(filename string, data []byte) string {
+	if format := extensionFormat(filename); format != "" {
+		return format
+	}
+	return DetectFormat(data)
+}
+
+// parse parses the contents of a file, in the format of the loader if it's the first one
+func (l *loader) parse(data []byte) (Parameters, error) {
+	if len(l.files) == 0 {
+		return ParseFormat(data, l.format)
+	}
+	return Parse(data)
+}
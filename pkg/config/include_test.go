@@ -0,0 +1,46 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssmeb-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"params.yaml":        "include: [shared/base.yaml, missing.yaml]\n",
+		"shared/base.yaml":   "include: [common.yaml]\n",
+		"shared/common.yaml": "include: [../shared/base.yaml]\n",
+	}
+	for name, contents := range files {
+		filename := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Files(filepath.Join(dir, "shared/base.yaml"), "")
+	want := []string{filepath.Join(dir, "shared/base.yaml"), filepath.Join(dir, "shared/common.yaml")}
+	if err == nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Files() = %v, %v, want %v and the cycle error", got, err, want)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "shared/common.yaml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err = Files(filepath.Join(dir, "params.yaml"), "")
+	want = []string{filepath.Join(dir, "missing.yaml"), filepath.Join(dir, "params.yaml"), filepath.Join(dir, "shared/base.yaml"), filepath.Join(dir, "shared/common.yaml")}
+	if err == nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Files() = %v, %v, want %v and the error of missing.yaml", got, err, want)
+	}
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+)
+
+// Merge combines the parameters read from several files, in order. It fails if an
+// option is defined by more than one of them, or if they set different namings.
+// Environments defined by several files get the overrides of all of them, and components
+// the parameters of all of them.
+func Merge(files ...Parameters) (Parameters, error) {
+	var merged Parameters
+	names := map[string]bool{}
+	for _, file := range files {
+		for _, par := range append(file.All(), file.Derived...) {
+			if par.Name == "" {
+				continue
+			}
+			if names[par.Name] {
+				return merged, fmt.Errorf("option_name `%s` is defined in more than one file", par.Name)
+			}
+			names[par.Name] = true
+		}
+		merged.Component = append(merged.Component, file.Component...)
+		merged.External = append(merged.External, file.External...)
+		merged.Derived = append(merged.Derived, file.Derived...)
+
+		if file.Naming != (Naming{}) {
+			if merged.Naming != (Naming{}) && merged.Naming != file.Naming {
+				return merged, fmt.Errorf("the files set different namings")
+			}
+			merged.Naming = file.Naming
+		}
+
+		for name, env := range file.Environments {
+			if merged.Environments == nil {
+				merged.Environments = map[string]Environment{}
+			}
+			existing, ok := merged.Environments[name]
+			if ok && existing.Extends != "" && env.Extends != "" && existing.Extends != env.Extends {
+				return merged, fmt.Errorf("environment `%s` extends different environments in different files", name)
+			}
+			if env.Extends == "" {
+				env.Extends = existing.Extends
+			}
+			env.Parameters = append(append([]Parameter{}, existing.Parameters...), env.Parameters...)
+			merged.Environments[name] = env
+		}
+
+		for name, component := range file.Components {
+			if merged.Components == nil {
+				merged.Components = map[string]Parameters{}
+			}
+			if existing, ok := merged.Components[name]; ok {
+				var err error
+				component, err = Merge(existing, component)
+				if err != nil {
+					return merged, fmt.Errorf("component `%s`: %v", name, err)
+				}
+			}
+			merged.Components[name] = component
+		}
+	}
+	return merged, nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Naming transforms the names of the options, so the names of the parameters don't
+// need to follow the convention expected by the application
+type Naming struct {
+	// Case converts the names to a case, e.g. `upper_snake` turns `dbHost` into `DB_HOST`
+	Case string `yaml:"case" json:"case,omitempty"`
+	// Prefix is prepended to the names after converting their case, e.g. `APP_`
+	Prefix string `yaml:"prefix" json:"prefix,omitempty"`
+}
+
+// Cases of the option names
+const (
+	// CaseUpper converts the names to upper case, e.g. `DB_HOST`
+	CaseUpper = "upper"
+	// CaseLower converts the names to lower case, e.g. `db_host`
+	CaseLower = "lower"
+	// CaseSnake converts the names to snake case, e.g. `dbHost` and `db-host` into `db_host`
+	CaseSnake = "snake"
+	// CaseUpperSnake converts the names to upper snake case, e.g. `dbHost` into `DB_HOST`
+	CaseUpperSnake = "upper_snake"
+)
+
+// cases holds every valid Case
+var cases = map[string]bool{CaseUpper: true, CaseLower: true, CaseSnake: true, CaseUpperSnake: true}
+
+// Apply returns the name transformed as configured
+func (n Naming) Apply(name string) string {
+	switch n.Case {
+	case CaseUpper:
+		name = strings.ToUpper(name)
+	case CaseLower:
+		name = strings.ToLower(name)
+	case CaseSnake:
+		name = snakeCase(name)
+	case CaseUpperSnake:
+		name = strings.ToUpper(snakeCase(name))
+	}
+	return n.Prefix + name
+}
+
+// snakeCase converts name to snake case, splitting words on case changes and on
+// any character other than letters and digits
+func snakeCase(name string) string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words, word = append(words, string(word)), nil
+			}
+			continue
+		}
+		// a new word starts at an upper case letter following a lower case one or a
+		// digit, or ending an acronym, like the `H` of `dbHost` and the `C` of `HTTPClient`
+		if unicode.IsUpper(r) && i > 0 && len(word) > 0 {
+			previous := runes[i-1]
+			endsAcronym := unicode.IsUpper(previous) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || endsAcronym {
+				words, word = append(words, string(word)), nil
+			}
+		}
+		word = append(word, unicode.ToLower(r))
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return strings.Join(words, "_")
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"path"
+)
+
+// selection holds the patterns given to Select, and the names it keeps because selected
+// derived parameters reference them
+type selection struct {
+	only       []string
+	except     []string
+	referenced map[string]bool
+}
+
+// selects reports whether the option name is matched by any of the only patterns, or
+// there are none, and by none of the except ones, or is referenced
+func (s *selection) selects(name string) bool {
+	matches := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return s.referenced[name] || (len(s.only) == 0 || matches(s.only)) && !matches(s.except)
+}
+
+// Select returns a copy of the parameters whose option_name matches any of the only
+// patterns, or all of them if there are none, and none of the except patterns. Patterns
+// are globs like `DB_*`. The parameters referenced by the derived parameters selected are
+// kept too, since they are needed to compute them. Wildcard parameters are all kept, as
+// the names of their children are only known once expanded, and Selects filters them then.
+func (p Parameters) Select(only []string, except []string) (Parameters, error) {
+	for _, pattern := range append(append([]string{}, only...), except...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return p, fmt.Errorf("invalid pattern `%s`: %v", pattern, err)
+		}
+	}
+	s := &selection{only: only, except: except, referenced: map[string]bool{}}
+
+	selected := map[string]bool{}
+	for _, par := range append(p.All(), p.Derived...) {
+		if s.selects(par.Name) {
+			selected[par.Name] = true
+		}
+	}
+	// keep what the selected derived parameters reference, until nothing is added
+	for added := true; added; {
+		added = false
+		for _, par := range p.Derived {
+			if !selected[par.Name] {
+				continue
+			}
+			for _, name := range References(par.Value) {
+				s.referenced[name] = true
+				if !selected[name] {
+					selected[name] = true
+					added = true
+				}
+			}
+		}
+	}
+
+	filter := func(list []Parameter) []Parameter {
+		var kept []Parameter
+		for _, par := range list {
+			if selected[par.Name] || par.IsWildcard() {
+				kept = append(kept, par)
+			}
+		}
+		return kept
+	}
+	selection := p
+	selection.Component = filter(p.Component)
+	selection.External = filter(p.External)
+	selection.Derived = filter(p.Derived)
+	selection.selection = s
+	return selection, nil
+}
+
+// Selects reports whether the option name of a child of a wildcard parameter, once
+// expanded, is kept by the last Select of the parameters, which keeps every name if
+// there was none
+func (p Parameters) Selects(name string) bool {
+	return p.selection == nil || p.selection.selects(name)
+}
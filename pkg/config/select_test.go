@@ -0,0 +1,52 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+	parameters := Parameters{
+		Component: []Parameter{
+			{Name: "DB_HOST", Path: "/db/host"},
+			{Name: "DB_PORT", Path: "/db/port"},
+			{Name: "FLAG_{leaf}", Path: "/flags/*"},
+			{Path: "/features/*"},
+		},
+		Derived: []Parameter{{Name: "DB_URL", Value: "postgres://{DB_HOST}/app?debug={FLAG_debug}"}},
+	}
+
+	tests := []struct {
+		only, except []string
+		names        []string
+		selects      map[string]bool
+	}{
+		{nil, nil, []string{"DB_HOST", "DB_PORT", "FLAG_{leaf}", "", "DB_URL"}, map[string]bool{"FLAG_alpha": true, "beta": true}},
+		{[]string{"DB_PORT"}, nil, []string{"DB_PORT", "FLAG_{leaf}", ""}, map[string]bool{"FLAG_alpha": false, "beta": false, "DB_PORT": true}},
+		{[]string{"FLAG_a*"}, nil, []string{"FLAG_{leaf}", ""}, map[string]bool{"FLAG_alpha": true, "FLAG_beta": false, "alpha": false}},
+		{nil, []string{"FLAG_b*", "beta"}, []string{"DB_HOST", "DB_PORT", "FLAG_{leaf}", "", "DB_URL"}, map[string]bool{"FLAG_alpha": true, "FLAG_beta": false, "beta": false, "gamma": true}},
+		{[]string{"DB_URL"}, []string{"FLAG_*"}, []string{"DB_HOST", "FLAG_{leaf}", "", "DB_URL"}, map[string]bool{"FLAG_debug": true, "FLAG_alpha": false}},
+	}
+	for _, test := range tests {
+		selection, err := parameters.Select(test.only, test.except)
+		if err != nil {
+			t.Fatalf("Select(%v, %v) error = %v", test.only, test.except, err)
+		}
+		var names []string
+		for _, par := range append(selection.All(), selection.Derived...) {
+			names = append(names, par.Name)
+		}
+		if !reflect.DeepEqual(names, test.names) {
+			t.Errorf("Select(%v, %v) = %q, want %q", test.only, test.except, names, test.names)
+		}
+		for name, want := range test.selects {
+			if got := selection.Selects(name); got != want {
+				t.Errorf("Select(%v, %v).Selects(%s) = %v, want %v", test.only, test.except, name, got, want)
+			}
+		}
+	}
+
+	if _, err := parameters.Select([]string{"["}, nil); err == nil {
+		t.Errorf("Select() with an invalid pattern error = nil")
+	}
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Transforms of the values, applied after getting them from the store
+const (
+	// TransformTrim removes the leading and trailing whitespace, e.g. the newline of a value
+	// stored from a file
+	TransformTrim = "trim"
+	// TransformBase64 encodes the value in standard base64
+	TransformBase64 = "base64"
+	// TransformBase64Decode decodes a value stored in standard base64
+	TransformBase64Decode = "base64_decode"
+	// TransformJSONEscape escapes the value to be embedded in a JSON string, without quotes
+	TransformJSONEscape = "json_escape"
+	// TransformURLEncode escapes the value to be embedded in a URL query, e.g. a password in
+	// a connection string
+	TransformURLEncode = "url_encode"
+)
+
+// transforms holds every valid transform
+var transforms = map[string]bool{TransformTrim: true, TransformBase64: true, TransformBase64Decode: true, TransformJSONEscape: true, TransformURLEncode: true}
+
+// Transform returns the value with the transforms of the parameter applied in order. It
+// fails if a value can't be decoded.
+func (par Parameter) Transform(value string) (string, error) {
+	for _, transform := range par.Transforms {
+		switch transform {
+		case TransformTrim:
+			value = strings.TrimSpace(value)
+		case TransformBase64:
+			value = base64.StdEncoding.EncodeToString([]byte(value))
+		case TransformBase64Decode:
+			decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+			if err != nil {
+				return "", fmt.Errorf("the value isn't valid base64: %v", err)
+			}
+			value = string(decoded)
+		case TransformJSONEscape:
+			var buffer bytes.Buffer
+			encoder := json.NewEncoder(&buffer)
+			encoder.SetEscapeHTML(false)
+			if err := encoder.Encode(value); err != nil {
+				return "", err
+			}
+			// strip the quotes and the newline written by the encoder
+			escaped := strings.TrimSuffix(buffer.String(), "\n")
+			value = escaped[1 : len(escaped)-1]
+		case TransformURLEncode:
+			value = url.QueryEscape(value)
+		default:
+			return "", fmt.Errorf("unknown transform: %s", transform)
+		}
+	}
+	return value, nil
+}
+
+// transformProblems returns the problems of the transforms of the parameter, which must
+// be known
+func transformProblems(section string, par Parameter) []string {
+	var problems []string
+	for _, transform := range par.Transforms {
+		if !transforms[transform] {
+			problems = append(problems, fmt.Sprintf("%s parameter `%s` has an unknown transform: %s", section, par.Name, transform))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"strings"
+)
+
+// Wildcard ends the paths of parameters expanding into one option per child
+// parameter, e.g. `/myservice/features/*`
+const Wildcard = "/*"
+
+// leafPlaceholder is replaced in the option_name of wildcard parameters by the last
+// element of the path of each child, e.g. `FEATURE_{leaf}`
+const leafPlaceholder = "{leaf}"
+
+// IsWildcard reports whether the path of the parameter ends in Wildcard
+func (par Parameter) IsWildcard() bool {
+	return strings.HasSuffix(par.Path, Wildcard)
+}
+
+// WildcardPrefix returns the path under which the children of a wildcard parameter are stored
+func (par Parameter) WildcardPrefix() string {
+	return strings.TrimSuffix(par.Path, Wildcard)
+}
+
+// ExpandName returns the option name of the child named leaf of a wildcard parameter,
+// which is leaf itself unless option_name holds a template like `FEATURE_{leaf}`
+func (par Parameter) ExpandName(leaf string) string {
+	if par.Name == "" {
+		return leaf
+	}
+	return strings.Replace(par.Name, leafPlaceholder, leaf, -1)
+}
+
+// MayExpandTo reports whether name may be the option name of a child of a wildcard parameter,
+// which is always true if it has no option_name
+func (par Parameter) MayExpandTo(name string) bool {
+	if par.Name == "" {
+		return true
+	}
+	parts := strings.SplitN(par.Name, leafPlaceholder, 2)
+	if len(parts) == 1 {
+		return par.Name == name
+	}
+	return len(name) > len(parts[0])+len(parts[1]) && strings.HasPrefix(name, parts[0]) && strings.HasSuffix(name, parts[1])
+}
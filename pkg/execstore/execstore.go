@@ -0,0 +1,98 @@
+// Package execstore implements a read-only store.Store getting the values from
+// plugins, which are executables run once per value.
+//
+// Paths are like `plugin/reference`. The executable `ssmeb-plugin-<plugin>` is
+// looked up in the PATH and run with a JSON request on stdin:
+//
+//	{"plugin": "onepassword", "reference": "Production/db/password", "environment": "production"}
+//
+// It writes the value to stdout, where a single trailing newline is removed, and
+// exits with status 0. Any other status fails, with the stderr of the plugin shown
+// to the user. The values are treated as secrets.
+package execstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/codacy/ssmeb/pkg/store"
+)
+
+// commandPrefix is the prefix of the names of the plugin executables
+const commandPrefix = "ssmeb-plugin-"
+
+// Store gets the values from plugins
+type Store struct {
+	environment string
+}
+
+// New creates a Store telling the plugins the values are for the given environment
+func New(environment string) *Store {
+	return &Store{environment: environment}
+}
+
+// request is written to the stdin of the plugins
+type request struct {
+	// Plugin is the name of the plugin
+	Plugin string `json:"plugin"`
+	// Reference identifies the value in the plugin, e.g. an item in a password manager
+	Reference string `json:"reference"`
+	// Environment is the environment given to ssmeb, if any
+	Environment string `json:"environment,omitempty"`
+}
+
+// SplitPath splits a path into the name of the plugin and the reference given to it,
+// failing if any of them is empty
+func SplitPath(path string) (string, string, error) {
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("`%s` is not like `plugin/reference`", path)
+	}
+	return parts[0], parts[1], nil
+}
+
+// Get runs the plugin in path, returning the value it writes
+func (s *Store) Get(path string) (store.Parameter, error) {
+	plugin, reference, err := SplitPath(path)
+	if err != nil {
+		return store.Parameter{}, err
+	}
+	input, err := json.Marshal(request{Plugin: plugin, Reference: reference, Environment: s.environment})
+	if err != nil {
+		return store.Parameter{}, err
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command(commandPrefix + plugin)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return store.Parameter{}, fmt.Errorf("plugin `%s`: %v", plugin, err)
+	}
+	value := strings.TrimSuffix(stdout.String(), "\n")
+	return store.Parameter{Path: path, Value: value, Secret: true}, nil
+}
+
+// Put fails, since plugins are read-only
+func (s *Store) Put(par store.Parameter) (int64, error) {
+	return 0, errReadOnly
+}
+
+// Delete fails, since plugins are read-only
+func (s *Store) Delete(path string) error {
+	return errReadOnly
+}
+
+// List fails, since plugins only get single values
+func (s *Store) List(prefix string) ([]store.Parameter, error) {
+	return nil, errors.New("plugins can't list values")
+}
+
+// errReadOnly is returned when writing to a plugin
+var errReadOnly = errors.New("plugins are read-only")
@@ -0,0 +1,154 @@
+// Package filestore implements a store.Store backed by a local JSON file, so the
+// whole flow can be tried without any cloud account, e.g. in demos and tests.
+//
+// The file maps each path to its parameter, and is created by the first Put:
+//
+//	{
+//	  "/codacy/db/host": {"value": "localhost", "description": "Hostname of the database", "version": 1},
+//	  "/codacy/db/password": {"value": "secret", "secret": true, "version": 3}
+//	}
+//
+// Values are kept in plain text, even the secret ones. The file is read on every
+// call and rewritten atomically on every change, so it can be edited by hand
+// between runs. Tags and labels are ignored.
+package filestore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/codacy/ssmeb/pkg/store"
+)
+
+// Store reads and writes parameters in a JSON file
+type Store struct {
+	filename string
+	mutex    sync.Mutex
+}
+
+// entry is a parameter in the file
+type entry struct {
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+	Secret      bool   `json:"secret,omitempty"`
+	Version     int64  `json:"version"`
+}
+
+// New creates a Store keeping the parameters in the file with name filename
+func New(filename string) *Store {
+	return &Store{filename: filename}
+}
+
+// Get returns the parameter in path
+func (s *Store) Get(path string) (store.Parameter, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entries, err := s.read()
+	if err != nil {
+		return store.Parameter{}, err
+	}
+	e, ok := entries[path]
+	if !ok {
+		return store.Parameter{}, store.ErrNotFound
+	}
+	return e.parameter(path), nil
+}
+
+// Put stores the parameter, overwriting any existing value, and returns its new version
+func (s *Store) Put(par store.Parameter) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entries, err := s.read()
+	if err != nil {
+		return 0, err
+	}
+	version := entries[par.Path].Version + 1
+	entries[par.Path] = entry{Value: par.Value, Description: par.Description, Secret: par.Secret, Version: version}
+	return version, s.write(entries)
+}
+
+// Delete removes the parameter in path
+func (s *Store) Delete(path string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entries, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := entries[path]; !ok {
+		return store.ErrNotFound
+	}
+	delete(entries, path)
+	return s.write(entries)
+}
+
+// List returns every parameter stored under the prefix path, recursively, sorted by path
+func (s *Store) List(prefix string) ([]store.Parameter, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entries, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+	var parameters []store.Parameter
+	for path, e := range entries {
+		if strings.HasPrefix(path, prefix) {
+			parameters = append(parameters, e.parameter(path))
+		}
+	}
+	sort.Slice(parameters, func(i, j int) bool { return parameters[i].Path < parameters[j].Path })
+	return parameters, nil
+}
+
+// read returns the entries in the file by path, which are none if it doesn't exist yet
+func (s *Store) read() (map[string]entry, error) {
+	entries := map[string]entry{}
+	data, err := ioutil.ReadFile(s.filename)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// write replaces the file with the entries, through a temporary file so it's never
+// left half written
+func (s *Store) write(entries map[string]entry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.filename), "."+filepath.Base(s.filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(append(data, '\n'))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		// readable by the owner only, as it holds the secrets in plain text
+		err = os.Chmod(tmp.Name(), 0600)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.filename)
+}
+
+// parameter converts the entry in path into a store parameter
+func (e entry) parameter(path string) store.Parameter {
+	return store.Parameter{Path: path, Value: e.Value, Description: e.Description, Secret: e.Secret, Version: e.Version}
+}
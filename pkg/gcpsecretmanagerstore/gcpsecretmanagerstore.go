@@ -0,0 +1,177 @@
+// Package gcpsecretmanagerstore implements a store.Store backed by Google Cloud Secret Manager.
+//
+// Secret ids can only hold alphanumeric characters, dashes and underscores, so paths
+// are converted into ids by dropping the leading slash and replacing any other
+// character with a dash, e.g. `/codacy/db.host` is stored in the `codacy-db-host` secret.
+// Paths get the latest version of the secret, unless they end in `@version`, e.g. `/codacy/db_host@3`.
+package gcpsecretmanagerstore
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/codacy/ssmeb/pkg/store"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// descriptionAnnotation is the secret annotation holding the description of the parameter
+const descriptionAnnotation = "description"
+
+// Store reads and writes parameters in the Secret Manager of a Google Cloud project
+type Store struct {
+	client  *secretmanager.Client
+	project string
+}
+
+// New creates a Store for the given project, using the application default credentials
+func New(project string) (*Store, error) {
+	client, err := secretmanager.NewClient(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &Store{client: client, project: project}, nil
+}
+
+// Get returns the version of the secret for path, which is the latest one unless path ends in `@version`
+func (s *Store) Get(path string) (store.Parameter, error) {
+	ctx := context.Background()
+	name, version := splitVersion(path)
+
+	response, err := s.client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{
+		Name: s.secretName(name) + "/versions/" + version,
+	})
+	if err != nil {
+		return store.Parameter{}, convertError(err)
+	}
+	secret, err := s.client.GetSecret(ctx, &secretmanagerpb.GetSecretRequest{Name: s.secretName(name)})
+	if err != nil {
+		return store.Parameter{}, convertError(err)
+	}
+
+	return store.Parameter{
+		Path:        path,
+		Value:       string(response.Payload.Data),
+		Secret:      true,
+		Description: secret.Annotations[descriptionAnnotation],
+		Version:     versionNumber(response.Name),
+	}, nil
+}
+
+// Put adds the value as a new version of the secret for its path, creating the secret
+// with automatic replication if needed, and returns the number of the new version
+func (s *Store) Put(par store.Parameter) (int64, error) {
+	ctx := context.Background()
+	name, _ := splitVersion(par.Path)
+
+	_, err := s.client.GetSecret(ctx, &secretmanagerpb.GetSecretRequest{Name: s.secretName(name)})
+	if convertError(err) == store.ErrNotFound {
+		secret := &secretmanagerpb.Secret{
+			Replication: &secretmanagerpb.Replication{
+				Replication: &secretmanagerpb.Replication_Automatic_{Automatic: &secretmanagerpb.Replication_Automatic{}},
+			},
+		}
+		if par.Description != "" {
+			secret.Annotations = map[string]string{descriptionAnnotation: par.Description}
+		}
+		_, err = s.client.CreateSecret(ctx, &secretmanagerpb.CreateSecretRequest{
+			Parent:   "projects/" + s.project,
+			SecretId: SecretID(name),
+			Secret:   secret,
+		})
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	version, err := s.client.AddSecretVersion(ctx, &secretmanagerpb.AddSecretVersionRequest{
+		Parent:  s.secretName(name),
+		Payload: &secretmanagerpb.SecretPayload{Data: []byte(par.Value)},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return versionNumber(version.Name), nil
+}
+
+// Delete deletes the secret for path, along with all its versions
+func (s *Store) Delete(path string) error {
+	name, _ := splitVersion(path)
+	err := s.client.DeleteSecret(context.Background(), &secretmanagerpb.DeleteSecretRequest{Name: s.secretName(name)})
+	return convertError(err)
+}
+
+// List returns the latest version of every secret whose id starts with the one for prefix.
+// The paths of the returned parameters are the secret ids, since the original paths can't be recovered.
+func (s *Store) List(prefix string) ([]store.Parameter, error) {
+	idPrefix := SecretID(prefix)
+
+	var ids []string
+	secrets := s.client.ListSecrets(context.Background(), &secretmanagerpb.ListSecretsRequest{Parent: "projects/" + s.project})
+	for {
+		secret, err := secrets.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if id := path.Base(secret.Name); strings.HasPrefix(id, idPrefix) {
+			ids = append(ids, id)
+		}
+	}
+
+	var parameters []store.Parameter
+	for _, id := range ids {
+		par, err := s.Get(id)
+		if err != nil {
+			return parameters, err
+		}
+		parameters = append(parameters, par)
+	}
+	return parameters, nil
+}
+
+// secretName returns the resource name of the secret for path
+func (s *Store) secretName(path string) string {
+	return fmt.Sprintf("projects/%s/secrets/%s", s.project, SecretID(path))
+}
+
+// SecretID converts a parameter path into a valid Secret Manager secret id
+func SecretID(path string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, strings.TrimPrefix(path, "/"))
+}
+
+// splitVersion splits a path like `name@version` into its parts, using the latest version by default
+func splitVersion(path string) (string, string) {
+	i := strings.LastIndex(path, "@")
+	if i < 0 {
+		return path, "latest"
+	}
+	return path[:i], path[i+1:]
+}
+
+// versionNumber returns the number at the end of a secret version resource name
+func versionNumber(name string) int64 {
+	number, _ := strconv.ParseInt(path.Base(name), 10, 64)
+	return number
+}
+
+// convertError replaces the errors for missing secrets with store.ErrNotFound
+func convertError(err error) error {
+	if status.Code(err) == codes.NotFound {
+		return store.ErrNotFound
+	}
+	return err
+}
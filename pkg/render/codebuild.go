@@ -0,0 +1,30 @@
+package render
+
+import (
+	"fmt"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// codeBuildEnv is the env section of a CodeBuild buildspec
+type codeBuildEnv struct {
+	Env struct {
+		Variables         yaml.MapSlice `yaml:"variables,omitempty"`
+		ExportedVariables []string      `yaml:"exported-variables,omitempty"`
+	} `yaml:"env"`
+}
+
+// CodeBuild renders the options as the env section of a CodeBuild buildspec, setting
+// them as variables and exporting all of them, so the later actions of a CodePipeline
+// can reference them as `#{namespace.NAME}`
+func CodeBuild(options []Option) ([]byte, error) {
+	var buildspec codeBuildEnv
+	for _, option := range options {
+		if !shellName.MatchString(option.Name) {
+			return nil, fmt.Errorf("`%s` is not a valid variable name", option.Name)
+		}
+		buildspec.Env.Variables = append(buildspec.Env.Variables, yaml.MapItem{Key: option.Name, Value: option.Value})
+		buildspec.Env.ExportedVariables = append(buildspec.Env.ExportedVariables, option.Name)
+	}
+	return yaml.Marshal(buildspec)
+}
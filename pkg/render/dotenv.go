@@ -0,0 +1,40 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// dotenvEscaper escapes the characters with a special meaning inside double quotes in .env files
+var dotenvEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", `\$`)
+
+// Dotenv renders the options as a .env file, as read by docker compose and the
+// dotenv libraries, with every value double quoted
+func Dotenv(options []Option) ([]byte, error) {
+	var buffer bytes.Buffer
+	for _, option := range options {
+		if !shellName.MatchString(option.Name) {
+			return nil, fmt.Errorf("`%s` is not a valid variable name", option.Name)
+		}
+		fmt.Fprintf(&buffer, "%s=\"%s\"\n", option.Name, dotenvEscaper.Replace(option.Value))
+	}
+	return buffer.Bytes(), nil
+}
+
+// GitLabDotenv renders the options as a dotenv report of GitLab CI, passing them to the
+// later jobs. Its values are read verbatim up to the end of the line, so they can't
+// have line breaks.
+func GitLabDotenv(options []Option) ([]byte, error) {
+	var buffer bytes.Buffer
+	for _, option := range options {
+		if !shellName.MatchString(option.Name) {
+			return nil, fmt.Errorf("`%s` is not a valid variable name", option.Name)
+		}
+		if strings.ContainsAny(option.Value, "\r\n") {
+			return nil, fmt.Errorf("the value of `%s` has line breaks, which GitLab dotenv reports can't hold", option.Name)
+		}
+		fmt.Fprintf(&buffer, "%s=%s\n", option.Name, option.Value)
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,44 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// workflowCommandEscaper escapes the data of GitHub Actions workflow commands
+var workflowCommandEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+// GitHubEnv renders the options as the lines of the file in $GITHUB_ENV of GitHub Actions,
+// each value between lines with the delimiter so it may span several lines. The
+// delimiter must not be in any of the values.
+func GitHubEnv(options []Option, delimiter string) ([]byte, error) {
+	var buffer bytes.Buffer
+	for _, option := range options {
+		if !shellName.MatchString(option.Name) {
+			return nil, fmt.Errorf("`%s` is not a valid variable name", option.Name)
+		}
+		if strings.Contains(option.Value, delimiter) {
+			return nil, fmt.Errorf("the value of `%s` contains the delimiter", option.Name)
+		}
+		fmt.Fprintf(&buffer, "%s<<%s\n%s\n%s\n", option.Name, delimiter, option.Value, delimiter)
+	}
+	return buffer.Bytes(), nil
+}
+
+// GitHubMasks renders the workflow commands of GitHub Actions masking the secret values
+// in the logs, one for each of their lines since values are masked line by line
+func GitHubMasks(options []Option) []byte {
+	var buffer bytes.Buffer
+	for _, option := range options {
+		if !option.Secret {
+			continue
+		}
+		for _, line := range strings.Split(option.Value, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				fmt.Fprintf(&buffer, "::add-mask::%s\n", workflowCommandEscaper.Replace(line))
+			}
+		}
+	}
+	return buffer.Bytes()
+}
@@ -0,0 +1,19 @@
+package render
+
+import (
+	"encoding/json"
+)
+
+// Map returns the options as a map from name to value
+func Map(options []Option) map[string]string {
+	values := make(map[string]string, len(options))
+	for _, option := range options {
+		values[option.Name] = option.Value
+	}
+	return values
+}
+
+// JSON renders the options as a JSON object from name to value
+func JSON(options []Option) ([]byte, error) {
+	return json.MarshalIndent(Map(options), "", "  ")
+}
@@ -0,0 +1,160 @@
+// Package render converts resolved parameters into the formats consumed by
+// elastic beanstalk.
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Option holds info about a beanstalk option
+type Option struct {
+	// Name is the option name
+	Name string `yaml:"option_name"`
+	// Value is the option value
+	Value string `yaml:"value"`
+	// Secret reports whether the value came from an encrypted parameter. It's never rendered.
+	Secret bool `yaml:"-"`
+	// Description is the description of the parameter, only rendered by DocumentedEBYAML
+	Description string `yaml:"-"`
+	// Targets lists the outputs the option is written to, the ones without a target if empty.
+	// It's never rendered.
+	Targets []string `yaml:"-"`
+	// Group is the section the option is written in by EBYAML, under a comment with its name
+	Group string `yaml:"-"`
+}
+
+// EBOptionSettings conforms with the format used for elastic beanstalk extensions
+type EBOptionSettings struct {
+	Options []Option `yaml:"option_settings"`
+}
+
+// EBYAML renders the options as an elastic beanstalk extensions config file. Options
+// with a group are written together, after the ones without, under a comment with its name.
+func EBYAML(options []Option) ([]byte, error) {
+	for _, option := range options {
+		if option.Group != "" {
+			return commentedEBYAML(options, false)
+		}
+	}
+	return yaml.Marshal(EBOptionSettings{Options: options})
+}
+
+// YAML renders the options as a yaml mapping from name to value
+func YAML(options []Option) ([]byte, error) {
+	return yaml.Marshal(Map(options))
+}
+
+// DocumentedEBYAML renders the options like EBYAML, with the description of each
+// option as a comment above it
+func DocumentedEBYAML(options []Option) ([]byte, error) {
+	return commentedEBYAML(options, true)
+}
+
+// commentedEBYAML renders the options like EBYAML with comments naming their groups,
+// and describing each option if descriptions is true
+func commentedEBYAML(options []Option, descriptions bool) ([]byte, error) {
+	if len(options) == 0 {
+		return EBYAML(options)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("option_settings:\n")
+	group := ""
+	for _, option := range Grouped(options) {
+		if option.Group != group {
+			group = option.Group
+			if out.Len() > len("option_settings:\n") {
+				out.WriteString("\n")
+			}
+			out.WriteString("# " + group + "\n")
+		}
+		if descriptions && option.Description != "" {
+			for _, line := range strings.Split(strings.TrimRight(option.Description, "\n"), "\n") {
+				out.WriteString(strings.TrimRight("# "+line, " ") + "\n")
+			}
+		}
+		// rendered one at a time to interleave the comments, as yaml.v2 can't write them
+		data, err := yaml.Marshal([]Option{option})
+		if err != nil {
+			return nil, err
+		}
+		out.Write(data)
+	}
+	return out.Bytes(), nil
+}
+
+// Grouped returns the options with the ones of each group together, in the order of the
+// first option of each group, after the options without a group
+func Grouped(options []Option) []Option {
+	var groups []string
+	byGroup := map[string][]Option{}
+	for _, option := range options {
+		if _, ok := byGroup[option.Group]; !ok && option.Group != "" {
+			groups = append(groups, option.Group)
+		}
+		byGroup[option.Group] = append(byGroup[option.Group], option)
+	}
+	grouped := append(make([]Option, 0, len(options)), byGroup[""]...)
+	for _, group := range groups {
+		grouped = append(grouped, byGroup[group]...)
+	}
+	return grouped
+}
+
+// formats maps the name of each output format to the function rendering it
+var formats = map[string]func([]Option) ([]byte, error){
+	"ebyaml":    EBYAML,
+	"codebuild": CodeBuild,
+	"dotenv":    Dotenv,
+	"gitlab":    GitLabDotenv,
+	"json":      JSON,
+	"shell":     Exports,
+	"yaml":      YAML,
+}
+
+// Render renders the options in the format with the given name, e.g. `ebyaml`
+func Render(format string, options []Option) ([]byte, error) {
+	renderer, ok := formats[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown format `%s`, expected one of %v", format, Formats())
+	}
+	return renderer(options)
+}
+
+// HasFormat reports whether Render supports the format with the given name
+func HasFormat(format string) bool {
+	_, ok := formats[format]
+	return ok
+}
+
+// Formats returns the names of the formats supported by Render, sorted
+func Formats() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasSecrets reports whether any of the options holds a secret value
+func HasSecrets(options []Option) bool {
+	for _, option := range options {
+		if option.Secret {
+			return true
+		}
+	}
+	return false
+}
+
+// Sort sorts the options by name, keeping the input order of options with the same name
+func Sort(options []Option) {
+	sort.SliceStable(options, func(i, j int) bool {
+		return options[i].Name < options[j].Name
+	})
+}
@@ -0,0 +1,42 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// shellName matches the names that can be used as shell variables
+var shellName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Exports renders the options as POSIX shell `export` statements, meant to be
+// evaluated with `eval "$(ssmeb env)"`
+func Exports(options []Option) ([]byte, error) {
+	var buffer bytes.Buffer
+	for _, option := range options {
+		if !shellName.MatchString(option.Name) {
+			return nil, fmt.Errorf("`%s` is not a valid shell variable name", option.Name)
+		}
+		fmt.Fprintf(&buffer, "export %s=%s\n", option.Name, ShellQuote(option.Value))
+	}
+	return buffer.Bytes(), nil
+}
+
+// Unsets renders `unset` statements undoing the ones rendered by Exports
+func Unsets(options []Option) ([]byte, error) {
+	var buffer bytes.Buffer
+	for _, option := range options {
+		if !shellName.MatchString(option.Name) {
+			return nil, fmt.Errorf("`%s` is not a valid shell variable name", option.Name)
+		}
+		fmt.Fprintf(&buffer, "unset %s\n", option.Name)
+	}
+	return buffer.Bytes(), nil
+}
+
+// ShellQuote quotes value so a POSIX shell reads it literally, by wrapping it in
+// single quotes and escaping the single quotes it contains
+func ShellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
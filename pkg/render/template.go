@@ -0,0 +1,31 @@
+package render
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/Masterminds/sprig"
+)
+
+// TemplateData is the data available to templates rendered by Template
+type TemplateData struct {
+	// Options holds the options in order, e.g. `{{range .Options}}{{.Name}}={{.Value}}{{end}}`
+	Options []Option
+	// Values maps each option name to its value, e.g. `{{.Values.DB_HOST}}`
+	Values map[string]string
+}
+
+// Template renders the options through the Go template in text, which can use the
+// sprig functions (e.g. `{{.Values.DB_HOST | quote}}`), to support any other format
+func Template(name string, text string, options []Option) ([]byte, error) {
+	tmpl, err := template.New(name).Funcs(sprig.TxtFuncMap()).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	err = tmpl.Execute(&buffer, TemplateData{Options: options, Values: Map(options)})
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
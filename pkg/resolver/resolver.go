@@ -0,0 +1,297 @@
+// Package resolver resolves the parameters of a parameters file into beanstalk
+// options, getting their values from a store.
+package resolver
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/render"
+	"github.com/codacy/ssmeb/pkg/store"
+)
+
+// Resolver gets the values of parameters from a store
+type Resolver struct {
+	// Store holds the parameter values
+	Store store.Store
+	// StoreFor returns the store of the parameters got with their own credentials, i.e.
+	// with a role_arn or profile. They are got from Store too when it's nil.
+	StoreFor func(par config.Parameter) (store.Store, error)
+	// Progress receives a line for each parameter fetched. It's discarded by default.
+	Progress io.Writer
+	// KeepGoing makes Resolve try every parameter after one fails, returning all the
+	// failures at the end as Errors
+	KeepGoing bool
+	// SkipMissingExternal makes Resolve leave out the external parameters missing from
+	// the store with a warning, as if they were optional, instead of failing
+	SkipMissingExternal bool
+	// Summary counts the outcome of the parameters in the last call to Resolve
+	Summary Summary
+}
+
+// Summary counts the parameters by outcome
+type Summary struct {
+	// OK counts the parameters resolved, including each child of a wildcard
+	OK int
+	// Skipped counts the optional parameters missing from the store
+	Skipped int
+	// Defaulted counts the parameters missing from the store that got their default
+	Defaulted int
+	// Failed counts the parameters that couldn't be resolved
+	Failed int
+}
+
+// Errors holds the failures of the parameters tried by a Resolver in KeepGoing mode
+type Errors []error
+
+// Error lists every failure, one per line
+func (e Errors) Error() string {
+	lines := make([]string, 0, len(e)+1)
+	lines = append(lines, fmt.Sprintf("%d parameter(s) failed:", len(e)))
+	for _, err := range e {
+		lines = append(lines, "  "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// New creates a Resolver getting the values from s
+func New(s store.Store) *Resolver {
+	return &Resolver{Store: s, Progress: ioutil.Discard}
+}
+
+// Value is a parameter along with the value it resolved to
+type Value struct {
+	// Parameter is the entry of the parameters file
+	Parameter config.Parameter
+	// Stored is the parameter as found in the store
+	Stored store.Parameter
+}
+
+// Resolve gets the value of each of the parameters from the store, followed by the
+// derived ones, applies their transforms, and names them as configured in the naming of the parameters. Paths
+// referenced by several parameters are only fetched once. Parameters missing from the
+// store get their default if they have one, or are skipped if they're optional, or
+// external and SkipMissingExternal is set.
+func (r *Resolver) Resolve(parameters config.Parameters) ([]Value, error) {
+	var values []Value
+	// outcomes holds the count of the Summary each value adds to once transformed
+	var outcomes []*int
+	var failures Errors
+	r.Summary = Summary{}
+
+	fetched := map[string]store.Parameter{}
+	for i, par := range parameters.All() {
+		if par.IsWildcard() {
+			children, err := r.expand(par, parameters)
+			if err != nil {
+				r.Summary.Failed++
+			}
+			if err != nil && r.KeepGoing {
+				failures = append(failures, err)
+				continue
+			}
+			if err != nil {
+				return values, err
+			}
+			values = append(values, children...)
+			for range children {
+				outcomes = append(outcomes, &r.Summary.OK)
+			}
+			continue
+		}
+
+		fmt.Fprintf(r.Progress, "* Getting `%s` from path `%s`... ", par.Name, par.Path)
+		// the same path may hold different values in other accounts
+		key := par.Credentials() + "\x00" + par.Path
+		if stored, ok := fetched[key]; ok {
+			values = append(values, Value{Parameter: par, Stored: stored})
+			outcomes = append(outcomes, &r.Summary.OK)
+			fmt.Fprintln(r.Progress, "OK (already fetched)")
+			continue
+		}
+
+		s, err := r.storeOf(par)
+		var stored store.Parameter
+		if err == nil {
+			stored, err = s.Get(par.Path)
+		}
+		if err == store.ErrNotFound && par.Default != "" {
+			fmt.Fprintln(r.Progress, "WARNING: not found, using the default")
+			stored = store.Parameter{Path: par.Path, Value: par.Default, Secret: par.Type == config.TypeSecureString}
+			values = append(values, Value{Parameter: par, Stored: stored})
+			outcomes = append(outcomes, &r.Summary.Defaulted)
+			continue
+		}
+		if err == store.ErrNotFound && r.SkipMissingExternal && i >= len(parameters.Component) {
+			fmt.Fprintln(r.Progress, "WARNING: not found, left out (external)")
+			r.Summary.Skipped++
+			continue
+		}
+		if err == store.ErrNotFound && par.Optional {
+			fmt.Fprintln(r.Progress, "SKIPPED (optional, not found)")
+			r.Summary.Skipped++
+			continue
+		}
+		if err != nil {
+			fmt.Fprintln(r.Progress, "FAILED")
+			r.Summary.Failed++
+			err = fmt.Errorf("%s: %v", par.Path, err)
+			if r.KeepGoing {
+				failures = append(failures, err)
+				continue
+			}
+			return values, err
+		}
+		fetched[key] = stored
+		values = append(values, Value{Parameter: par, Stored: stored})
+		outcomes = append(outcomes, &r.Summary.OK)
+		fmt.Fprintln(r.Progress, "OK")
+	}
+
+	// values are counted once transformed, as failing to transform them fails them
+	for i, value := range values {
+		transformed, err := transform(value.Parameter, value.Stored)
+		if err != nil {
+			r.Summary.Failed++
+		}
+		if err != nil && r.KeepGoing {
+			failures = append(failures, err)
+			continue
+		}
+		if err != nil {
+			return values, err
+		}
+		values[i].Stored = transformed
+		*outcomes[i]++
+	}
+	if len(failures) > 0 {
+		return values, failures
+	}
+
+	values, err := derive(parameters, values)
+	if err != nil {
+		return values, err
+	}
+	r.Summary.OK += len(parameters.Derived)
+	// derived values reference the names in the parameters file, so rename afterwards
+	for i := range values {
+		values[i].Parameter.Name = parameters.Naming.Apply(values[i].Parameter.Name)
+	}
+	return values, nil
+}
+
+// expand returns one value for each parameter stored directly under the path of a
+// wildcard parameter, sorted by path, named after the last element of their paths. The
+// ones whose names aren't selected in the parameters are left out.
+func (r *Resolver) expand(par config.Parameter, parameters config.Parameters) ([]Value, error) {
+	fmt.Fprintf(r.Progress, "* Listing the parameters in path `%s`... ", par.Path)
+	prefix := par.WildcardPrefix()
+	s, err := r.storeOf(par)
+	var stored []store.Parameter
+	if err == nil {
+		stored, err = s.List(prefix)
+	}
+	if err != nil {
+		fmt.Fprintln(r.Progress, "FAILED")
+		return nil, fmt.Errorf("%s: %v", par.Path, err)
+	}
+	sort.Slice(stored, func(i, j int) bool { return stored[i].Path < stored[j].Path })
+
+	var children []Value
+	for _, child := range stored {
+		leaf := strings.TrimPrefix(child.Path, prefix+"/")
+		if leaf == child.Path || strings.Contains(leaf, "/") {
+			// not a direct child
+			continue
+		}
+		expanded := par
+		expanded.Name = par.ExpandName(leaf)
+		expanded.Path = child.Path
+		if !parameters.Selects(expanded.Name) {
+			continue
+		}
+		children = append(children, Value{Parameter: expanded, Stored: child})
+	}
+	fmt.Fprintf(r.Progress, "OK (%d parameters)\n", len(children))
+	return children, nil
+}
+
+// storeOf returns the store holding the value of the parameter
+func (r *Resolver) storeOf(par config.Parameter) (store.Store, error) {
+	if r.StoreFor == nil || par.Credentials() == "" {
+		return r.Store, nil
+	}
+	return r.StoreFor(par)
+}
+
+// derive appends the derived parameters to the resolved values, composing them from
+// the values they reference. Derived values are secret if any of the referenced ones is.
+func derive(parameters config.Parameters, values []Value) ([]Value, error) {
+	ordered, err := parameters.DerivedOrder()
+	if err != nil {
+		return values, err
+	}
+
+	byName := map[string]store.Parameter{}
+	for _, value := range values {
+		byName[value.Parameter.Name] = value.Stored
+	}
+	for _, par := range ordered {
+		derived := store.Parameter{}
+		derived.Value = config.Expand(par.Value, func(name string) string {
+			derived.Secret = derived.Secret || byName[name].Secret
+			return byName[name].Value
+		})
+		derived, err := transform(par, derived)
+		if err != nil {
+			return values, err
+		}
+		byName[par.Name] = derived
+	}
+
+	for _, par := range parameters.Derived {
+		values = append(values, Value{Parameter: par, Stored: byName[par.Name]})
+	}
+	return values, nil
+}
+
+// transform returns the stored parameter with the transforms of the parameter applied
+// to its value
+func transform(par config.Parameter, stored store.Parameter) (store.Parameter, error) {
+	value, err := par.Transform(stored.Value)
+	if err != nil {
+		return stored, fmt.Errorf("%s: %v", par.Name, err)
+	}
+	stored.Value = value
+	return stored, nil
+}
+
+// Options converts the parameters into beanstalk options, by getting the value
+// of each one from the store
+func (r *Resolver) Options(parameters config.Parameters) ([]render.Option, error) {
+	values, err := r.Resolve(parameters)
+	if err != nil {
+		return nil, err
+	}
+	return Options(values), nil
+}
+
+// Options converts resolved values into beanstalk options
+func Options(values []Value) []render.Option {
+	options := make([]render.Option, 0, len(values))
+	for _, value := range values {
+		options = append(options, render.Option{
+			Name:        value.Parameter.Name,
+			Value:       value.Stored.Value,
+			Secret:      value.Stored.Secret,
+			Description: value.Parameter.Description,
+			Targets:     value.Parameter.Targets,
+			Group:       value.Parameter.Group,
+		})
+	}
+	return options
+}
@@ -0,0 +1,74 @@
+// Package s3io reads and writes whole objects addressed by s3://bucket/key urls.
+package s3io
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// scheme is the prefix of the urls handled by this package
+const scheme = "s3://"
+
+// IsURL reports whether name is an s3://bucket/key url
+func IsURL(name string) bool {
+	return strings.HasPrefix(name, scheme)
+}
+
+// ParseURL splits an s3://bucket/key url into its bucket and key
+func ParseURL(url string) (string, string, error) {
+	if !IsURL(url) {
+		return "", "", fmt.Errorf("`%s` is not an s3 url", url)
+	}
+	parts := strings.SplitN(strings.TrimPrefix(url, scheme), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("`%s` is not an s3://bucket/key url", url)
+	}
+	return parts[0], parts[1], nil
+}
+
+// Read returns the contents of the object in url
+func Read(client s3iface.S3API, url string) ([]byte, error) {
+	bucket, key, err := ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	output, err := client.GetObject(&s3.GetObjectInput{Bucket: &bucket, Key: &key})
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+	return ioutil.ReadAll(output.Body)
+}
+
+// Write replaces the contents of the object in url with data, encrypted with SSE-S3
+func Write(client s3iface.S3API, url string, data []byte) error {
+	return WriteWithKey(client, url, data, "")
+}
+
+// WriteWithKey replaces the contents of the object in url with data, encrypted with
+// SSE-KMS under the key with the given id, ARN or alias. Without a key, SSE-S3 is used.
+func WriteWithKey(client s3iface.S3API, url string, data []byte, keyID string) error {
+	bucket, key, err := ParseURL(url)
+	if err != nil {
+		return err
+	}
+	input := &s3.PutObjectInput{
+		Bucket: &bucket,
+		Key:    &key,
+		Body:   bytes.NewReader(data),
+		// the rendered files may hold decrypted secrets
+		ServerSideEncryption: aws.String(s3.ServerSideEncryptionAes256),
+	}
+	if keyID != "" {
+		input.ServerSideEncryption = aws.String(s3.ServerSideEncryptionAwsKms)
+		input.SSEKMSKeyId = aws.String(keyID)
+	}
+	_, err = client.PutObject(input)
+	return err
+}
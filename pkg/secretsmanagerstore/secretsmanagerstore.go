@@ -0,0 +1,173 @@
+// Package secretsmanagerstore implements a store.Store backed by AWS Secrets Manager.
+//
+// Paths are secret names or ARNs, optionally followed by `#key` to address a single
+// key of a secret holding a JSON object, e.g. `rds/credentials#username`.
+package secretsmanagerstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/codacy/ssmeb/pkg/store"
+)
+
+// Store reads and writes parameters in Secrets Manager
+type Store struct {
+	client secretsmanageriface.SecretsManagerAPI
+}
+
+// New creates a Store using the provided Secrets Manager client
+func New(client secretsmanageriface.SecretsManagerAPI) *Store {
+	return &Store{client: client}
+}
+
+// Get returns the secret stored in path, or one of its keys if path has a `#key` suffix
+func (s *Store) Get(path string) (store.Parameter, error) {
+	name, key := splitKey(path)
+	secret, err := s.getSecretString(name)
+	if err != nil {
+		return store.Parameter{}, err
+	}
+	if key == "" {
+		return store.Parameter{Path: path, Value: secret, Secret: true}, nil
+	}
+
+	object, err := parseObject(name, secret)
+	if err != nil {
+		return store.Parameter{}, err
+	}
+	value, ok := object[key]
+	if !ok {
+		return store.Parameter{}, store.ErrNotFound
+	}
+	var text string
+	if err := json.Unmarshal(value, &text); err != nil {
+		// not a JSON string, so use the raw JSON value (e.g. a number)
+		text = string(value)
+	}
+	return store.Parameter{Path: path, Value: text, Secret: true}, nil
+}
+
+// Put stores the value in the secret, creating it if needed. When path has a `#key`
+// suffix only that key of the JSON object held by the secret is changed.
+func (s *Store) Put(par store.Parameter) (int64, error) {
+	name, key := splitKey(par.Path)
+	value := par.Value
+
+	exists := true
+	secret, err := s.getSecretString(name)
+	if err == store.ErrNotFound {
+		exists = false
+	} else if err != nil {
+		return 0, err
+	}
+
+	if key != "" {
+		object := map[string]json.RawMessage{}
+		if exists {
+			if object, err = parseObject(name, secret); err != nil {
+				return 0, err
+			}
+		}
+		encodedValue, _ := json.Marshal(par.Value)
+		object[key] = encodedValue
+		encodedObject, err := json.Marshal(object)
+		if err != nil {
+			return 0, err
+		}
+		value = string(encodedObject)
+	}
+
+	if !exists {
+		_, err = s.client.CreateSecret(&secretsmanager.CreateSecretInput{
+			Name:         aws.String(name),
+			Description:  aws.String(par.Description),
+			SecretString: aws.String(value),
+		})
+		return 0, err
+	}
+	_, err = s.client.PutSecretValue(&secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(name),
+		SecretString: aws.String(value),
+	})
+	return 0, err
+}
+
+// Delete schedules the deletion of the secret stored in path, using the default recovery window
+func (s *Store) Delete(path string) error {
+	name, key := splitKey(path)
+	if key != "" {
+		return fmt.Errorf("can't delete a single key of secret `%s`", name)
+	}
+	_, err := s.client.DeleteSecret(&secretsmanager.DeleteSecretInput{SecretId: aws.String(name)})
+	return convertError(err)
+}
+
+// List returns every secret whose name starts with prefix
+func (s *Store) List(prefix string) ([]store.Parameter, error) {
+	var names []string
+	err := s.client.ListSecretsPages(&secretsmanager.ListSecretsInput{}, func(output *secretsmanager.ListSecretsOutput, lastPage bool) bool {
+		for _, secret := range output.SecretList {
+			if name := aws.StringValue(secret.Name); strings.HasPrefix(name, prefix) {
+				names = append(names, name)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var parameters []store.Parameter
+	for _, name := range names {
+		par, err := s.Get(name)
+		if err != nil {
+			return parameters, err
+		}
+		parameters = append(parameters, par)
+	}
+	return parameters, nil
+}
+
+// getSecretString returns the current string value of the secret with name
+func (s *Store) getSecretString(name string) (string, error) {
+	output, err := s.client.GetSecretValue(&secretsmanager.GetSecretValueInput{SecretId: aws.String(name)})
+	if err != nil {
+		return "", convertError(err)
+	}
+	if output.SecretString == nil {
+		return "", fmt.Errorf("secret `%s` holds binary data, which is not supported", name)
+	}
+	return *output.SecretString, nil
+}
+
+// splitKey splits a path like `name#key` into the secret name and the key
+func splitKey(path string) (string, string) {
+	parts := strings.SplitN(path, "#", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// parseObject parses a secret holding a JSON object
+func parseObject(name string, secret string) (map[string]json.RawMessage, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(secret), &object); err != nil {
+		return nil, fmt.Errorf("secret `%s` is not a JSON object: %v", name, err)
+	}
+	return object, nil
+}
+
+// convertError replaces the secrets manager errors for missing secrets with store.ErrNotFound
+func convertError(err error) error {
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == secretsmanager.ErrCodeResourceNotFoundException {
+		return store.ErrNotFound
+	}
+	return err
+}
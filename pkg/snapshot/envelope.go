@@ -0,0 +1,112 @@
+package snapshot
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+)
+
+// envelope is a snapshot encrypted with a data key, which is itself encrypted with a
+// KMS key. Only the encrypted data key is stored, so KMS is needed to read it back.
+type envelope struct {
+	// KeyID is the ARN of the KMS key encrypting the data key
+	KeyID string `json:"kms_key_id"`
+	// EncryptedKey is the data key encrypted with the KMS key
+	EncryptedKey []byte `json:"encrypted_key"`
+	// Nonce is the AES-GCM nonce of the ciphertext
+	Nonce []byte `json:"nonce"`
+	// Ciphertext is the snapshot encrypted with the data key using AES-GCM
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// errEncrypted is returned when reading an encrypted snapshot without a KMS client
+var errEncrypted = errors.New("the snapshot is encrypted with KMS")
+
+// WriteEncryptedFile is like WriteFile, encrypting the snapshot with a data key
+// generated by the KMS key with the given id, ARN or alias, so the values are never
+// written in plain text
+func (s Snapshot) WriteEncryptedFile(filename string, client kmsiface.KMSAPI, keyID string) error {
+	plaintext, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	dataKey, err := client.GenerateDataKey(&kms.GenerateDataKeyInput{
+		KeyId:   aws.String(keyID),
+		KeySpec: aws.String(kms.DataKeySpecAes256),
+	})
+	if err != nil {
+		return err
+	}
+	defer zero(dataKey.Plaintext)
+
+	gcm, err := newGCM(dataKey.Plaintext)
+	if err != nil {
+		return err
+	}
+	sealed := envelope{
+		KeyID:        aws.StringValue(dataKey.KeyId),
+		EncryptedKey: dataKey.CiphertextBlob,
+		Nonce:        make([]byte, gcm.NonceSize()),
+	}
+	if _, err := io.ReadFull(rand.Reader, sealed.Nonce); err != nil {
+		return err
+	}
+	sealed.Ciphertext = gcm.Seal(nil, sealed.Nonce, plaintext, nil)
+
+	data, err := json.MarshalIndent(sealed, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(data, '\n'), 0600)
+}
+
+// decrypt returns the snapshot sealed in the envelope, decrypting its data key with KMS
+func (e envelope) decrypt(client kmsiface.KMSAPI) (Snapshot, error) {
+	var snapshot Snapshot
+	if client == nil {
+		return snapshot, errEncrypted
+	}
+	dataKey, err := client.Decrypt(&kms.DecryptInput{CiphertextBlob: e.EncryptedKey})
+	if err != nil {
+		return snapshot, err
+	}
+	defer zero(dataKey.Plaintext)
+
+	gcm, err := newGCM(dataKey.Plaintext)
+	if err != nil {
+		return snapshot, err
+	}
+	if len(e.Nonce) != gcm.NonceSize() {
+		return snapshot, errors.New("invalid nonce in the encrypted snapshot")
+	}
+	plaintext, err := gcm.Open(nil, e.Nonce, e.Ciphertext, nil)
+	if err != nil {
+		return snapshot, err
+	}
+	err = json.Unmarshal(plaintext, &snapshot)
+	return snapshot, err
+}
+
+// newGCM returns the AES-GCM cipher with the given key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// zero overwrites the key, so it doesn't linger in memory
+func zero(key []byte) {
+	for i := range key {
+		key[i] = 0
+	}
+}
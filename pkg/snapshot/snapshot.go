@@ -0,0 +1,141 @@
+// Package snapshot records the values resolved for a parameters file at a point
+// in time, so they can be compared or reused later without the store.
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/codacy/ssmeb/pkg/render"
+	"github.com/codacy/ssmeb/pkg/resolver"
+	"github.com/codacy/ssmeb/pkg/store"
+)
+
+// Snapshot is the values resolved for a parameters file
+type Snapshot struct {
+	// Created is when the values were resolved
+	Created time.Time `json:"created"`
+	// Environment is the environment used as prefix for the paths
+	Environment string `json:"environment,omitempty"`
+	// Entries holds one entry per parameter, in the order of the parameters file
+	Entries []Entry `json:"parameters"`
+}
+
+// Entry is the value of a single parameter
+type Entry struct {
+	// Name is the option name
+	Name string `json:"option_name"`
+	// Path is the path of the parameter in the store
+	Path string `json:"path"`
+	// Value is the value of the parameter
+	Value string `json:"value"`
+	// Version is the version of the parameter, if the store keeps track of it
+	Version int64 `json:"version,omitempty"`
+	// Secret reports whether the store holds the value encrypted
+	Secret bool `json:"secret,omitempty"`
+}
+
+// New creates a snapshot of the resolved values
+func New(environment string, values []resolver.Value) Snapshot {
+	snapshot := Snapshot{Created: time.Now().UTC(), Environment: environment}
+	for _, value := range values {
+		snapshot.Entries = append(snapshot.Entries, Entry{
+			Name:    value.Parameter.Name,
+			Path:    value.Stored.Path,
+			Value:   value.Stored.Value,
+			Version: value.Stored.Version,
+			Secret:  value.Stored.Secret,
+		})
+	}
+	return snapshot
+}
+
+// ReadFile reads a snapshot from the file with name filename. Snapshots encrypted with
+// KMS are decrypted with client, which may be nil when they're known to be plain.
+func ReadFile(filename string, client kmsiface.KMSAPI) (Snapshot, error) {
+	var snapshot Snapshot
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return snapshot, err
+	}
+	var sealed envelope
+	if err := json.Unmarshal(data, &sealed); err == nil && len(sealed.EncryptedKey) > 0 {
+		return sealed.decrypt(client)
+	}
+	err = json.Unmarshal(data, &snapshot)
+	return snapshot, err
+}
+
+// WriteFile saves the snapshot to the file with name filename. Since it holds
+// the values of the parameters, the file is only readable by its owner.
+func (s Snapshot) WriteFile(filename string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(data, '\n'), 0600)
+}
+
+// Options returns the entries as beanstalk options
+func (s Snapshot) Options() []render.Option {
+	options := make([]render.Option, 0, len(s.Entries))
+	for _, entry := range s.Entries {
+		options = append(options, render.Option{Name: entry.Name, Value: entry.Value, Secret: entry.Secret})
+	}
+	return options
+}
+
+// Store is a read-only store.Store serving the values of a snapshot, so they can
+// be resolved without access to the original store
+type Store struct {
+	entries []Entry
+}
+
+// NewStore creates a Store serving the values of s
+func (s Snapshot) NewStore() *Store {
+	return &Store{entries: s.Entries}
+}
+
+// Get returns the parameter recorded for path
+func (s *Store) Get(path string) (store.Parameter, error) {
+	for _, entry := range s.entries {
+		if entry.Path == path {
+			return entry.parameter(), nil
+		}
+	}
+	return store.Parameter{}, store.ErrNotFound
+}
+
+// Put fails, since snapshots are read-only
+func (s *Store) Put(par store.Parameter) (int64, error) {
+	return 0, errReadOnly
+}
+
+// Delete fails, since snapshots are read-only
+func (s *Store) Delete(path string) error {
+	return errReadOnly
+}
+
+// List returns the parameters recorded under prefix
+func (s *Store) List(prefix string) ([]store.Parameter, error) {
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+	var parameters []store.Parameter
+	for _, entry := range s.entries {
+		if strings.HasPrefix(entry.Path, prefix) {
+			parameters = append(parameters, entry.parameter())
+		}
+	}
+	return parameters, nil
+}
+
+// errReadOnly is returned when writing to a snapshot
+var errReadOnly = errors.New("snapshots are read-only")
+
+// parameter converts the entry into a store parameter
+func (e Entry) parameter() store.Parameter {
+	return store.Parameter{Path: e.Path, Value: e.Value, Version: e.Version, Secret: e.Secret}
+}
@@ -0,0 +1,459 @@
+// Package ssmfake provides an in-memory implementation of the SSM API, so code
+// using ssmstore can be exercised without an AWS account.
+package ssmfake
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	ssm "github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+// Client is an in-memory ssmiface.SSMAPI. Only the parameter operations used by
+// ssmeb are implemented, calling any other method panics. Like SSM, the values of
+// SecureString parameters are only returned in plain text when decrypting them.
+type Client struct {
+	ssmiface.SSMAPI
+
+	mutex      sync.Mutex
+	parameters map[string]*ssm.Parameter
+	tags       map[string]map[string]string
+	// versions holds every version of each parameter, oldest first
+	versions map[string][]*ssm.Parameter
+	// labels maps each label of a parameter to the version it's attached to
+	labels map[string]map[string]int64
+	// descriptions holds the description of the parameters given one
+	descriptions map[string]string
+	// keyIDs holds the KMS key encrypting each SecureString parameter
+	keyIDs map[string]string
+	// settings holds the tier, allowed pattern and policies of each parameter
+	settings map[string]settings
+}
+
+// settings are the attributes of a parameter that, like in SSM, are reset to their
+// defaults when it's overwritten without them
+type settings struct {
+	tier           string
+	allowedPattern *string
+	policies       []*ssm.ParameterInlinePolicy
+}
+
+// defaultKeyID is the key SSM encrypts SecureString parameters with when none is given
+const defaultKeyID = "alias/aws/ssm"
+
+// New creates an empty Client
+func New() *Client {
+	return &Client{
+		parameters:   map[string]*ssm.Parameter{},
+		tags:         map[string]map[string]string{},
+		versions:     map[string][]*ssm.Parameter{},
+		labels:       map[string]map[string]int64{},
+		descriptions: map[string]string{},
+		keyIDs:       map[string]string{},
+		settings:     map[string]settings{},
+	}
+}
+
+// Set stores value in path as a String parameter, creating a new version of it
+func (c *Client) Set(path string, value string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.put(path, value, ssm.ParameterTypeString)
+}
+
+// Value returns the value stored in path, reporting whether it exists
+func (c *Client) Value(path string) (string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	par, ok := c.parameters[path]
+	if !ok {
+		return "", false
+	}
+	return *par.Value, true
+}
+
+// GetParameter returns the parameter in input.Name, or a ParameterNotFound error. The
+// name may end in a `:version` or `:label` selector to get another version.
+func (c *Client) GetParameter(input *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	name, selector := aws.StringValue(input.Name), ""
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		name, selector = name[:i], name[i+1:]
+	}
+	par, ok := c.parameters[name]
+	if !ok {
+		return nil, notFound(aws.StringValue(input.Name))
+	}
+	if selector != "" {
+		version, err := strconv.ParseInt(selector, 10, 64)
+		if err != nil {
+			if version, ok = c.labels[name][selector]; !ok {
+				return nil, notFound(aws.StringValue(input.Name))
+			}
+		}
+		if version < 1 || version > int64(len(c.versions[name])) {
+			return nil, awserr.New(ssm.ErrCodeParameterVersionNotFound, fmt.Sprintf("version %s of %s not found", selector, name), nil)
+		}
+		par = c.versions[name][version-1]
+	}
+	result := encrypted(par, input.WithDecryption)
+	if selector != "" {
+		result.Selector = aws.String(":" + selector)
+	}
+	return &ssm.GetParameterOutput{Parameter: result}, nil
+}
+
+// GetParameters returns the parameters found in input.Names, listing the others as invalid
+func (c *Client) GetParameters(input *ssm.GetParametersInput) (*ssm.GetParametersOutput, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	output := &ssm.GetParametersOutput{}
+	for _, name := range input.Names {
+		par, ok := c.parameters[aws.StringValue(name)]
+		if !ok {
+			output.InvalidParameters = append(output.InvalidParameters, name)
+			continue
+		}
+		output.Parameters = append(output.Parameters, encrypted(par, input.WithDecryption))
+	}
+	return output, nil
+}
+
+// GetParametersByPath returns every parameter under input.Path in a single page,
+// sorted by name. Only direct children are returned unless input.Recursive is set.
+func (c *Client) GetParametersByPath(input *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	prefix := strings.TrimSuffix(aws.StringValue(input.Path), "/") + "/"
+	output := &ssm.GetParametersByPathOutput{}
+	for name, par := range c.parameters {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if !aws.BoolValue(input.Recursive) && strings.Contains(name[len(prefix):], "/") {
+			continue
+		}
+		output.Parameters = append(output.Parameters, encrypted(par, input.WithDecryption))
+	}
+	sort.Slice(output.Parameters, func(i, j int) bool {
+		return *output.Parameters[i].Name < *output.Parameters[j].Name
+	})
+	return output, nil
+}
+
+// DescribeParametersPages calls fn with a single page holding the metadata of the
+// parameters named in the Name filters of input.ParameterFilters, sorted by name. Any
+// other filter panics.
+func (c *Client) DescribeParametersPages(input *ssm.DescribeParametersInput, fn func(*ssm.DescribeParametersOutput, bool) bool) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	output := &ssm.DescribeParametersOutput{}
+	for _, filter := range input.ParameterFilters {
+		if aws.StringValue(filter.Key) != "Name" || aws.StringValue(filter.Option) != "Equals" {
+			panic(fmt.Sprintf("ssmfake: unsupported filter %s %s", aws.StringValue(filter.Key), aws.StringValue(filter.Option)))
+		}
+		for _, name := range filter.Values {
+			if par, ok := c.parameters[aws.StringValue(name)]; ok {
+				settings := c.settings[aws.StringValue(name)]
+				if settings.tier == "" {
+					settings.tier = ssm.ParameterTierStandard
+				}
+				output.Parameters = append(output.Parameters, &ssm.ParameterMetadata{
+					Name:             par.Name,
+					Description:      aws.String(c.descriptions[aws.StringValue(name)]),
+					Type:             par.Type,
+					KeyId:            keyID(c.keyIDs, aws.StringValue(name)),
+					Tier:             aws.String(settings.tier),
+					DataType:         par.DataType,
+					AllowedPattern:   settings.allowedPattern,
+					Policies:         settings.policies,
+					Version:          par.Version,
+					LastModifiedDate: par.LastModifiedDate,
+				})
+			}
+		}
+	}
+	sort.Slice(output.Parameters, func(i, j int) bool {
+		return *output.Parameters[i].Name < *output.Parameters[j].Name
+	})
+	fn(output, true)
+	return nil
+}
+
+// PutParameter stores the parameter, failing if it exists and input.Overwrite is not set.
+// Like SSM, it only takes tags when input.Overwrite is not set, and policies in the
+// Advanced tier. The tier, data type, allowed pattern and policies not given are reset
+// to their defaults.
+func (c *Client) PutParameter(input *ssm.PutParameterInput) (*ssm.PutParameterOutput, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	name := aws.StringValue(input.Name)
+	if len(input.Tags) > 0 && aws.BoolValue(input.Overwrite) {
+		return nil, awserr.New("ValidationException", "tags can't be given when overwriting a parameter", nil)
+	}
+	if _, ok := c.parameters[name]; ok && !aws.BoolValue(input.Overwrite) {
+		return nil, awserr.New(ssm.ErrCodeParameterAlreadyExists, fmt.Sprintf("parameter %s already exists", name), nil)
+	}
+	tier := ssm.ParameterTierStandard
+	if input.Tier != nil {
+		tier = aws.StringValue(input.Tier)
+	}
+	policies, err := inlinePolicies(input.Policies)
+	if err != nil {
+		return nil, awserr.New(ssm.ErrCodeInvalidPolicyAttributeException, fmt.Sprintf("invalid policies of %s: %v", name, err), nil)
+	}
+	if len(policies) > 0 && tier != ssm.ParameterTierAdvanced {
+		return nil, awserr.New(ssm.ErrCodeInvalidPolicyTypeException, fmt.Sprintf("policies of %s need the Advanced tier", name), nil)
+	}
+	par := c.put(name, aws.StringValue(input.Value), aws.StringValue(input.Type))
+	if input.DataType != nil {
+		par.DataType = input.DataType
+	}
+	c.settings[name] = settings{tier: tier, allowedPattern: input.AllowedPattern, policies: policies}
+	// like SSM, overwriting a SecureString without a key encrypts it with the default one
+	delete(c.keyIDs, name)
+	if aws.StringValue(input.Type) == ssm.ParameterTypeSecureString {
+		c.keyIDs[name] = defaultKeyID
+		if input.KeyId != nil {
+			c.keyIDs[name] = aws.StringValue(input.KeyId)
+		}
+	}
+	if input.Description != nil {
+		c.descriptions[name] = aws.StringValue(input.Description)
+	}
+	if len(input.Tags) > 0 {
+		c.tags[name] = map[string]string{}
+		for _, tag := range input.Tags {
+			c.tags[name][aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+	}
+	return &ssm.PutParameterOutput{Version: par.Version}, nil
+}
+
+// AddTagsToResource adds the tags to the parameter in input.ResourceId, replacing the
+// values of existing keys
+func (c *Client) AddTagsToResource(input *ssm.AddTagsToResourceInput) (*ssm.AddTagsToResourceOutput, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	name := aws.StringValue(input.ResourceId)
+	if _, ok := c.parameters[name]; !ok {
+		return nil, awserr.New(ssm.ErrCodeInvalidResourceId, fmt.Sprintf("parameter %s not found", name), nil)
+	}
+	if c.tags[name] == nil {
+		c.tags[name] = map[string]string{}
+	}
+	for _, tag := range input.Tags {
+		c.tags[name][aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	return &ssm.AddTagsToResourceOutput{}, nil
+}
+
+// LabelParameterVersion attaches the labels to the version of the parameter in
+// input.ParameterVersion, or the latest one, moving them from other versions
+func (c *Client) LabelParameterVersion(input *ssm.LabelParameterVersionInput) (*ssm.LabelParameterVersionOutput, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	name := aws.StringValue(input.Name)
+	par, ok := c.parameters[name]
+	if !ok {
+		return nil, notFound(name)
+	}
+	version := aws.Int64Value(par.Version)
+	if input.ParameterVersion != nil {
+		version = aws.Int64Value(input.ParameterVersion)
+	}
+	if version < 1 || version > int64(len(c.versions[name])) {
+		return nil, awserr.New(ssm.ErrCodeParameterVersionNotFound, fmt.Sprintf("version %d of %s not found", version, name), nil)
+	}
+	if c.labels[name] == nil {
+		c.labels[name] = map[string]int64{}
+	}
+	output := &ssm.LabelParameterVersionOutput{}
+	for _, label := range input.Labels {
+		if !validLabel(aws.StringValue(label)) {
+			output.InvalidLabels = append(output.InvalidLabels, label)
+			continue
+		}
+		c.labels[name][aws.StringValue(label)] = version
+	}
+	return output, nil
+}
+
+// validLabel reports whether SSM accepts the label. Like SSM, invalid labels are
+// reported in the output of LabelParameterVersion rather than as an error.
+func validLabel(label string) bool {
+	lower := strings.ToLower(label)
+	if label == "" || len(label) > 100 || label[0] >= '0' && label[0] <= '9' || strings.HasPrefix(lower, "aws") || strings.HasPrefix(lower, "ssm") {
+		return false
+	}
+	for _, r := range label {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// Labels returns the labels of the parameter in path, along with the versions they're attached to
+func (c *Client) Labels(path string) map[string]int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	labels := map[string]int64{}
+	for label, version := range c.labels[path] {
+		labels[label] = version
+	}
+	return labels
+}
+
+// ListTagsForResource returns the tags of the parameter in input.ResourceId, sorted by key
+func (c *Client) ListTagsForResource(input *ssm.ListTagsForResourceInput) (*ssm.ListTagsForResourceOutput, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	name := aws.StringValue(input.ResourceId)
+	if _, ok := c.parameters[name]; !ok {
+		return nil, awserr.New(ssm.ErrCodeInvalidResourceId, fmt.Sprintf("parameter %s not found", name), nil)
+	}
+	output := &ssm.ListTagsForResourceOutput{}
+	for key, value := range c.tags[name] {
+		output.TagList = append(output.TagList, &ssm.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+	sort.Slice(output.TagList, func(i, j int) bool {
+		return *output.TagList[i].Key < *output.TagList[j].Key
+	})
+	return output, nil
+}
+
+// Tags returns the tags of the parameter in path
+func (c *Client) Tags(path string) map[string]string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	tags := map[string]string{}
+	for key, value := range c.tags[path] {
+		tags[key] = value
+	}
+	return tags
+}
+
+// DeleteParameter removes the parameter in input.Name, or fails with a ParameterNotFound error
+func (c *Client) DeleteParameter(input *ssm.DeleteParameterInput) (*ssm.DeleteParameterOutput, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	name := aws.StringValue(input.Name)
+	if _, ok := c.parameters[name]; !ok {
+		return nil, notFound(name)
+	}
+	c.remove(name)
+	return &ssm.DeleteParameterOutput{}, nil
+}
+
+// DeleteParameters removes the parameters in input.Names, listing the missing ones as invalid
+func (c *Client) DeleteParameters(input *ssm.DeleteParametersInput) (*ssm.DeleteParametersOutput, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	output := &ssm.DeleteParametersOutput{}
+	for _, name := range input.Names {
+		if _, ok := c.parameters[aws.StringValue(name)]; !ok {
+			output.InvalidParameters = append(output.InvalidParameters, name)
+			continue
+		}
+		c.remove(aws.StringValue(name))
+		output.DeletedParameters = append(output.DeletedParameters, name)
+	}
+	return output, nil
+}
+
+// put stores a new version of the parameter, the caller must hold the mutex
+func (c *Client) put(name string, value string, parType string) *ssm.Parameter {
+	version := int64(1)
+	if previous, ok := c.parameters[name]; ok {
+		version = *previous.Version + 1
+	}
+	par := &ssm.Parameter{
+		Name:             aws.String(name),
+		Value:            aws.String(value),
+		Type:             aws.String(parType),
+		DataType:         aws.String("text"),
+		Version:          aws.Int64(version),
+		LastModifiedDate: aws.Time(time.Now()),
+	}
+	c.parameters[name] = par
+	c.versions[name] = append(c.versions[name], par)
+	return par
+}
+
+// remove deletes every version of the parameter, the caller must hold the mutex
+func (c *Client) remove(name string) {
+	delete(c.parameters, name)
+	delete(c.tags, name)
+	delete(c.versions, name)
+	delete(c.labels, name)
+	delete(c.descriptions, name)
+	delete(c.keyIDs, name)
+	delete(c.settings, name)
+}
+
+// KeyID returns the KMS key encrypting the SecureString parameter in path, or an empty
+// string if it's not one
+func (c *Client) KeyID(path string) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.keyIDs[path]
+}
+
+// keyID returns the key of the parameter in keyIDs, or nil if it has none
+func keyID(keyIDs map[string]string, name string) *string {
+	if keyID, ok := keyIDs[name]; ok {
+		return aws.String(keyID)
+	}
+	return nil
+}
+
+// inlinePolicies returns the policies in the JSON array given to PutParameter, as
+// described by SSM
+func inlinePolicies(policies *string) ([]*ssm.ParameterInlinePolicy, error) {
+	if policies == nil {
+		return nil, nil
+	}
+	var texts []json.RawMessage
+	if err := json.Unmarshal([]byte(aws.StringValue(policies)), &texts); err != nil {
+		return nil, err
+	}
+	var result []*ssm.ParameterInlinePolicy
+	for _, text := range texts {
+		var policy struct{ Type string }
+		if err := json.Unmarshal(text, &policy); err != nil {
+			return nil, err
+		}
+		result = append(result, &ssm.ParameterInlinePolicy{
+			PolicyText:   aws.String(string(text)),
+			PolicyType:   aws.String(policy.Type),
+			PolicyStatus: aws.String("Pending"),
+		})
+	}
+	return result, nil
+}
+
+// encrypted returns a copy of the parameter as returned by SSM, whose SecureString values
+// are replaced with a stand-in for their KMS ciphertext unless decrypting them
+func encrypted(par *ssm.Parameter, withDecryption *bool) *ssm.Parameter {
+	result := *par
+	if aws.StringValue(par.Type) == ssm.ParameterTypeSecureString && !aws.BoolValue(withDecryption) {
+		result.Value = aws.String("AQICAH" + base64.StdEncoding.EncodeToString([]byte(aws.StringValue(par.Value))))
+	}
+	return &result
+}
+
+// notFound returns the error SSM gives for a missing parameter
+func notFound(name string) error {
+	return awserr.New(ssm.ErrCodeParameterNotFound, fmt.Sprintf("parameter %s not found", name), nil)
+}
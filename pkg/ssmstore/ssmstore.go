@@ -0,0 +1,162 @@
+// Package ssmstore implements a store.Store backed by the AWS Systems Manager
+// Parameter Store.
+package ssmstore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	ssm "github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/codacy/ssmeb/pkg/store"
+)
+
+// Store reads and writes parameters in SSM
+type Store struct {
+	client ssmiface.SSMAPI
+}
+
+// New creates a Store using the provided SSM client, which is usually created with
+// ssm.New(session) or, in tests, is an ssmfake.Client
+func New(client ssmiface.SSMAPI) *Store {
+	return &Store{client: client}
+}
+
+// Get returns the parameter stored in path, decrypting SecureString values, which SSM
+// returns as KMS ciphertext otherwise. The path may end in a `:version` or `:label`
+// selector to get another version than the latest.
+func (s *Store) Get(path string) (store.Parameter, error) {
+	parOutput, err := s.client.GetParameter(&ssm.GetParameterInput{Name: &path, WithDecryption: aws.Bool(true)})
+	if err != nil {
+		return store.Parameter{}, convertError(err)
+	}
+	return convertParameter(parOutput.Parameter), nil
+}
+
+// Put stores the parameter as a String, or a SecureString if it's secret, overwriting
+// any existing value, and returns its new version. Secret values keep the KMS key of the
+// existing parameter. Its tags are added to the existing ones, and its labels attached to
+// the new version.
+func (s *Store) Put(par store.Parameter) (int64, error) {
+	input := &ssm.PutParameterInput{
+		Name:        aws.String(par.Path),
+		Description: aws.String(par.Description),
+		Value:       aws.String(par.Value),
+		Overwrite:   aws.Bool(true),
+		Type:        aws.String(ssm.ParameterTypeString),
+	}
+	if par.Secret {
+		input.Type = aws.String(ssm.ParameterTypeSecureString)
+		// SSM encrypts the new version with the default key unless given one
+		keyID, err := s.keyID(par.Path)
+		if err != nil {
+			return 0, fmt.Errorf("error getting the KMS key of `%s`: %v", par.Path, err)
+		}
+		input.KeyId = keyID
+	}
+	putOutput, err := s.client.PutParameter(input)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(par.Tags) > 0 {
+		// PutParameter doesn't accept tags when overwriting
+		keys := make([]string, 0, len(par.Tags))
+		for key := range par.Tags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var tags []*ssm.Tag
+		for _, key := range keys {
+			tags = append(tags, &ssm.Tag{Key: aws.String(key), Value: aws.String(par.Tags[key])})
+		}
+		_, err = s.client.AddTagsToResource(&ssm.AddTagsToResourceInput{
+			ResourceType: aws.String(ssm.ResourceTypeForTaggingParameter),
+			ResourceId:   aws.String(par.Path),
+			Tags:         tags,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("error tagging `%s`: %v", par.Path, err)
+		}
+	}
+
+	if len(par.Labels) > 0 {
+		labelOutput, err := s.client.LabelParameterVersion(&ssm.LabelParameterVersionInput{
+			Name:             aws.String(par.Path),
+			ParameterVersion: putOutput.Version,
+			Labels:           aws.StringSlice(par.Labels),
+		})
+		if err == nil && len(labelOutput.InvalidLabels) > 0 {
+			err = fmt.Errorf("invalid labels %s", strings.Join(aws.StringValueSlice(labelOutput.InvalidLabels), ", "))
+		}
+		if err != nil {
+			return 0, fmt.Errorf("error labelling `%s`: %v", par.Path, err)
+		}
+	}
+	return aws.Int64Value(putOutput.Version), nil
+}
+
+// keyID returns the KMS key of the SecureString parameter in path, or nil if there's no
+// such parameter
+func (s *Store) keyID(path string) (*string, error) {
+	var keyID *string
+	err := s.client.DescribeParametersPages(&ssm.DescribeParametersInput{
+		ParameterFilters: []*ssm.ParameterStringFilter{{Key: aws.String("Name"), Option: aws.String("Equals"), Values: aws.StringSlice([]string{path})}},
+	}, func(output *ssm.DescribeParametersOutput, last bool) bool {
+		for _, metadata := range output.Parameters {
+			if aws.StringValue(metadata.Type) == ssm.ParameterTypeSecureString {
+				keyID = metadata.KeyId
+			}
+		}
+		return true
+	})
+	return keyID, err
+}
+
+// Delete removes the parameter stored in path
+func (s *Store) Delete(path string) error {
+	_, err := s.client.DeleteParameter(&ssm.DeleteParameterInput{Name: &path})
+	return convertError(err)
+}
+
+// List returns every parameter stored under the prefix path, recursively
+func (s *Store) List(prefix string) ([]store.Parameter, error) {
+	var parameters []store.Parameter
+
+	input := &ssm.GetParametersByPathInput{Path: &prefix, Recursive: aws.Bool(true), WithDecryption: aws.Bool(true)}
+	for {
+		output, err := s.client.GetParametersByPath(input)
+		if err != nil {
+			return parameters, err
+		}
+		for _, par := range output.Parameters {
+			parameters = append(parameters, convertParameter(par))
+		}
+		if aws.StringValue(output.NextToken) == "" {
+			return parameters, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// convertParameter converts an ssm parameter into a store one
+func convertParameter(par *ssm.Parameter) store.Parameter {
+	return store.Parameter{
+		Path:    aws.StringValue(par.Name),
+		Value:   aws.StringValue(par.Value),
+		Version: aws.Int64Value(par.Version),
+		Secret:  aws.StringValue(par.Type) == ssm.ParameterTypeSecureString,
+	}
+}
+
+// convertError replaces the ssm errors for missing parameters, or versions of them,
+// with store.ErrNotFound
+func convertError(err error) error {
+	if aerr, ok := err.(awserr.Error); ok && (aerr.Code() == ssm.ErrCodeParameterNotFound || aerr.Code() == ssm.ErrCodeParameterVersionNotFound) {
+		return store.ErrNotFound
+	}
+	return err
+}
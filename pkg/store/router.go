@@ -0,0 +1,98 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Router is a Store dispatching each path to the store registered for its scheme,
+// e.g. `secretsmanager://name`, or to the default store for plain paths. The scheme
+// is removed from the path given to the store, and added back to the results.
+type Router struct {
+	def     Store
+	schemes map[string]Store
+}
+
+// NewRouter creates a Router sending plain paths to def
+func NewRouter(def Store) *Router {
+	return &Router{def: def, schemes: map[string]Store{}}
+}
+
+// Register sends the paths with the given scheme to s
+func (r *Router) Register(scheme string, s Store) {
+	r.schemes[scheme] = s
+}
+
+// Get returns the parameter stored in path
+func (r *Router) Get(path string) (Parameter, error) {
+	s, scheme, name, err := r.route(path)
+	if err != nil {
+		return Parameter{}, err
+	}
+	par, err := s.Get(name)
+	par.Path = join(scheme, par.Path)
+	return par, err
+}
+
+// Put stores the parameter, overwriting any existing value, and returns its new version
+func (r *Router) Put(par Parameter) (int64, error) {
+	s, _, name, err := r.route(par.Path)
+	if err != nil {
+		return 0, err
+	}
+	par.Path = name
+	return s.Put(par)
+}
+
+// Delete removes the parameter stored in path
+func (r *Router) Delete(path string) error {
+	s, _, name, err := r.route(path)
+	if err != nil {
+		return err
+	}
+	return s.Delete(name)
+}
+
+// List returns every parameter stored under the prefix path, recursively
+func (r *Router) List(prefix string) ([]Parameter, error) {
+	s, scheme, name, err := r.route(prefix)
+	if err != nil {
+		return nil, err
+	}
+	parameters, err := s.List(name)
+	for i := range parameters {
+		parameters[i].Path = join(scheme, parameters[i].Path)
+	}
+	return parameters, err
+}
+
+// route returns the store for path, along with its scheme and the path without it
+func (r *Router) route(path string) (Store, string, string, error) {
+	scheme, name := SplitScheme(path)
+	if scheme == "" {
+		return r.def, "", name, nil
+	}
+	s, ok := r.schemes[scheme]
+	if !ok {
+		return nil, "", "", fmt.Errorf("unknown store `%s` in path `%s`", scheme, path)
+	}
+	return s, scheme, name, nil
+}
+
+// SplitScheme splits a path like `scheme://name` into its scheme and name. The
+// scheme is empty for plain paths.
+func SplitScheme(path string) (string, string) {
+	parts := strings.SplitN(path, "://", 2)
+	if len(parts) == 1 {
+		return "", path
+	}
+	return parts[0], parts[1]
+}
+
+// join is the reverse of SplitScheme
+func join(scheme string, name string) string {
+	if scheme == "" || name == "" {
+		return name
+	}
+	return scheme + "://" + name
+}
@@ -0,0 +1,55 @@
+// Package store defines the interface between ssmeb and the backends holding
+// the parameter values, such as the SSM Parameter Store.
+package store
+
+import (
+	"errors"
+)
+
+// ErrNotFound is returned when a parameter does not exist in a Store
+var ErrNotFound = errors.New("parameter not found")
+
+// Parameter is a value held by a Store
+type Parameter struct {
+	// Path is the key of the parameter in the store
+	Path string
+	// Value is the value of the parameter
+	Value string
+	// Description is an optional string describing the parameter
+	Description string
+	// Version is the version of the parameter, when the store keeps track of it. It's ignored by Put.
+	Version int64
+	// Secret reports whether the store holds the value encrypted, like an SSM SecureString.
+	// Put encrypts secret values in stores that don't always do.
+	Secret bool
+	// Tags are attached to the parameter by Put, in stores supporting them. They're not
+	// returned by Get.
+	Tags map[string]string
+	// Labels are attached to the version written by Put, in stores supporting them, moving
+	// them from the previous versions. They're not returned by Get.
+	Labels []string
+}
+
+// Store is a backend holding parameter values
+type Store interface {
+	// Get returns the parameter stored in path, or ErrNotFound if there's none
+	Get(path string) (Parameter, error)
+	// Put stores the parameter, overwriting any existing value, and returns its new version
+	Put(par Parameter) (int64, error)
+	// Delete removes the parameter stored in path, or returns ErrNotFound if there's none
+	Delete(path string) error
+	// List returns every parameter stored under the prefix path, recursively
+	List(prefix string) ([]Parameter, error)
+}
+
+// Lookup returns the parameter stored in path, reporting whether it exists
+func Lookup(s Store, path string) (Parameter, bool, error) {
+	par, err := s.Get(path)
+	if err == ErrNotFound {
+		return par, false, nil
+	}
+	if err != nil {
+		return par, false, err
+	}
+	return par, true, nil
+}
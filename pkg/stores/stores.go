@@ -0,0 +1,95 @@
+// Package stores creates the store the parameter values are got from, for the backend
+// selected by name and the AWS session, as used by both ssmeb and its lambda function.
+package stores
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/codacy/ssmeb/pkg/azurekeyvaultstore"
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/execstore"
+	"github.com/codacy/ssmeb/pkg/filestore"
+	"github.com/codacy/ssmeb/pkg/gcpsecretmanagerstore"
+	"github.com/codacy/ssmeb/pkg/secretsmanagerstore"
+	"github.com/codacy/ssmeb/pkg/ssmstore"
+	"github.com/codacy/ssmeb/pkg/store"
+)
+
+// New creates the store holding the parameter values, which is the one of the backend,
+// like `ssm` or `file:params.json`, except for paths prefixed with another source (e.g.
+// `secretsmanager://`). The AWS stores use the session, and the plugins of the exec
+// source are told the environment.
+func New(session *session.Session, backend string, environment string) (*store.Router, error) {
+	var def store.Store
+	name, argument := SplitBackend(backend)
+	switch name {
+	case "ssm":
+		def = ssmstore.New(ssm.New(session))
+	case "azurekeyvault":
+		if argument == "" {
+			return nil, fmt.Errorf("Missing vault url in backend `%s`, e.g. azurekeyvault:https://myvault.vault.azure.net", backend)
+		}
+		var err error
+		def, err = azurekeyvaultstore.New(argument)
+		if err != nil {
+			return nil, fmt.Errorf("Error creating Azure Key Vault client: %v", err)
+		}
+	case "gcpsecretmanager":
+		if argument == "" {
+			return nil, fmt.Errorf("Missing project in backend `%s`, e.g. gcpsecretmanager:my-project", backend)
+		}
+		var err error
+		def, err = gcpsecretmanagerstore.New(argument)
+		if err != nil {
+			return nil, fmt.Errorf("Error creating Google Secret Manager client: %v", err)
+		}
+	case "file":
+		if argument == "" {
+			return nil, fmt.Errorf("Missing file in backend `%s`, e.g. file:params.json", backend)
+		}
+		def = filestore.New(argument)
+	default:
+		return nil, fmt.Errorf("Invalid backend: %s", backend)
+	}
+
+	router := store.NewRouter(def)
+	router.Register(config.SourceSecretsManager, secretsmanagerstore.New(secretsmanager.New(session)))
+	router.Register(config.SourceExec, execstore.New(environment))
+	return router, nil
+}
+
+// SplitBackend splits a backend like `name:argument` into its parts
+func SplitBackend(backend string) (string, string) {
+	parts := strings.SplitN(backend, ":", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// ExpandPlaceholders replaces the placeholders of the parameters that depend on AWS,
+// the region and account id of the session, only contacting it when they are used
+func ExpandPlaceholders(session *session.Session, parameters config.Parameters) (config.Parameters, error) {
+	values := map[string]string{}
+	if parameters.Uses(config.PlaceholderRegion) {
+		region := aws.StringValue(session.Config.Region)
+		if region == "" {
+			return parameters, fmt.Errorf("Missing AWS region, used as placeholder in the input")
+		}
+		values[config.PlaceholderRegion] = region
+	}
+	if parameters.Uses(config.PlaceholderAccountID) {
+		identity, err := sts.New(session).GetCallerIdentity(&sts.GetCallerIdentityInput{})
+		if err != nil {
+			return parameters, fmt.Errorf("Error getting the AWS account id: %v", err)
+		}
+		values[config.PlaceholderAccountID] = aws.StringValue(identity.Account)
+	}
+	return parameters.Interpolate(values), nil
+}
@@ -0,0 +1,61 @@
+package stores
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/codacy/ssmeb/pkg/config"
+)
+
+func TestNew(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssmeb-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "params.json")
+	if err := ioutil.WriteFile(filename, []byte(`{"/staging/db/host": {"value": "db.internal"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := session.Must(session.NewSession(&aws.Config{Region: aws.String("us-east-1")}))
+
+	router, err := New(s, "file:"+filename, "staging")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if par, err := router.Get("/staging/db/host"); err != nil || par.Value != "db.internal" {
+		t.Errorf("Get() = %+v, %v, want the value of the file", par, err)
+	}
+
+	for _, backend := range []string{"file", "azurekeyvault", "gcpsecretmanager", "vault"} {
+		if _, err := New(s, backend, "staging"); err == nil {
+			t.Errorf("New(%s) error = nil", backend)
+		}
+	}
+}
+
+func TestSplitBackend(t *testing.T) {
+	tests := []struct{ backend, name, argument string }{
+		{"ssm", "ssm", ""},
+		{"file:params.json", "file", "params.json"},
+		{"azurekeyvault:https://myvault.vault.azure.net", "azurekeyvault", "https://myvault.vault.azure.net"},
+	}
+	for _, test := range tests {
+		if name, argument := SplitBackend(test.backend); name != test.name || argument != test.argument {
+			t.Errorf("SplitBackend(%s) = %s, %s, want %s, %s", test.backend, name, argument, test.name, test.argument)
+		}
+	}
+}
+
+func TestExpandPlaceholders(t *testing.T) {
+	s := session.Must(session.NewSession(&aws.Config{Region: aws.String("eu-west-1")}))
+	parameters := config.Parameters{Component: []config.Parameter{{Name: "QUEUE", Path: "/{region}/queue"}}}
+	got, err := ExpandPlaceholders(s, parameters)
+	if err != nil || got.Component[0].Path != "/eu-west-1/queue" {
+		t.Errorf("ExpandPlaceholders() = %+v, %v, want the region replaced", got.Component, err)
+	}
+}
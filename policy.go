@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	policyCommands  []string
+	policyRegion    string
+	policyAccountID string
+)
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Print the IAM policy allowing to get or set the parameters, without contacting AWS",
+	Long: `Print the IAM policy allowing to get or set the parameters, without contacting AWS.
+
+The policy only grants the actions used by the commands on the ARNs of the
+parameters in the environment, plus decrypting them through SSM and Secrets
+Manager, and assuming the roles of the external parameters with a role_arn.
+The region defaults to the one configured for the AWS SDK and the account to
+any, which only matches the account of the role using the policy. The ARNs are
+in the partition of the region, e.g. aws-us-gov in GovCloud.`,
+	Example: `  ssmeb policy -i params.yaml -e production --commands get > policy.json
+  ssmeb policy -i params.yaml -e production --account-id 123456789012`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		policy, err := buildPolicy(policyCommands, policyRegion, policyAccountID)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(policy, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	},
+}
+
+func init() {
+	policyCmd.Flags().StringSliceVar(&policyCommands, "commands", []string{"get", "set"}, "commands the policy allows running, get and set")
+	policyCmd.Flags().StringVar(&policyRegion, "region", "", "region of the parameters (defaults to the configured region, or any)")
+	policyCmd.Flags().StringVar(&policyAccountID, "account-id", "*", "id of the account holding the parameters")
+	rootCmd.AddCommand(policyCmd)
+}
+
+// iamPolicy is an IAM policy document
+type iamPolicy struct {
+	Version   string
+	Statement []iamStatement
+}
+
+// iamStatement is a statement of an IAM policy document
+type iamStatement struct {
+	Sid       string
+	Effect    string
+	Action    []string
+	Resource  []string
+	Condition map[string]map[string][]string `json:",omitempty"`
+}
+
+// buildPolicy returns the policy allowing the commands, get and set, to access the
+// parameters in the input, in the given region and account. The region defaults to the
+// configured one.
+func buildPolicy(commands []string, region string, accountID string) (iamPolicy, error) {
+	var get, set bool
+	for _, command := range commands {
+		switch command {
+		case "get":
+			get = true
+		case "set":
+			set = true
+		default:
+			return iamPolicy{}, fmt.Errorf("Invalid command `%s`, expected get or set", command)
+		}
+	}
+	if name, _ := splitBackend(backend); name != "ssm" {
+		return iamPolicy{}, fmt.Errorf("The policy only covers AWS, not the `%s` backend", name)
+	}
+
+	parameters, err := loadParameters()
+	if err != nil {
+		return iamPolicy{}, err
+	}
+	if parameters.Uses(config.PlaceholderEnvironment) {
+		return iamPolicy{}, fmt.Errorf("Missing mandatory argument: `environment`, used as placeholder in the input")
+	}
+	if region == "" {
+		region = aws.StringValue(newSession().Config.Region)
+	}
+	if region == "" {
+		region = "*"
+	}
+	parameters = parameters.Interpolate(map[string]string{
+		config.PlaceholderRegion:    region,
+		config.PlaceholderAccountID: accountID,
+	})
+
+	// the ARNs of GovCloud and China have their own partition
+	partition := partitionOf(region)
+	var reads, lists, secrets, writes, roles []string
+	encrypted := false
+	for _, par := range parameters.All() {
+		// the access to parameters got with other credentials is granted by those
+		if par.Credentials() != "" {
+			if par.RoleARN != "" && par.Profile == "" {
+				roles = append(roles, par.RoleARN)
+			}
+			continue
+		}
+		scheme, name := store.SplitScheme(par.Path)
+		if scheme == config.SourceSecretsManager {
+			secrets = append(secrets, secretARN(partition, region, accountID, name))
+			continue
+		}
+		if scheme == config.SourceExec {
+			continue
+		}
+		if par.IsWildcard() {
+			lists = append(lists, parameterARN(partition, region, accountID, par.WildcardPrefix()))
+			continue
+		}
+		reads = append(reads, parameterARN(partition, region, accountID, name))
+	}
+	for _, par := range parameters.Component {
+		if scheme, _ := store.SplitScheme(par.Path); scheme == "" && !par.IsWildcard() {
+			writes = append(writes, parameterARN(partition, region, accountID, par.Path))
+			encrypted = encrypted || par.Type == config.TypeSecureString
+		}
+	}
+
+	policy := iamPolicy{Version: "2012-10-17"}
+	add := func(sid string, actions []string, resources []string) {
+		if len(resources) > 0 {
+			policy.Statement = append(policy.Statement, iamStatement{
+				Sid: sid, Effect: "Allow", Action: actions, Resource: unique(resources),
+			})
+		}
+	}
+	if get {
+		add("GetParameters", []string{"ssm:GetParameter"}, reads)
+		add("ListParameters", []string{"ssm:GetParametersByPath"}, lists)
+		add("GetSecrets", []string{"secretsmanager:GetSecretValue"}, secrets)
+		add("AssumeRoles", []string{"sts:AssumeRole"}, roles)
+	}
+	if set {
+		add("PutParameters", []string{"ssm:PutParameter"}, writes)
+	}
+
+	// values are encrypted with keys unknown to the input, so limit them to the services instead
+	var keyActions, services []string
+	if get && len(reads)+len(lists)+len(secrets) > 0 {
+		keyActions = append(keyActions, "kms:Decrypt")
+		services = append(services, serviceHost("ssm", region), serviceHost("secretsmanager", region))
+	}
+	if set && encrypted {
+		keyActions = append(keyActions, "kms:Encrypt", "kms:GenerateDataKey")
+		services = append(services, serviceHost("ssm", region))
+	}
+	if len(keyActions) > 0 {
+		policy.Statement = append(policy.Statement, iamStatement{
+			Sid:       "UseKeys",
+			Effect:    "Allow",
+			Action:    keyActions,
+			Resource:  []string{"*"},
+			Condition: map[string]map[string][]string{"StringLike": {"kms:ViaService": unique(services)}},
+		})
+	}
+	return policy, nil
+}
+
+// parameterARN returns the ARN of the SSM parameter with the given path
+func parameterARN(partition string, region string, accountID string, path string) string {
+	return fmt.Sprintf("arn:%s:ssm:%s:%s:parameter/%s", partition, region, accountID, strings.TrimPrefix(path, "/"))
+}
+
+// secretARN returns the ARN matching the secret with the name in path, which may end
+// in a `#key`. Secrets Manager appends a random suffix to the ARNs it creates.
+func secretARN(partition string, region string, accountID string, path string) string {
+	name := strings.SplitN(path, "#", 2)[0]
+	if strings.HasPrefix(name, "arn:") {
+		return name
+	}
+	return fmt.Sprintf("arn:%s:secretsmanager:%s:%s:secret:%s-??????", partition, region, accountID, name)
+}
+
+// unique returns the sorted strings of list without duplicates
+func unique(list []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, s := range list {
+		if !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
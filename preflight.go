@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/store"
+)
+
+// preflightBatch is the most names DescribeParameters takes in a filter
+const preflightBatch = 50
+
+// preflight fails listing every required parameter missing from SSM, when the preflight
+// flag is set, before any value is got
+func preflight(parameters config.Parameters) error {
+	if !preflightCheck || offline {
+		return nil
+	}
+	if name, _ := splitBackend(backend); name != "ssm" {
+		return fmt.Errorf("The preflight check needs the `ssm` backend")
+	}
+	fmt.Fprintf(os.Stderr, "* Checking that the required parameters exist... ")
+	missing, err := missingParameters(ssm.New(newSession()), parameters)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "FAILED")
+		return fmt.Errorf("Error checking the required parameters: %v", err)
+	}
+	if len(missing) > 0 {
+		fmt.Fprintln(os.Stderr, "FAILED")
+		return fmt.Errorf("%d required parameter(s) missing from SSM:\n  %s", len(missing), strings.Join(missing, "\n  "))
+	}
+	fmt.Fprintln(os.Stderr, "OK")
+	return nil
+}
+
+// missingParameters returns the sorted paths of the required parameters missing from
+// SSM, describing them in batches without getting their values. Optional parameters,
+// the ones with a default, under wildcard paths, in other stores or got with other
+// credentials are not checked.
+func missingParameters(client ssmiface.SSMAPI, parameters config.Parameters) ([]string, error) {
+	var paths []string
+	for _, par := range parameters.All() {
+		scheme, _ := store.SplitScheme(par.Path)
+		if par.Optional || par.Default != "" || par.IsWildcard() || scheme != "" || par.Credentials() != "" {
+			continue
+		}
+		paths = append(paths, par.Path)
+	}
+	paths = unique(paths)
+
+	found, err := existingPaths(client, paths)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, path := range paths {
+		if !found[path] {
+			missing = append(missing, path)
+		}
+	}
+	sort.Strings(missing)
+	return missing, nil
+}
+
+// existingPaths returns which of the paths exist in SSM, describing them in batches
+// without getting their values
+func existingPaths(client ssmiface.SSMAPI, paths []string) (map[string]bool, error) {
+	found := map[string]bool{}
+	for start := 0; start < len(paths); start += preflightBatch {
+		batch := paths[start:]
+		if len(batch) > preflightBatch {
+			batch = batch[:preflightBatch]
+		}
+		input := &ssm.DescribeParametersInput{
+			ParameterFilters: []*ssm.ParameterStringFilter{{
+				Key:    aws.String("Name"),
+				Option: aws.String("Equals"),
+				Values: aws.StringSlice(batch),
+			}},
+		}
+		err := client.DescribeParametersPages(input, func(page *ssm.DescribeParametersOutput, lastPage bool) bool {
+			for _, par := range page.Parameters {
+				found[aws.StringValue(par.Name)] = true
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/spf13/cobra"
+)
+
+var (
+	purgePath    string
+	purgeConfirm string
+)
+
+var purgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Delete every parameter in SSM under a path, after confirming it",
+	Long: `Delete every parameter in SSM under a path, after confirming it.
+
+Every parameter under the path, recursively, is listed first. They are only
+deleted once the path is typed back, or given in --confirm when running
+unattended, for decommissioning whole services safely. The input file is not
+used.`,
+	Example: `  ssmeb purge --path /codacy/old-service
+  ssmeb purge --path /codacy/old-service --confirm /codacy/old-service`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("path", purgePath)
+		if name, _ := splitBackend(backend); name != "ssm" || offline {
+			return fmt.Errorf("Only the parameters in the `ssm` backend can be purged")
+		}
+		p := &prompter{reader: bufio.NewReader(os.Stdin), out: os.Stderr}
+		return purgeParameters(ssm.New(newSession()), p, purgePath, purgeConfirm)
+	},
+}
+
+func init() {
+	purgeCmd.Flags().StringVar(&purgePath, "path", "", "path whose parameters are deleted, recursively (required)")
+	purgeCmd.Flags().StringVar(&purgeConfirm, "confirm", "", "the path again, to delete without asking")
+	rootCmd.AddCommand(purgeCmd)
+}
+
+// purgeBatch is the most names DeleteParameters takes
+const purgeBatch = 10
+
+// purgeParameters lists the parameters under the prefix and deletes them once the
+// prefix is confirmed, asking for it if confirm is empty
+func purgeParameters(client ssmiface.SSMAPI, p *prompter, prefix string, confirm string) error {
+	if trimmed := strings.TrimSuffix(prefix, "/"); !strings.HasPrefix(trimmed, "/") {
+		return fmt.Errorf("Invalid path `%s`, expected a path like /service, which can't be the root", prefix)
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	// the values are not needed, so they're not decrypted
+	var names []string
+	input := &ssm.GetParametersByPathInput{Path: aws.String(prefix), Recursive: aws.Bool(true)}
+	for {
+		output, err := client.GetParametersByPath(input)
+		if err != nil {
+			return fmt.Errorf("Error listing `%s`: %v", prefix, err)
+		}
+		for _, par := range output.Parameters {
+			names = append(names, aws.StringValue(par.Name))
+		}
+		if aws.StringValue(output.NextToken) == "" {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+	if len(names) == 0 {
+		fmt.Fprintf(p.out, "No parameters under `%s`\n", prefix)
+		return nil
+	}
+	sort.Strings(names)
+	fmt.Fprintf(p.out, "%d parameter(s) under `%s` will be deleted:\n", len(names), prefix)
+	for _, name := range names {
+		fmt.Fprintf(p.out, "  %s\n", name)
+	}
+
+	if confirm == "" {
+		var err error
+		confirm, err = p.ask("Type the path to confirm", "")
+		if err != nil {
+			return err
+		}
+	}
+	if strings.TrimSuffix(confirm, "/") != prefix {
+		return fmt.Errorf("The confirmation doesn't match `%s`, nothing was deleted", prefix)
+	}
+
+	deleted := 0
+	for start := 0; start < len(names); start += purgeBatch {
+		batch := names[start:]
+		if len(batch) > purgeBatch {
+			batch = batch[:purgeBatch]
+		}
+		output, err := client.DeleteParameters(&ssm.DeleteParametersInput{Names: aws.StringSlice(batch)})
+		if err != nil {
+			return fmt.Errorf("Error deleting parameters, %d of %d deleted: %v", deleted, len(names), err)
+		}
+		deleted += len(output.DeletedParameters)
+		for _, name := range output.InvalidParameters {
+			fmt.Fprintf(p.out, "* `%s` was already deleted\n", aws.StringValue(name))
+		}
+	}
+	fmt.Fprintf(p.out, "%d parameter(s) deleted\n", deleted)
+	return nil
+}
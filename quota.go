@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/store"
+)
+
+// strictQuota makes set fail, instead of warning, when it would bring the account close
+// to the quota of standard parameters
+var strictQuota bool
+
+// quotaWarningRatio is the share of the standard quota from which creating parameters warns
+const quotaWarningRatio = 0.9
+
+// checkQuota checks that the component parameters missing from SSM can be created
+// within the quota of standard parameters of the account, failing if they can't and
+// warning if the account would get close to it. The account is only counted when
+// there are parameters to create.
+func checkQuota(client *ssm.SSM, parameters config.Parameters) error {
+	var paths []string
+	for _, par := range parameters.Component {
+		if scheme, _ := store.SplitScheme(par.Path); scheme == "" && !par.IsWildcard() {
+			paths = append(paths, par.Path)
+		}
+	}
+	paths = unique(paths)
+	found, err := existingPaths(client, paths)
+	if err != nil {
+		return fmt.Errorf("Error describing the parameters: %v", err)
+	}
+	created := len(paths) - len(found)
+	if created == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "* Checking the quota of standard parameters for %d new one(s)... ", created)
+	account := tierCounts{}
+	err = describeTiers(client, nil, func(par *tieredParameter) { account[tierOf(par)]++ })
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "FAILED")
+		return fmt.Errorf("Error counting the parameters of the account: %v", err)
+	}
+	used := account[tierStandard]
+	switch {
+	case used+created > standardParameterQuota:
+		fmt.Fprintln(os.Stderr, "FAILED")
+		return fmt.Errorf("Creating %d parameter(s) would exceed the quota of %d standard parameters, of which %d are used. Delete unused parameters, or move some to the Advanced tier, before setting them", created, standardParameterQuota, used)
+	case float64(used+created) >= quotaWarningRatio*standardParameterQuota:
+		problem := fmt.Sprintf("%d of the %d standard parameters of the account would be used", used+created, standardParameterQuota)
+		if strictQuota {
+			fmt.Fprintln(os.Stderr, "FAILED")
+			return fmt.Errorf("%s, which is too close to the quota with --strict-quota", problem)
+		}
+		fmt.Fprintln(os.Stderr, "WARNING")
+		fmt.Fprintf(os.Stderr, "Warning: %s, new ones will soon need the Advanced tier\n", problem)
+	default:
+		fmt.Fprintln(os.Stderr, "OK")
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// maxTPS is the most SSM calls made per second, across every session, or 0 for no limit
+var maxTPS float64
+
+// tokenBucket lets calls through at a steady rate, allowing a burst of a second's worth
+// after a pause
+type tokenBucket struct {
+	mutex  sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// ssmLimiter limits the SSM calls of every session once created
+var (
+	ssmLimiter     *tokenBucket
+	ssmLimiterOnce sync.Once
+)
+
+// newTokenBucket creates a full bucket letting rate calls through per second
+func newTokenBucket(rate float64) *tokenBucket {
+	return &tokenBucket{rate: rate, tokens: math.Max(rate, 1), last: time.Now()}
+}
+
+// reserve takes a token, returning how long to wait for it to be available. Tokens
+// may be taken ahead, so concurrent callers wait in turn.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	now := time.Now()
+	b.tokens = math.Min(math.Max(b.rate, 1), b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// limitSSM makes the SSM calls of the session, retries included, wait for the limiter
+// when the max-tps flag is set
+func limitSSM(s *session.Session) *session.Session {
+	if maxTPS <= 0 {
+		return s
+	}
+	ssmLimiterOnce.Do(func() { ssmLimiter = newTokenBucket(maxTPS) })
+	s.Handlers.Send.PushFront(func(r *request.Request) {
+		if r.ClientInfo.ServiceName == ssm.ServiceName {
+			time.Sleep(ssmLimiter.reserve())
+		}
+	})
+	return s
+}
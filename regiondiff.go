@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	regionDiffSource     string
+	regionDiffReplica    string
+	regionDiffShowValues bool
+)
+
+var regionDiffCmd = &cobra.Command{
+	Use:   "region-diff",
+	Short: "Show the differences between the values of the parameters in two regions",
+	Long: `Show the differences between the values of the parameters in two regions.
+
+Every parameter is looked up at the same path in both regions, reporting the
+ones missing from either region or whose values differ. The parameters under
+wildcard paths are compared too. Exits with an error if any difference is
+found, e.g. when a disaster recovery region has fallen behind.`,
+	Example: `  ssmeb region-diff -i params.yaml -e production --replica us-east-1`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("input", inputName(), "environment", environment, "source", regionDiffSource, "replica", regionDiffReplica)
+		return runRegionDiff(regionDiffSource, regionDiffReplica, regionDiffShowValues)
+	},
+}
+
+func init() {
+	regionDiffCmd.Flags().StringVar(&regionDiffSource, "source", "", "region holding the reference values (defaults to the configured region)")
+	regionDiffCmd.Flags().StringVar(&regionDiffReplica, "replica", "", "region compared with the source (required)")
+	regionDiffCmd.Flags().BoolVar(&regionDiffShowValues, "show-values", false, "print the differing values (they may contain secrets)")
+	rootCmd.AddCommand(regionDiffCmd)
+}
+
+// runRegionDiff compares the values of the parameters in the source and replica
+// regions and reports every difference
+func runRegionDiff(source string, replica string, showValues bool) error {
+	if replica == "" {
+		return fmt.Errorf("Missing mandatory argument: `replica`")
+	}
+	if name, _ := splitBackend(backend); name != "ssm" || offline {
+		return fmt.Errorf("Regions can only be compared with the `ssm` backend")
+	}
+	if source == "" {
+		source = aws.StringValue(newSession().Config.Region)
+	}
+
+	parameters, err := loadParameters()
+	if err != nil {
+		return err
+	}
+	parameters, err = expandPlaceholders(parameters)
+	if err != nil {
+		return err
+	}
+	sourceStore, err := newStoreIn(source)
+	if err != nil {
+		return err
+	}
+	replicaStore, err := newStoreIn(replica)
+	if err != nil {
+		return err
+	}
+
+	differences, err := diffRegions(sourceStore, replicaStore, parameters, showValues)
+	if err != nil {
+		return fmt.Errorf("Error getting values: %v", err)
+	}
+	if differences > 0 {
+		return fmt.Errorf("%d parameter(s) differ between `%s` and `%s`", differences, source, replica)
+	}
+	fmt.Fprintf(os.Stderr, "No differences found between `%s` and `%s`\n", source, replica)
+	return nil
+}
+
+// diffRegions prints a line for each parameter missing from one of the stores or whose
+// values differ, and returns how many were found
+func diffRegions(source store.Store, replica store.Store, parameters config.Parameters, showValues bool) (int, error) {
+	differences := 0
+	for _, par := range parameters.All() {
+		var paths []string
+		if par.IsWildcard() {
+			var err error
+			paths, err = childPaths(par, source, replica)
+			if err != nil {
+				return differences, err
+			}
+		} else {
+			paths = []string{par.Path}
+		}
+
+		for _, path := range paths {
+			name := par.Name
+			if par.IsWildcard() {
+				name = par.ExpandName(strings.TrimPrefix(path, par.WildcardPrefix()+"/"))
+			}
+			if !parameters.Selects(name) {
+				continue
+			}
+			sourceValue, inSource, err := store.Lookup(source, path)
+			if err != nil {
+				return differences, err
+			}
+			replicaValue, inReplica, err := store.Lookup(replica, path)
+			if err != nil {
+				return differences, err
+			}
+			switch {
+			case !inSource && !inReplica:
+				continue
+			case !inReplica:
+				fmt.Printf("* `%s` (%s): missing in the replica\n", name, path)
+			case !inSource:
+				fmt.Printf("* `%s` (%s): missing in the source\n", name, path)
+			case sourceValue.Value != replicaValue.Value:
+				fmt.Printf("* `%s` (%s): value differs\n", name, path)
+				if showValues {
+					fmt.Printf("    - %s\n    + %s\n", replicaValue.Value, sourceValue.Value)
+				}
+			default:
+				continue
+			}
+			differences++
+		}
+	}
+	return differences, nil
+}
+
+// childPaths returns the paths stored directly under the path of the wildcard parameter
+// in any of the stores, sorted
+func childPaths(par config.Parameter, stores ...store.Store) ([]string, error) {
+	prefix := par.WildcardPrefix()
+	seen := map[string]bool{}
+	var paths []string
+	for _, s := range stores {
+		children, err := s.List(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", par.Path, err)
+		}
+		for _, child := range children {
+			leaf := strings.TrimPrefix(child.Path, prefix+"/")
+			if leaf == child.Path || strings.Contains(leaf, "/") || seen[child.Path] {
+				continue
+			}
+			seen[child.Path] = true
+			paths = append(paths, child.Path)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v3"
+)
+
+var (
+	renameFrom    string
+	renameTo      string
+	renameMapping string
+	renameKeepOld bool
+)
+
+var renameCmd = &cobra.Command{
+	Use:   "rename",
+	Short: "Move parameters in SSM to new paths, updating the input file",
+	Long: `Move parameters in SSM to new paths, updating the input file.
+
+The value, type, description, tags, tier, data type, allowed pattern and
+policies of the parameter in --from are copied to --to, which must not exist,
+and the parameter in --from is deleted. With --keep-old it's kept instead for a
+grace period, tagged with the new path in ssmeb:renamed-to, so it can be purged
+later. Several parameters can be moved at once with --mapping, a yaml file
+mapping each old path to its new one.
+
+The paths are replaced in the input file too, when a single file is given, which
+must be yaml so it can be rewritten.
+Paths written without the environment prefix are replaced when both paths are
+under /<environment>, which renames them for every environment, so the
+parameters of the other environments must be moved too. If a rename fails, the
+input is still updated with the renames done before it.`,
+	Example: `  ssmeb rename -i params.yaml --from /myservice/db/host --to /myservice/database/host
+  ssmeb rename -i params.yaml -e production --mapping renames.yaml --keep-old`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("input", inputName(), "environment", environment)
+		renames, err := readRenames()
+		if err != nil {
+			return err
+		}
+		if name, _ := splitBackend(backend); name != "ssm" || offline {
+			return fmt.Errorf("Only the parameters in the `ssm` backend can be renamed")
+		}
+		input, err := renamedInput()
+		if err != nil {
+			return err
+		}
+		return renameAll(ssm.New(newSession()), renames, input)
+	},
+}
+
+func init() {
+	renameCmd.Flags().StringVar(&renameFrom, "from", "", "path of the parameter to move")
+	renameCmd.Flags().StringVar(&renameTo, "to", "", "new path of the parameter")
+	renameCmd.Flags().StringVar(&renameMapping, "mapping", "", "yaml file mapping old paths to new ones, to move several parameters")
+	renameCmd.Flags().BoolVar(&renameKeepOld, "keep-old", false, "keep the old parameters, tagged with their new path, instead of deleting them")
+	rootCmd.AddCommand(renameCmd)
+}
+
+// readRenames returns the pairs of old and new paths given in the flags, sorted by old path
+func readRenames() ([][2]string, error) {
+	mapping := map[string]string{}
+	switch {
+	case renameMapping != "" && (renameFrom != "" || renameTo != ""):
+		return nil, fmt.Errorf("Expected either --mapping or --from and --to")
+	case renameMapping != "":
+		data, err := ioutil.ReadFile(renameMapping)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading file `%s`: %v", renameMapping, err)
+		}
+		if err := yaml.Unmarshal(data, &mapping); err != nil {
+			return nil, fmt.Errorf("Error parsing file `%s`: %v", renameMapping, err)
+		}
+	case renameFrom != "" && renameTo != "":
+		mapping[renameFrom] = renameTo
+	default:
+		return nil, fmt.Errorf("Missing mandatory arguments: `from` and `to`, or `mapping`")
+	}
+
+	var renames [][2]string
+	targets := map[string]bool{}
+	for from, to := range mapping {
+		if !strings.HasPrefix(from, "/") || !strings.HasPrefix(to, "/") || from == to {
+			return nil, fmt.Errorf("Invalid rename of `%s` to `%s`, expected two different paths starting with `/`", from, to)
+		}
+		if targets[to] {
+			return nil, fmt.Errorf("More than one parameter is renamed to `%s`", to)
+		}
+		targets[to] = true
+		renames = append(renames, [2]string{from, to})
+	}
+	sort.Slice(renames, func(i, j int) bool { return renames[i][0] < renames[j][0] })
+	return renames, nil
+}
+
+// renameAll moves the parameters, in order, and replaces their paths in the input. If a
+// rename fails, the input is still updated with the ones already done, so it keeps
+// matching SSM, before returning the error.
+func renameAll(client ssmiface.SSMAPI, renames [][2]string, input string) error {
+	for i, rename := range renames {
+		if err := renameParameter(client, rename[0], rename[1], renameKeepOld); err != nil {
+			if i == 0 {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Warning: %d of %d parameter(s) were renamed, updating the input with them\n", i, len(renames))
+			if inputErr := renameInInput(input, renames[:i]); inputErr != nil {
+				return fmt.Errorf("%v\n%v", err, inputErr)
+			}
+			return err
+		}
+	}
+	return renameInInput(input, renames)
+}
+
+// renamedInput returns the input file whose paths are replaced after renaming, or empty
+// if there isn't a single local one. It fails if the file isn't yaml, the only format
+// rewritten, so nothing is renamed in SSM that the input would miss.
+func renamedInput() (string, error) {
+	if len(inputs) != 1 || inputs[0] == stdinInput || isRemoteInput(inputs[0]) {
+		return "", nil
+	}
+	format := inputFormat
+	if format == "" {
+		data, err := ioutil.ReadFile(inputs[0])
+		if err != nil {
+			return "", fmt.Errorf("Error reading file `%s`: %v", inputs[0], err)
+		}
+		format = config.FileFormat(inputs[0], data)
+	}
+	if format != config.FormatYAML {
+		return "", fmt.Errorf("Only yaml inputs can be updated with the new paths, and `%s` is %s, nothing was renamed", inputs[0], format)
+	}
+	return inputs[0], nil
+}
+
+// renameParameter copies the value, type, description, tags and settings such as the
+// tier and policies of the parameter in path from to path to, which must not exist, and deletes it or tags it as renamed
+func renameParameter(client ssmiface.SSMAPI, from string, to string, keepOld bool) error {
+	fmt.Printf("* Renaming `%s` to `%s`... ", from, to)
+	got, err := client.GetParameter(&ssm.GetParameterInput{Name: aws.String(from), WithDecryption: aws.Bool(true)})
+	if err != nil {
+		fmt.Println("FAILED")
+		return fmt.Errorf("Error getting `%s`: %v", from, err)
+	}
+	var metadata ssm.ParameterMetadata
+	input := &ssm.DescribeParametersInput{
+		ParameterFilters: []*ssm.ParameterStringFilter{{
+			Key:    aws.String("Name"),
+			Option: aws.String("Equals"),
+			Values: aws.StringSlice([]string{from}),
+		}},
+	}
+	err = client.DescribeParametersPages(input, func(page *ssm.DescribeParametersOutput, lastPage bool) bool {
+		for _, par := range page.Parameters {
+			metadata = *par
+		}
+		return true
+	})
+	if err != nil {
+		fmt.Println("FAILED")
+		return fmt.Errorf("Error describing `%s`: %v", from, err)
+	}
+	tags, err := client.ListTagsForResource(&ssm.ListTagsForResourceInput{
+		ResourceType: aws.String(ssm.ResourceTypeForTaggingParameter),
+		ResourceId:   aws.String(from),
+	})
+	if err != nil {
+		fmt.Println("FAILED")
+		return fmt.Errorf("Error listing the tags of `%s`: %v", from, err)
+	}
+
+	// the new parameter is created, not overwritten, so it can take the tags
+	put := &ssm.PutParameterInput{
+		Name:           aws.String(to),
+		Value:          got.Parameter.Value,
+		Type:           got.Parameter.Type,
+		Description:    metadata.Description,
+		KeyId:          metadata.KeyId,
+		Tier:           metadata.Tier,
+		DataType:       metadata.DataType,
+		AllowedPattern: metadata.AllowedPattern,
+		Policies:       inlinePolicies(metadata.Policies),
+		Overwrite:      aws.Bool(false),
+	}
+	if len(tags.TagList) > 0 {
+		put.Tags = tags.TagList
+	}
+	if _, err := client.PutParameter(put); err != nil {
+		fmt.Println("FAILED")
+		return fmt.Errorf("Error creating `%s`: %v", to, err)
+	}
+
+	if keepOld {
+		_, err = client.AddTagsToResource(&ssm.AddTagsToResourceInput{
+			ResourceType: aws.String(ssm.ResourceTypeForTaggingParameter),
+			ResourceId:   aws.String(from),
+			Tags:         []*ssm.Tag{{Key: aws.String("ssmeb:renamed-to"), Value: aws.String(to)}},
+		})
+	} else {
+		_, err = client.DeleteParameter(&ssm.DeleteParameterInput{Name: aws.String(from)})
+	}
+	if err != nil {
+		fmt.Println("FAILED")
+		return fmt.Errorf("Error cleaning up `%s`, already copied to `%s`: %v", from, to, err)
+	}
+	fmt.Println("OK")
+	return nil
+}
+
+// renameInInput replaces the old paths with the new ones in the yaml input file given by
+// renamedInput, if any
+func renameInInput(input string, renames [][2]string) error {
+	if input == "" {
+		fmt.Fprintln(os.Stderr, "Warning: the input wasn't updated, it's only updated when a single local file is given")
+		return nil
+	}
+	data, err := ioutil.ReadFile(input)
+	if err != nil {
+		return fmt.Errorf("Error reading file `%s`: %v", input, err)
+	}
+	doc, err := config.ParseDocument(data)
+	if err != nil {
+		return fmt.Errorf("Error parsing file `%s`: %v", input, err)
+	}
+
+	prefix := "/" + environment
+	for _, rename := range renames {
+		from, to := rename[0], rename[1]
+		renamed := doc.RenamePath(from, to)
+		if renamed == 0 && environment != "" && strings.HasPrefix(from, prefix+"/") && strings.HasPrefix(to, prefix+"/") {
+			renamed = doc.RenamePath(strings.TrimPrefix(from, prefix), strings.TrimPrefix(to, prefix))
+		}
+		if renamed == 0 {
+			fmt.Fprintf(os.Stderr, "Warning: `%s` isn't in `%s`\n", from, input)
+		}
+	}
+	if err := doc.WriteFile(input); err != nil {
+		return fmt.Errorf("Error writing file `%s`: %v", input, err)
+	}
+	fmt.Fprintf(os.Stderr, "Updated the paths in `%s`\n", input)
+	return nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/codacy/ssmeb/pkg/ssmstore/ssmfake"
+)
+
+// renameFake returns a fake SSM client holding a tagged SecureString in /staging/db/pass
+func renameFake() *ssmfake.Client {
+	client := ssmfake.New()
+	client.PutParameter(&ssm.PutParameterInput{
+		Name:        aws.String("/staging/db/pass"),
+		Value:       aws.String("s3cret"),
+		Type:        aws.String(ssm.ParameterTypeSecureString),
+		Description: aws.String("database password"),
+		Tags:        []*ssm.Tag{{Key: aws.String("team"), Value: aws.String("data")}},
+	})
+	return client
+}
+
+func TestRenameParameter(t *testing.T) {
+	client := renameFake()
+	if err := renameParameter(client, "/staging/db/pass", "/staging/db/password", false); err != nil {
+		t.Fatalf("renameParameter() error = %v", err)
+	}
+
+	if _, found := client.Value("/staging/db/pass"); found {
+		t.Errorf("/staging/db/pass still exists")
+	}
+	got, err := client.GetParameter(&ssm.GetParameterInput{Name: aws.String("/staging/db/password"), WithDecryption: aws.Bool(true)})
+	if err != nil {
+		t.Fatalf("/staging/db/password wasn't created: %v", err)
+	}
+	if aws.StringValue(got.Parameter.Value) != "s3cret" || aws.StringValue(got.Parameter.Type) != ssm.ParameterTypeSecureString {
+		t.Errorf("/staging/db/password = %v, want the SecureString s3cret", got.Parameter)
+	}
+	if tags := client.Tags("/staging/db/password"); !reflect.DeepEqual(tags, map[string]string{"team": "data"}) {
+		t.Errorf("tags of /staging/db/password = %v, want the ones of /staging/db/pass", tags)
+	}
+}
+
+func TestRenameParameterAdvanced(t *testing.T) {
+	client := ssmfake.New()
+	_, err := client.PutParameter(&ssm.PutParameterInput{
+		Name:           aws.String("/staging/ami"),
+		Value:          aws.String("ami-12345678"),
+		Type:           aws.String(ssm.ParameterTypeString),
+		Tier:           aws.String(ssm.ParameterTierAdvanced),
+		DataType:       aws.String("aws:ec2:image"),
+		AllowedPattern: aws.String("^ami-[0-9a-f]+$"),
+		Policies:       aws.String("[" + expiration + "]"),
+	})
+	if err != nil {
+		t.Fatalf("PutParameter() error = %v", err)
+	}
+	if err := renameParameter(client, "/staging/ami", "/staging/base-image", false); err != nil {
+		t.Fatalf("renameParameter() error = %v", err)
+	}
+
+	metadata := describe(t, client, "/staging/base-image")
+	if got := aws.StringValue(metadata.Tier); got != ssm.ParameterTierAdvanced {
+		t.Errorf("tier = %q, want %q", got, ssm.ParameterTierAdvanced)
+	}
+	if got := aws.StringValue(metadata.DataType); got != "aws:ec2:image" {
+		t.Errorf("data type = %q, want %q", got, "aws:ec2:image")
+	}
+	if got := aws.StringValue(metadata.AllowedPattern); got != "^ami-[0-9a-f]+$" {
+		t.Errorf("allowed pattern = %q, want %q", got, "^ami-[0-9a-f]+$")
+	}
+	if len(metadata.Policies) != 1 || aws.StringValue(metadata.Policies[0].PolicyText) != expiration {
+		t.Errorf("policies = %v, want the expiration policy", metadata.Policies)
+	}
+}
+
+func TestRenameParameterKeepOld(t *testing.T) {
+	client := renameFake()
+	if err := renameParameter(client, "/staging/db/pass", "/staging/db/password", true); err != nil {
+		t.Fatalf("renameParameter() error = %v", err)
+	}
+	want := map[string]string{"team": "data", "ssmeb:renamed-to": "/staging/db/password"}
+	if tags := client.Tags("/staging/db/pass"); !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags of /staging/db/pass = %v, want %v", tags, want)
+	}
+	if value, _ := client.Value("/staging/db/password"); value != "s3cret" {
+		t.Errorf("value of /staging/db/password = %q, want s3cret", value)
+	}
+}
+
+func TestRenameParameterErrors(t *testing.T) {
+	client := renameFake()
+	client.Set("/staging/db/existing", "taken")
+
+	if err := renameParameter(client, "/staging/db/missing", "/staging/db/new", false); err == nil {
+		t.Errorf("renameParameter() of a missing parameter error = nil")
+	}
+	if err := renameParameter(client, "/staging/db/pass", "/staging/db/existing", false); err == nil {
+		t.Errorf("renameParameter() to an existing parameter error = nil")
+	}
+	if value, _ := client.Value("/staging/db/pass"); value != "s3cret" {
+		t.Errorf("/staging/db/pass = %q after a failed rename, want it kept", value)
+	}
+	if value, _ := client.Value("/staging/db/existing"); value != "taken" {
+		t.Errorf("/staging/db/existing = %q after a failed rename, want it kept", value)
+	}
+}
+
+func TestRenameAllPartialFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssmeb-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	input := filepath.Join(dir, "params.yaml")
+	written := "component:\n  - option_name: DB_PASS\n    path: /db/pass\n  - option_name: DB_USER\n    path: /db/user\n"
+	if err := ioutil.WriteFile(input, []byte(written), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(i []string, e string) { inputs, environment = i, e }(inputs, environment)
+	inputs, environment = []string{input}, "staging"
+
+	client := renameFake()
+	client.Set("/staging/db/user", "app")
+	client.Set("/staging/db/username", "taken")
+	renames := [][2]string{{"/staging/db/pass", "/staging/db/password"}, {"/staging/db/user", "/staging/db/username"}}
+	if err := renameAll(client, renames, input); err == nil {
+		t.Fatalf("renameAll() error = nil, want the failure of the second rename")
+	}
+
+	data, err := ioutil.ReadFile(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Replace(written, "/db/pass\n", "/db/password\n", 1)
+	if string(data) != want {
+		t.Errorf("input after a failed rename =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestRenamedInput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssmeb-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"params.yaml":    "component:\n  - option_name: DB_PASS\n    path: /db/pass\n",
+		"params.json":    `{"component": [{"option_name": "DB_PASS", "path": "/db/pass"}]}`,
+		"params.hcl":     "component \"DB_PASS\" {\n  path = \"/db/pass\"\n}\n",
+		"params.jsonnet": "{component: [{option_name: 'DB_PASS', path: '/db/pass'}]}",
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer func(i []string, f string) { inputs, inputFormat = i, f }(inputs, inputFormat)
+
+	tests := []struct {
+		inputs []string
+		format string
+		want   string
+		err    bool
+	}{
+		{[]string{"params.yaml"}, "", "params.yaml", false},
+		{[]string{"params.json"}, "", "", true},
+		{[]string{"params.hcl"}, "", "", true},
+		{[]string{"params.jsonnet"}, "", "", true},
+		{[]string{"params.yaml"}, "json", "", true},
+		{[]string{"params.yaml", "params.json"}, "", "", false},
+		{[]string{stdinInput}, "", "", false},
+	}
+	for _, test := range tests {
+		inputs, inputFormat = nil, test.format
+		for _, input := range test.inputs {
+			if input != stdinInput {
+				input = filepath.Join(dir, input)
+			}
+			inputs = append(inputs, input)
+		}
+		got, err := renamedInput()
+		if test.want != "" {
+			test.want = filepath.Join(dir, test.want)
+		}
+		if got != test.want || (err != nil) != test.err {
+			t.Errorf("renamedInput() with %v (%q) = %q, %v, want %q (error: %v)", test.inputs, test.format, got, err, test.want, test.err)
+		}
+	}
+}
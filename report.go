@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/codacy/ssmeb/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Summarize the parameters by tier, the standard tier quota and the Advanced tier cost",
+	Long: `Summarize the parameters by tier, the standard tier quota and the Advanced tier cost.
+
+The parameters of the component, i.e. its component parameters and the ones
+under its wildcard paths, are counted by tier, along with every parameter in
+the account and region. The account total is compared with the quota of
+standard parameters, and the monthly storage cost of the Advanced parameters
+is estimated, for the component as it is and if all of it were Advanced.
+Prices are the list ones of us-east-1, without the API interactions.`,
+	Example: `  ssmeb report -i params.yaml -e production`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("input", inputName(), "environment", environment)
+		return runReport()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+}
+
+const (
+	// standardParameterQuota is the most standard parameters of an account in a region
+	standardParameterQuota = 10000
+	// advancedParameterPrice is the monthly storage price of an Advanced parameter, in USD
+	advancedParameterPrice = 0.05
+	// tierStandard and tierAdvanced are the tiers of the parameters
+	tierStandard = "Standard"
+	tierAdvanced = "Advanced"
+)
+
+// tieredParameter is the metadata of a parameter used by the report. It's got with a
+// request of its own, since this version of the SDK doesn't model the tiers.
+type tieredParameter struct {
+	Name *string
+	Tier *string
+}
+
+// describeTieredOutput is a page of DescribeParameters holding the tiers
+type describeTieredOutput struct {
+	NextToken  *string
+	Parameters []*tieredParameter
+}
+
+// tierCounts counts parameters by tier
+type tierCounts map[string]int
+
+// runReport prints the tiers of the parameters of the component and of the account
+func runReport() error {
+	if !canDescribe() {
+		return fmt.Errorf("The report needs the `ssm` backend")
+	}
+	parameters, err := loadParameters()
+	if err != nil {
+		return err
+	}
+	parameters, err = expandPlaceholders(parameters)
+	if err != nil {
+		return err
+	}
+
+	client := ssm.New(newSession())
+	var exact []string
+	var filters [][]*ssm.ParameterStringFilter
+	for _, par := range parameters.Component {
+		if scheme, _ := store.SplitScheme(par.Path); scheme != "" || par.Credentials() != "" {
+			continue
+		}
+		if par.IsWildcard() {
+			filters = append(filters, []*ssm.ParameterStringFilter{{
+				Key:    aws.String("Path"),
+				Option: aws.String("Recursive"),
+				Values: aws.StringSlice([]string{par.WildcardPrefix()}),
+			}})
+			continue
+		}
+		exact = append(exact, par.Path)
+	}
+	exact = unique(exact)
+	for start := 0; start < len(exact); start += preflightBatch {
+		batch := exact[start:]
+		if len(batch) > preflightBatch {
+			batch = batch[:preflightBatch]
+		}
+		filters = append(filters, []*ssm.ParameterStringFilter{{
+			Key:    aws.String("Name"),
+			Option: aws.String("Equals"),
+			Values: aws.StringSlice(batch),
+		}})
+	}
+
+	component := tierCounts{}
+	seen := map[string]bool{}
+	for _, filter := range filters {
+		err := describeTiers(client, filter, func(par *tieredParameter) {
+			if name := aws.StringValue(par.Name); !seen[name] {
+				seen[name] = true
+				component[tierOf(par)]++
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("Error describing the parameters of the component: %v", err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "* Counting the parameters of the account... ")
+	account := tierCounts{}
+	err = describeTiers(client, nil, func(par *tieredParameter) { account[tierOf(par)]++ })
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "FAILED")
+		return fmt.Errorf("Error describing the parameters of the account: %v", err)
+	}
+	fmt.Fprintln(os.Stderr, "OK")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SCOPE\tSTANDARD\tADVANCED\tTOTAL\tADVANCED COST")
+	for _, row := range []struct {
+		scope  string
+		counts tierCounts
+	}{{"component", component}, {"account", account}} {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t$%.2f/month\n", row.scope, row.counts[tierStandard], row.counts[tierAdvanced], row.counts.total(), float64(row.counts[tierAdvanced])*advancedParameterPrice)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	used := account[tierStandard]
+	fmt.Printf("\nStandard quota: %d of %d used (%.1f%%), %d left\n", used, standardParameterQuota, float64(used)*100/standardParameterQuota, standardParameterQuota-used)
+	fmt.Printf("The component all in the Advanced tier would cost $%.2f/month\n", float64(component.total())*advancedParameterPrice)
+	if used >= standardParameterQuota*9/10 {
+		fmt.Fprintln(os.Stderr, "Warning: the account is close to the quota of standard parameters, new ones will need the Advanced tier")
+	}
+	return nil
+}
+
+// describeTiers calls fn with the metadata of every parameter matching the filters,
+// or of the account if there are none
+func describeTiers(client *ssm.SSM, filters []*ssm.ParameterStringFilter, fn func(par *tieredParameter)) error {
+	op := &request.Operation{Name: "DescribeParameters", HTTPMethod: "POST", HTTPPath: "/"}
+	input := &ssm.DescribeParametersInput{ParameterFilters: filters, MaxResults: aws.Int64(50)}
+	for {
+		output := &describeTieredOutput{}
+		if err := client.NewRequest(op, input, output).Send(); err != nil {
+			return err
+		}
+		for _, par := range output.Parameters {
+			fn(par)
+		}
+		if aws.StringValue(output.NextToken) == "" {
+			return nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// tierOf returns the tier of the parameter, which is Standard when not reported
+func tierOf(par *tieredParameter) string {
+	if strings.EqualFold(aws.StringValue(par.Tier), tierAdvanced) {
+		return tierAdvanced
+	}
+	return tierStandard
+}
+
+// total counts the parameters of every tier
+func (c tierCounts) total() int {
+	total := 0
+	for _, count := range c {
+		total += count
+	}
+	return total
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var resolveCmd = &cobra.Command{
+	Use:   "resolve",
+	Short: "Show the parameters effective in the environment and where their fields come from",
+	Long: `Show the parameters effective in the environment and where their fields come from.
+
+Each environment in the environments section overrides the fields it sets in
+the base parameters. An environment extending another one applies the
+overrides of the extended environment first, so its own take precedence:
+
+  base parameters < extended environments (farthest first) < environment
+
+The store is not contacted, so the values shown are the ones in the input.`,
+	Example: `  ssmeb resolve -i params.yaml -e production`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		parameters, err := readComponentParameters()
+		if err != nil {
+			return err
+		}
+		return printExplanations(parameters, environment)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(resolveCmd)
+}
+
+// printExplanations prints a table with the parameters effective in environment,
+// telling the environment each overridden field comes from
+func printExplanations(parameters config.Parameters, environment string) error {
+	explanations, err := parameters.Explain(environment)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "OPTION\tPATH\tVALUE\tFROM")
+	for _, explanation := range explanations {
+		par := explanation.Parameter
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", par.Name, par.Path, par.Value, describeOrigins(explanation.Origins))
+	}
+	return w.Flush()
+}
+
+// describeOrigins summarizes where the fields of a parameter come from, e.g.
+// `base, path from staging`
+func describeOrigins(origins map[string]string) string {
+	fields := make([]string, 0, len(origins))
+	for field := range origins {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	description := []string{"base"}
+	for _, field := range fields {
+		description = append(description, fmt.Sprintf("%s from %s", field, origins[field]))
+	}
+	return strings.Join(description, ", ")
+}
@@ -0,0 +1,42 @@
+#!/usr/bin/env bash
+# Builds the release assets of ssmeb in dist/: a binary for each platform, named like
+# ssmeb_linux_amd64, their sha256 in checksums.txt, and the base64 ed25519 signature
+# of checksums.txt in checksums.txt.sig, which self-update verifies.
+#
+# Usage: RELEASE_SIGNING_KEY=release.pem scripts/release.sh v1.4.0
+#
+# RELEASE_SIGNING_KEY is an ed25519 private key in PEM, created once with
+# `openssl genpkey -algorithm ed25519 -out release.pem`. Its public key is built into
+# the binaries, so they can verify the next releases.
+set -euo pipefail
+
+version="${1:?Usage: RELEASE_SIGNING_KEY=release.pem $0 <version>}"
+key="${RELEASE_SIGNING_KEY:?RELEASE_SIGNING_KEY must be the ed25519 private key in PEM}"
+platforms="${RELEASE_PLATFORMS:-linux/amd64 linux/arm64 darwin/amd64 darwin/arm64 windows/amd64}"
+
+cd "$(dirname "$0")/.."
+rm -rf dist
+mkdir dist
+
+# the raw 32 bytes of the key are the last ones of its DER encoding
+public_key="$(openssl pkey -in "$key" -pubout -outform DER | tail -c 32 | base64)"
+ldflags="-s -w -X main.version=${version} -X main.commit=$(git rev-parse HEAD)"
+ldflags="${ldflags} -X main.date=$(date -u +%Y-%m-%dT%H:%M:%SZ) -X main.releasePublicKey=${public_key}"
+
+for platform in $platforms; do
+  os="${platform%/*}"
+  arch="${platform#*/}"
+  name="ssmeb_${os}_${arch}"
+  if [ "$os" = "windows" ]; then
+    name="${name}.exe"
+  fi
+  echo "* Building ${name}..."
+  CGO_ENABLED=0 GOOS="$os" GOARCH="$arch" go build -trimpath -ldflags "$ldflags" -o "dist/${name}" .
+done
+
+(cd dist && sha256sum ssmeb_* > checksums.txt)
+openssl pkeyutl -sign -rawin -inkey "$key" -in dist/checksums.txt | base64 -w 0 > dist/checksums.txt.sig
+# check the signature with the public key built into the binaries
+openssl pkeyutl -verify -rawin -pubin -inkey <(openssl pkey -in "$key" -pubout) \
+  -in dist/checksums.txt -sigfile <(base64 -d dist/checksums.txt.sig)
+echo "Release ${version} is in dist/"
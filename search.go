@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchLive   bool
+	searchPrefix string
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <term>",
+	Short: "Find the parameters whose name, path or description fuzzy-match a term",
+	Long: `Find the parameters whose name, path or description fuzzy-match a term.
+
+A word matches a field when its letters appear in it in order, not necessarily
+together, ignoring case. Every word of the term must match some field of a
+parameter, and the best matches, with the letters together or at the start of
+words, are printed first. With --live, the parameters stored in SSM under the
+prefix are searched too, by path, including the ones missing from the input.
+Values are never printed.`,
+	Example: `  ssmeb search -i params.yaml "redis endpoint"
+  ssmeb search -i params.yaml -e production --live dbpass`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSearch(args[0])
+	},
+}
+
+func init() {
+	searchCmd.Flags().BoolVar(&searchLive, "live", false, "also search the parameters stored in SSM under the prefix")
+	searchCmd.Flags().StringVar(&searchPrefix, "prefix", "", "path searched with --live (defaults to /<environment>)")
+	rootCmd.AddCommand(searchCmd)
+}
+
+// searchResult is a parameter matching the search term
+type searchResult struct {
+	Score       int
+	Name        string
+	Path        string
+	Section     string
+	Type        string
+	Description string
+}
+
+// runSearch prints the parameters of the input, and the ones in SSM with --live,
+// matching the term, best first
+func runSearch(term string) error {
+	words := strings.Fields(term)
+	if len(words) == 0 {
+		return fmt.Errorf("The search term is empty")
+	}
+	parameters, err := loadParameters()
+	if err != nil {
+		return err
+	}
+
+	var results []searchResult
+	inInput := map[string]bool{}
+	sections := []struct {
+		name string
+		list []config.Parameter
+	}{{"component", parameters.Component}, {"external", parameters.External}, {"derived", parameters.Derived}}
+	for _, section := range sections {
+		for _, par := range section.list {
+			inInput[par.Path] = true
+			parType := par.Type
+			if section.name == "component" && parType == "" {
+				parType = config.TypeString
+			}
+			result := searchResult{Name: par.Name, Path: par.Path, Section: section.name, Type: parType, Description: par.Description}
+			if score, ok := matchWords(words, par.Name, par.Path, par.Description); ok {
+				result.Score = score
+				results = append(results, result)
+			}
+		}
+	}
+
+	if searchLive {
+		prefix := searchPrefix
+		if prefix == "" && environment == "" {
+			return fmt.Errorf("Missing mandatory argument with --live: `prefix` or `environment`")
+		}
+		if prefix == "" {
+			prefix = "/" + environment
+		}
+		s, err := newStore()
+		if err != nil {
+			return err
+		}
+		stored, err := s.List(prefix)
+		if err != nil {
+			return fmt.Errorf("Error listing `%s`: %v", prefix, err)
+		}
+		for _, par := range stored {
+			if inInput[par.Path] {
+				continue
+			}
+			if score, ok := matchWords(words, par.Path); ok {
+				parType := config.TypeString
+				if par.Secret {
+					parType = config.TypeSecureString
+				}
+				results = append(results, searchResult{Score: score, Path: par.Path, Section: "not in input", Type: parType, Description: par.Description})
+			}
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) == 0 {
+		return fmt.Errorf("No parameters match `%s`", term)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "OPTION\tPATH\tSECTION\tTYPE\tDESCRIPTION")
+	for _, result := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", result.Name, result.Path, result.Section, result.Type, strings.Join(strings.Fields(result.Description), " "))
+	}
+	return w.Flush()
+}
+
+// matchWords returns the sum of the best score of each word in any of the fields,
+// reporting whether every word matched some field
+func matchWords(words []string, fields ...string) (int, bool) {
+	total := 0
+	for _, word := range words {
+		best, found := 0, false
+		for _, field := range fields {
+			if score, ok := fuzzyScore(word, field); ok && (!found || score > best) {
+				best, found = score, true
+			}
+		}
+		if !found {
+			return 0, false
+		}
+		total += best
+	}
+	return total, true
+}
+
+// fuzzyScore reports whether the letters of term appear in text in order, ignoring
+// case, scoring higher the letters following the previous match or starting a word
+func fuzzyScore(term string, text string) (int, bool) {
+	letters := []rune(strings.ToLower(text))
+	score, i, next := 0, 0, -1
+	for _, want := range strings.ToLower(term) {
+		for i < len(letters) && letters[i] != want {
+			i++
+		}
+		if i == len(letters) {
+			return 0, false
+		}
+		score++
+		if i == next {
+			score += 5
+		}
+		if i == 0 || strings.ContainsRune(wordSeparators, letters[i-1]) {
+			score += 3
+		}
+		i++
+		next = i
+	}
+	return score, true
+}
+
+// wordSeparators are the characters starting a new word in names, paths and descriptions
+const wordSeparators = "/_-. :#"
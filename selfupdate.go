@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	selfUpdateVersion string
+	selfUpdateCheck   bool
+)
+
+// releasePublicKey is the base64 ed25519 public key signing the checksums of the
+// releases, injected at link time with -X main.releasePublicKey=<key> by
+// scripts/release.sh. Without it, self-update refuses to install anything, since the
+// checksums alone come from the same place as the binaries.
+var releasePublicKey = ""
+
+// githubAPI is the url of the GitHub API the releases are got from
+var githubAPI = "https://api.github.com"
+
+const (
+	// releaseRepository is the GitHub repository publishing the releases
+	releaseRepository = "codacy/ssmeb"
+	// checksumsAsset is the release asset listing the sha256 of every binary, like sha256sum does
+	checksumsAsset = "checksums.txt"
+	// signatureAsset is the release asset holding the base64 ed25519 signature of the checksums
+	signatureAsset = checksumsAsset + ".sig"
+	// selfUpdateTimeout is how long each request to GitHub may take, downloads included
+	selfUpdateTimeout = 5 * time.Minute
+)
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Replace this binary with the latest release from GitHub",
+	Long: `Replace this binary with the latest release from GitHub.
+
+The binary for this OS and architecture, named like ssmeb_linux_amd64, is
+downloaded from the latest release of ` + releaseRepository + `, or the one tagged
+--version, and verified against the sha256 in the checksums.txt of the release,
+whose ed25519 signature in checksums.txt.sig is checked with the release public
+key built into this binary. Binaries built without one, like the ones built from
+source, can only --check. The running binary is then replaced, which needs write
+access to its directory. GITHUB_TOKEN is used, if set, to avoid the
+rate limits of anonymous requests.`,
+	Example: `  ssmeb self-update
+  ssmeb self-update --check
+  ssmeb self-update --version v1.4.0`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSelfUpdate(selfUpdateVersion, selfUpdateCheck)
+	},
+}
+
+func init() {
+	selfUpdateCmd.Flags().StringVar(&selfUpdateVersion, "version", "", "tag of the release to install, e.g. v1.4.0 (defaults to the latest one)")
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateCheck, "check", false, "only tell whether there's a newer release, without installing it")
+	rootCmd.AddCommand(selfUpdateCmd)
+}
+
+// release is the part of a GitHub release used to update
+type release struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// assetURL returns the download url of the asset with the given name
+func (r release) assetURL(name string) (string, error) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset.URL, nil
+		}
+	}
+	return "", fmt.Errorf("Release %s has no `%s`", r.TagName, name)
+}
+
+// runSelfUpdate replaces the running binary with the one of the release with the given
+// tag, or of the latest one, after verifying it
+func runSelfUpdate(tag string, check bool) error {
+	endpoint := fmt.Sprintf("%s/repos/%s/releases/latest", githubAPI, releaseRepository)
+	if tag != "" {
+		endpoint = fmt.Sprintf("%s/repos/%s/releases/tags/%s", githubAPI, releaseRepository, tag)
+	}
+	data, err := download(endpoint)
+	if err != nil {
+		return fmt.Errorf("Error getting the release: %v", err)
+	}
+	var latest release
+	if err := json.Unmarshal(data, &latest); err != nil {
+		return fmt.Errorf("Error parsing the release: %v", err)
+	}
+	if strings.TrimPrefix(latest.TagName, "v") == strings.TrimPrefix(version, "v") {
+		fmt.Fprintf(os.Stderr, "Already at %s\n", latest.TagName)
+		return nil
+	}
+	if check {
+		fmt.Printf("%s is available, this is %s. Run `ssmeb self-update` to install it\n", latest.TagName, version)
+		return nil
+	}
+	if releasePublicKey == "" {
+		return fmt.Errorf("This binary was built without a release public key, so %s can't be verified. Download it from https://github.com/%s/releases instead", latest.TagName, releaseRepository)
+	}
+
+	name := fmt.Sprintf("ssmeb_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	fmt.Fprintf(os.Stderr, "* Downloading %s of %s... ", name, latest.TagName)
+	binary, err := downloadVerified(latest, name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "FAILED")
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "OK")
+
+	executable, err := os.Executable()
+	if err == nil {
+		executable, err = filepath.EvalSymlinks(executable)
+	}
+	if err != nil {
+		return fmt.Errorf("Error finding this binary: %v", err)
+	}
+	if err := replaceExecutable(executable, binary); err != nil {
+		return fmt.Errorf("Error replacing `%s`: %v", executable, err)
+	}
+	fmt.Fprintf(os.Stderr, "Updated `%s` from %s to %s\n", executable, version, latest.TagName)
+	return nil
+}
+
+// downloadVerified downloads the asset of the release with the given name, checking it
+// against the checksums of the release and their signature
+func downloadVerified(r release, name string) ([]byte, error) {
+	checksumsURL, err := r.assetURL(checksumsAsset)
+	if err != nil {
+		return nil, err
+	}
+	checksums, err := download(checksumsURL)
+	if err != nil {
+		return nil, fmt.Errorf("Error downloading `%s`: %v", checksumsAsset, err)
+	}
+	if err := verifySignature(r, checksums); err != nil {
+		return nil, err
+	}
+
+	var wanted string
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		// lines are like `<sha256>  <name>`, with a `*` before binary names
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			wanted = strings.ToLower(fields[0])
+		}
+	}
+	if wanted == "" {
+		return nil, fmt.Errorf("`%s` has no checksum for `%s`", checksumsAsset, name)
+	}
+
+	binaryURL, err := r.assetURL(name)
+	if err != nil {
+		return nil, err
+	}
+	binary, err := download(binaryURL)
+	if err != nil {
+		return nil, fmt.Errorf("Error downloading `%s`: %v", name, err)
+	}
+	sum := sha256.Sum256(binary)
+	if got := hex.EncodeToString(sum[:]); got != wanted {
+		return nil, fmt.Errorf("The checksum of `%s` is %s instead of %s, it was not installed", name, got, wanted)
+	}
+	return binary, nil
+}
+
+// verifySignature checks the signature of the checksums with the release public key
+func verifySignature(r release, checksums []byte) error {
+	key, err := base64.StdEncoding.DecodeString(releasePublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("Invalid release public key built into this binary")
+	}
+	signatureURL, err := r.assetURL(signatureAsset)
+	if err != nil {
+		return err
+	}
+	encoded, err := download(signatureURL)
+	if err != nil {
+		return fmt.Errorf("Error downloading `%s`: %v", signatureAsset, err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil || !ed25519.Verify(ed25519.PublicKey(key), checksums, signature) {
+		return fmt.Errorf("The signature of `%s` is invalid, nothing was installed", checksumsAsset)
+	}
+	return nil
+}
+
+// download gets the contents at url, authenticating to GitHub with GITHUB_TOKEN if set
+func download(url string) ([]byte, error) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" && strings.HasPrefix(url, githubAPI) {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	client := http.Client{Timeout: selfUpdateTimeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, response.Status)
+	}
+	return ioutil.ReadAll(response.Body)
+}
+
+// replaceExecutable writes the binary next to the executable and moves it in its place.
+// The old one is moved aside first, since running binaries can't be overwritten on Windows.
+func replaceExecutable(executable string, binary []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(executable), "."+filepath.Base(executable)+".new")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(binary)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0755)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	old := executable + ".old"
+	os.Remove(old)
+	if err := os.Rename(executable, old); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), executable); err != nil {
+		os.Rename(old, executable)
+		os.Remove(tmp.Name())
+		return err
+	}
+	// removing the running binary fails on Windows, where it's left behind
+	os.Remove(old)
+	return nil
+}
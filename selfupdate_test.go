@@ -0,0 +1,91 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// releaseServer serves a release of the assets, with their url on the server
+func releaseServer(assets map[string]string) (*httptest.Server, release) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/download/")
+		if r.URL.Path == "/repos/"+releaseRepository+"/releases/latest" {
+			fmt.Fprint(w, `{"tag_name": "v2.0.0"}`)
+			return
+		}
+		contents, ok := assets[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, contents)
+	}))
+	r := release{TagName: "v2.0.0"}
+	for name := range assets {
+		r.Assets = append(r.Assets, struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		}{name, server.URL + "/download/" + name})
+	}
+	return server, r
+}
+
+func TestDownloadVerified(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(key string) { releasePublicKey = key }(releasePublicKey)
+	releasePublicKey = base64.StdEncoding.EncodeToString(public)
+
+	binary := "new binary"
+	sum := sha256.Sum256([]byte(binary))
+	checksums := hex.EncodeToString(sum[:]) + "  ssmeb_linux_amd64\n"
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(private, []byte(checksums)))
+
+	tests := []struct {
+		name   string
+		assets map[string]string
+		err    string
+	}{
+		{"signed", map[string]string{"ssmeb_linux_amd64": binary, checksumsAsset: checksums, signatureAsset: signature}, ""},
+		{"unsigned", map[string]string{"ssmeb_linux_amd64": binary, checksumsAsset: checksums}, "has no `checksums.txt.sig`"},
+		{"tampered checksums", map[string]string{"ssmeb_linux_amd64": "other binary", checksumsAsset: strings.Replace(checksums, checksums[:8], "00000000", 1), signatureAsset: signature}, "signature of `checksums.txt` is invalid"},
+		{"tampered binary", map[string]string{"ssmeb_linux_amd64": "other binary", checksumsAsset: checksums, signatureAsset: signature}, "The checksum of `ssmeb_linux_amd64`"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, r := releaseServer(test.assets)
+			defer server.Close()
+			got, err := downloadVerified(r, "ssmeb_linux_amd64")
+			if test.err == "" && (err != nil || string(got) != binary) {
+				t.Errorf("downloadVerified() = %q, %v, want %q", got, err, binary)
+			}
+			if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Errorf("downloadVerified() error = %v, want one containing %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestSelfUpdateWithoutKey(t *testing.T) {
+	server, _ := releaseServer(nil)
+	defer server.Close()
+	defer func(api string, key string) { githubAPI, releasePublicKey = api, key }(githubAPI, releasePublicKey)
+	githubAPI, releasePublicKey = server.URL, ""
+
+	if err := runSelfUpdate("", true); err != nil {
+		t.Errorf("runSelfUpdate() with --check error = %v, want it to work without a key", err)
+	}
+	err := runSelfUpdate("", false)
+	if err == nil || !strings.Contains(err.Error(), "without a release public key") {
+		t.Errorf("runSelfUpdate() error = %v, want a refusal to install without a key", err)
+	}
+}
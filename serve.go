@@ -0,0 +1,187 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codacy/ssmeb/pkg/render"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveListen  string
+	serveRefresh time.Duration
+	serveToken   string
+	serveGRPC    string
+	serveCert    string
+	serveKey     string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the resolved parameters as JSON over HTTP, refreshing them periodically",
+	Long: `Serve the resolved parameters as JSON over HTTP, refreshing them periodically.
+
+GET /config returns a JSON object from option name to value, and requires the
+token to be sent as "Authorization: Bearer <token>". GET /healthz reports
+whether the values were resolved at least once. When a refresh fails the
+previous values keep being served.
+
+With --grpc-listen, the ssmeb.v1.Control gRPC service described in
+proto/ssmeb/v1/control.proto is served too, so orchestrators can resolve,
+diff, apply and snapshot the parameters without running a command per call.
+It requires the same token, sent as "authorization: Bearer <token>" metadata.
+Since the token and the values would otherwise go over the network in
+cleartext, it's only served on loopback addresses unless --grpc-cert and
+--grpc-key are given, which serve it over TLS.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if serveRefresh <= 0 {
+			return fmt.Errorf("The refresh interval must be positive, and is %s", serveRefresh)
+		}
+		printSettings("input", inputName(), "environment", environment, "listen", serveListen, "refresh", serveRefresh.String())
+		return runServe(serveListen, serveRefresh, serveToken, serveGRPC, serveCert, serveKey)
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", getEnv("SSMEB_LISTEN", "127.0.0.1:8080"), "address to listen on")
+	serveCmd.Flags().DurationVar(&serveRefresh, "refresh", 5*time.Minute, "interval between refreshes of the values")
+	serveCmd.Flags().StringVar(&serveToken, "token", getEnv("SSMEB_TOKEN", ""), "bearer token required from clients (required)")
+	serveCmd.Flags().StringVar(&serveGRPC, "grpc-listen", getEnv("SSMEB_GRPC_LISTEN", ""), "address to serve the gRPC control API on (defaults to none, must be a loopback address without TLS)")
+	serveCmd.Flags().StringVar(&serveCert, "grpc-cert", getEnv("SSMEB_GRPC_CERT", ""), "PEM certificate serving the gRPC control API over TLS")
+	serveCmd.Flags().StringVar(&serveKey, "grpc-key", getEnv("SSMEB_GRPC_KEY", ""), "PEM private key of the --grpc-cert certificate")
+	rootCmd.AddCommand(serveCmd)
+}
+
+// configServer serves the latest resolved options
+type configServer struct {
+	token string
+
+	// resolving serializes the refreshes and the control calls, since they share the
+	// global flags and resolveSummary
+	resolving sync.Mutex
+
+	mutex   sync.RWMutex
+	options []render.Option
+	updated time.Time
+}
+
+// runServe resolves the parameters every refresh interval and serves them on listen,
+// and the control API on grpcListen if not empty, over TLS with the cert and key files
+// if given
+func runServe(listen string, refresh time.Duration, token string, grpcListen string, cert string, key string) error {
+	if token == "" {
+		return fmt.Errorf("Missing mandatory argument: `token`")
+	}
+	if (cert == "") != (key == "") {
+		return fmt.Errorf("Both `grpc-cert` and `grpc-key` are needed to serve gRPC over TLS")
+	}
+	if grpcListen != "" && cert == "" && !isLoopback(grpcListen) {
+		return fmt.Errorf("The gRPC control API can only be served on a loopback address without TLS, and `%s` isn't one. Give --grpc-cert and --grpc-key to serve it over TLS", grpcListen)
+	}
+
+	server := &configServer{token: token}
+	if err := server.refresh(); err != nil {
+		return err
+	}
+	go func() {
+		for range time.Tick(refresh) {
+			if err := server.refresh(); err != nil {
+				log.Printf("Error refreshing values, serving the previous ones: %v", err)
+			}
+		}
+	}()
+
+	if grpcListen != "" {
+		go func() {
+			log.Fatalf("Error serving gRPC: %v", runControl(grpcListen, cert, key, server))
+		}()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/config", server.handleConfig)
+	mux.HandleFunc("/healthz", server.handleHealth)
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", listen)
+	return http.ListenAndServe(listen, mux)
+}
+
+// refresh resolves the parameters again, replacing the served options on success
+func (s *configServer) refresh() error {
+	s.resolving.Lock()
+	defer s.resolving.Unlock()
+	return s.update()
+}
+
+// update is refresh for callers already holding the resolving lock
+func (s *configServer) update() error {
+	options, err := resolveOptions()
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.options = options
+	s.updated = time.Now()
+	return nil
+}
+
+// handleConfig writes the options as a JSON object, if the request has the right token
+func (s *configServer) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	s.mutex.RLock()
+	data, err := render.JSON(s.options)
+	updated := s.updated
+	s.mutex.RUnlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Last-Modified", updated.UTC().Format(http.TimeFormat))
+	w.Write(data)
+}
+
+// handleHealth reports whether the options were resolved at least once
+func (s *configServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.updated.IsZero() {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintf(w, "ok, last refresh at %s\n", s.updated.UTC().Format(time.RFC3339))
+}
+
+// isLoopback reports whether the host of the listen address is localhost or a loopback
+// IP. An empty host listens on every interface, so it isn't one.
+func isLoopback(listen string) bool {
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
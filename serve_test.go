@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsLoopback(t *testing.T) {
+	tests := []struct {
+		listen string
+		want   bool
+	}{
+		{"127.0.0.1:9090", true},
+		{"127.0.0.2:9090", true},
+		{"[::1]:9090", true},
+		{"localhost:9090", true},
+		{":9090", false},
+		{"0.0.0.0:9090", false},
+		{"10.0.0.1:9090", false},
+		{"example.com:9090", false},
+		{"127.0.0.1", false},
+	}
+	for _, test := range tests {
+		if got := isLoopback(test.listen); got != test.want {
+			t.Errorf("isLoopback(%q) = %v, want %v", test.listen, got, test.want)
+		}
+	}
+}
+
+func TestRunServeRefusesCleartextGRPC(t *testing.T) {
+	tests := []struct {
+		grpcListen, cert, key string
+		err                   string
+	}{
+		{":9090", "", "", "only be served on a loopback address"},
+		{"10.0.0.1:9090", "", "", "only be served on a loopback address"},
+		{"127.0.0.1:9090", "server.pem", "", "Both `grpc-cert` and `grpc-key`"},
+	}
+	for _, test := range tests {
+		err := runServe("127.0.0.1:0", 0, "token", test.grpcListen, test.cert, test.key)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("runServe() with --grpc-listen %s error = %v, want one containing %q", test.grpcListen, err, test.err)
+		}
+	}
+}
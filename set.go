@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/resolver"
+	"github.com/codacy/ssmeb/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	setGitTags        bool
+	setReplicaRegions []string
+	setLabels         []string
+	setDescriptions   bool
+)
+
+var setCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Store the component parameters in SSM, prompting for values missing from the input",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return forEachEnvironment(cmd, func() error {
+			printSettings("input", inputName(), "environment", environment)
+			return runSet()
+		})
+	},
+}
+
+func init() {
+	setCmd.Flags().StringSliceVar(&setReplicaRegions, "replicate-regions", nil, "also write the parameters to these AWS regions, e.g. eu-west-1,us-east-1")
+	setCmd.Flags().StringSliceVar(&setLabels, "label", nil, "attach these labels to the new versions, moving them from the previous ones, e.g. staging")
+	setCmd.Flags().BoolVar(&normalizeValues, "normalize", false, "strip byte order marks from the values and convert their CRLF line endings to LF")
+	setCmd.Flags().BoolVar(&setDescriptions, "descriptions-only", false, "only update the descriptions in SSM that differ from the input, keeping the values")
+	setCmd.Flags().BoolVar(&strictQuota, "strict-quota", false, "fail instead of warning when the new parameters would bring the account close to the quota of standard parameters")
+	setCmd.Flags().BoolVar(&setGitTags, "git-tags", false, "tag the parameters with the commit, branch and author of the git repository in the working directory")
+	addEnvironmentsFlag(setCmd)
+	rootCmd.AddCommand(setCmd)
+}
+
+// runSet sends the component parameters in the input file to SSM
+func runSet() error {
+	parameters, err := loadParameters()
+	if err != nil {
+		return err
+	}
+	parameters, err = expandPlaceholders(parameters)
+	if err != nil {
+		return err
+	}
+	if setDescriptions {
+		if !canDescribe() || len(setReplicaRegions) > 0 {
+			return fmt.Errorf("Descriptions can only be updated with the `ssm` backend, in a single region")
+		}
+		return syncDescriptions(ssm.New(newSession()), parameters)
+	}
+
+	var tags map[string]string
+	if setGitTags {
+		tags, err = gitProvenance()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(setReplicaRegions) > 0 {
+		return replicateParameters(parameters, tags, setLabels, setReplicaRegions)
+	}
+	if canDescribe() {
+		if err := checkQuota(ssm.New(newSession()), parameters); err != nil {
+			return err
+		}
+	}
+	s, err := newStore()
+	if err != nil {
+		return err
+	}
+	err = setParameters(s, parameters, tags, setLabels)
+	if err != nil {
+		return fmt.Errorf("Error setting values: %v", err)
+	}
+	return nil
+}
+
+// replicateParameters sends the component parameters into the store of the configured
+// region and the replica ones, asking the user for the missing values only once
+func replicateParameters(parameters config.Parameters, tags map[string]string, labels []string, replicas []string) error {
+	if name, _ := splitBackend(backend); name != "ssm" || offline {
+		return fmt.Errorf("Regions can only be replicated with the `ssm` backend")
+	}
+	regions := []string{aws.StringValue(newSession().Config.Region)}
+	if regions[0] == "" {
+		return fmt.Errorf("Missing AWS region, the parameters are replicated from it")
+	}
+	seen := map[string]bool{regions[0]: true}
+	for _, region := range replicas {
+		if !seen[region] {
+			seen[region] = true
+			regions = append(regions, region)
+		}
+	}
+
+	for _, region := range regions {
+		if err := checkQuota(ssm.New(newSessionIn(region)), parameters); err != nil {
+			return fmt.Errorf("Region `%s`: %v", region, err)
+		}
+	}
+
+	// the values kept at the prompt are the ones in the configured region, copied to the replicas
+	primary, err := newStoreIn(regions[0])
+	if err != nil {
+		return err
+	}
+	component := make([]config.Parameter, len(parameters.Component))
+	for i, par := range parameters.Component {
+		if par.Value == "" && !par.IsWildcard() {
+			current, found, err := store.Lookup(primary, par.Path)
+			if err != nil {
+				return fmt.Errorf("Error getting `%s`: %v", par.Path, err)
+			}
+			par.Value, err = promptValue(par.Path, current, found)
+			if err != nil {
+				return err
+			}
+		}
+		component[i] = par
+	}
+	parameters.Component = component
+
+	for _, region := range regions {
+		fmt.Printf("* Region `%s`\n", region)
+		s, err := newStoreIn(region)
+		if err != nil {
+			return err
+		}
+		err = setParameters(s, parameters, tags, labels)
+		if err != nil {
+			return fmt.Errorf("Error setting values in region `%s`: %v", region, err)
+		}
+	}
+	return nil
+}
+
+// setParameters sends the component parameters into the store with the given tags and
+// labels, asking the user for the values that are not present in the input. It ends printing
+// how many were set, skipped and failed.
+func setParameters(s store.Store, parameters config.Parameters, tags map[string]string, labels []string) error {
+	var summary resolver.Summary
+	defer func() { printSummary(summary) }()
+	for _, par := range parameters.Component {
+		if par.IsWildcard() {
+			fmt.Printf("* Skipping `%s`, wildcard paths can't be set\n", par.Path)
+			summary.Skipped++
+			continue
+		}
+		value := par.Value
+		// parameters without a type keep the one they have in the store, so they're looked up too
+		var current store.Parameter
+		var found bool
+		var err error
+		if value == "" || par.Type == "" {
+			current, found, err = store.Lookup(s, par.Path)
+			if err != nil && par.Type == "" {
+				summary.Failed++
+				return fmt.Errorf("Error getting `%s` to keep its type, declare its `type` in the input: %v", par.Path, err)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: couldn't get the current value of `%s`: %v\n", par.Path, err)
+			}
+		}
+		if value == "" {
+			value, err = promptValue(par.Path, current, found)
+			if err != nil {
+				summary.Failed++
+				return err
+			}
+			if found && value == current.Value {
+				fmt.Println("  Kept the current value")
+				summary.Skipped++
+				continue
+			}
+		} else {
+			fmt.Printf("* Setting value for `%s`...\n", par.Path)
+		}
+		value, err = checkEncoding(par.Path, value)
+		if err != nil {
+			summary.Failed++
+			return err
+		}
+
+		secret := par.Type == config.TypeSecureString || par.Type == "" && found && current.Secret
+		version, err := s.Put(store.Parameter{Path: par.Path, Value: value, Description: par.Description, Secret: secret, Tags: tags, Labels: labels})
+		if err != nil {
+			summary.Failed++
+			return err
+		}
+		fmt.Printf("  OK (version %d)\n", version)
+		summary.OK++
+	}
+	return nil
+}
+
+// invalidTagCharacters matches the characters not allowed in the values of SSM tags
+var invalidTagCharacters = regexp.MustCompile(`[^\p{L}\p{Z}\p{N}_.:/=+\-@]`)
+
+// gitProvenance returns the tags tracing the parameters back to the current commit of
+// the git repository in the working directory
+func gitProvenance() (map[string]string, error) {
+	fields := []struct{ tag, command string }{
+		{"ssmeb:git-commit", "rev-parse HEAD"},
+		{"ssmeb:git-branch", "rev-parse --abbrev-ref HEAD"},
+		{"ssmeb:git-author", "log -1 --format=%ae"},
+	}
+	tags := map[string]string{}
+	for _, field := range fields {
+		out, err := exec.Command("git", strings.Fields(field.command)...).Output()
+		if err != nil {
+			return nil, fmt.Errorf("Error running `git %s`, the working directory must be in a git repository: %v", field.command, err)
+		}
+		value := invalidTagCharacters.ReplaceAllString(strings.TrimSpace(string(out)), "_")
+		if len(value) > 256 {
+			value = value[:256]
+		}
+		tags[field.tag] = value
+	}
+	return tags, nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/ssmstore"
+	"github.com/codacy/ssmeb/pkg/ssmstore/ssmfake"
+	"github.com/codacy/ssmeb/pkg/store"
+)
+
+func TestSetParameters(t *testing.T) {
+	client := ssmfake.New()
+	client.Set("/staging/db/host", "old.internal")
+	parameters := config.Parameters{
+		Component: []config.Parameter{
+			{Name: "DB_HOST", Path: "/staging/db/host", Value: "db.internal", Description: "database host"},
+			{Name: "DB_PASSWORD", Path: "/staging/db/password", Value: "s3cret", Type: config.TypeSecureString},
+			{Name: "FLAG_{leaf}", Path: "/staging/flags/*"},
+		},
+	}
+
+	tags := map[string]string{"ssmeb:git-commit": "abc123"}
+	if err := setParameters(ssmstore.New(client), parameters, tags, []string{"staging"}); err != nil {
+		t.Fatalf("setParameters() error = %v", err)
+	}
+
+	for path, want := range map[string]string{"/staging/db/host": "db.internal", "/staging/db/password": "s3cret"} {
+		if value, _ := client.Value(path); value != want {
+			t.Errorf("value of %s = %q, want %q", path, value, want)
+		}
+		if got := client.Tags(path); !reflect.DeepEqual(got, tags) {
+			t.Errorf("tags of %s = %v, want %v", path, got, tags)
+		}
+	}
+	if labels := client.Labels("/staging/db/host"); labels["staging"] != 2 {
+		t.Errorf("labels of /staging/db/host = %v, want staging on version 2", labels)
+	}
+	got, err := client.GetParameter(&ssm.GetParameterInput{Name: aws.String("/staging/db/password")})
+	if err != nil || aws.StringValue(got.Parameter.Type) != ssm.ParameterTypeSecureString {
+		t.Errorf("/staging/db/password = %v (%v), want a SecureString", got, err)
+	}
+	if _, found := client.Value("/staging/flags/*"); found {
+		t.Errorf("the wildcard parameter was set, it should be skipped")
+	}
+}
+
+func TestSetParametersKeepsSecureString(t *testing.T) {
+	client := ssmfake.New()
+	client.PutParameter(&ssm.PutParameterInput{
+		Name:  aws.String("/staging/db/password"),
+		Value: aws.String("old"),
+		Type:  aws.String(ssm.ParameterTypeSecureString),
+		KeyId: aws.String("alias/myapp"),
+	})
+	parameters := config.Parameters{
+		Component: []config.Parameter{{Name: "DB_PASSWORD", Path: "/staging/db/password", Value: "new"}},
+	}
+	if err := setParameters(ssmstore.New(client), parameters, nil, nil); err != nil {
+		t.Fatalf("setParameters() error = %v", err)
+	}
+
+	got, err := client.GetParameter(&ssm.GetParameterInput{Name: aws.String("/staging/db/password"), WithDecryption: aws.Bool(true)})
+	if err != nil || aws.StringValue(got.Parameter.Type) != ssm.ParameterTypeSecureString || aws.StringValue(got.Parameter.Value) != "new" {
+		t.Errorf("/staging/db/password = %v (%v), want the SecureString new", got, err)
+	}
+	if keyID := client.KeyID("/staging/db/password"); keyID != "alias/myapp" {
+		t.Errorf("key of /staging/db/password = %q, want alias/myapp kept", keyID)
+	}
+
+	// declaring the type still turns it into a String
+	parameters.Component[0].Type = config.TypeString
+	if err := setParameters(ssmstore.New(client), parameters, nil, nil); err != nil {
+		t.Fatalf("setParameters() error = %v", err)
+	}
+	got, err = client.GetParameter(&ssm.GetParameterInput{Name: aws.String("/staging/db/password")})
+	if err != nil || aws.StringValue(got.Parameter.Type) != ssm.ParameterTypeString {
+		t.Errorf("/staging/db/password = %v (%v), want a String", got, err)
+	}
+}
+
+// unreadableStore is a store failing to get any parameter
+type unreadableStore struct {
+	store.Store
+}
+
+func (s unreadableStore) Get(path string) (store.Parameter, error) {
+	return store.Parameter{}, errors.New("access denied")
+}
+
+func TestSetParametersUnknownType(t *testing.T) {
+	client := ssmfake.New()
+	parameters := config.Parameters{
+		Component: []config.Parameter{{Name: "DB_PASSWORD", Path: "/staging/db/password", Value: "new"}},
+	}
+	err := setParameters(unreadableStore{ssmstore.New(client)}, parameters, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "declare its `type`") {
+		t.Errorf("setParameters() error = %v, want one asking for the type", err)
+	}
+	if _, found := client.Value("/staging/db/password"); found {
+		t.Errorf("/staging/db/password was set without knowing its type")
+	}
+
+	parameters.Component[0].Type = config.TypeSecureString
+	if err := setParameters(unreadableStore{ssmstore.New(client)}, parameters, nil, nil); err != nil {
+		t.Errorf("setParameters() with a declared type error = %v", err)
+	}
+}
+
+func TestSetParametersInvalidEncoding(t *testing.T) {
+	client := ssmfake.New()
+	parameters := config.Parameters{
+		Component: []config.Parameter{{Name: "BINARY", Path: "/staging/binary", Value: "\xff\xfe"}},
+	}
+	if err := setParameters(ssmstore.New(client), parameters, nil, nil); err == nil {
+		t.Errorf("setParameters() error = nil, want an error for the invalid UTF-8")
+	}
+	if _, found := client.Value("/staging/binary"); found {
+		t.Errorf("the invalid value was set")
+	}
+}
+
+func TestDiffParameters(t *testing.T) {
+	client := ssmfake.New()
+	client.Set("/staging/db/host", "db.internal")
+	client.Set("/staging/db/port", "5432")
+	client.Set("/shared/queue", "https://sqs/queue")
+	parameters := config.Parameters{
+		Component: []config.Parameter{
+			{Name: "DB_HOST", Path: "/staging/db/host", Value: "db.internal"},
+			{Name: "DB_PORT", Path: "/staging/db/port", Value: "5433"},
+			{Name: "DB_NAME", Path: "/staging/db/name"},
+			{Name: "UNSET", Path: "/staging/unset", Value: "anything"},
+		},
+		External: []config.Parameter{
+			{Name: "QUEUE_URL", Path: "/shared/queue", Value: "ignored, external values aren't compared"},
+			{Name: "TOPIC_ARN", Path: "/shared/topic"},
+		},
+	}
+
+	s := ssmstore.New(client)
+	differences, err := parameterDifferences(s, parameters)
+	if err != nil {
+		t.Fatalf("parameterDifferences() error = %v", err)
+	}
+	want := []parameterDifference{
+		{Name: "DB_PORT", Path: "/staging/db/port", Stored: "5432", Wanted: "5433"},
+		{Name: "DB_NAME", Path: "/staging/db/name", Missing: true},
+		{Name: "UNSET", Path: "/staging/unset", Missing: true},
+		{Name: "TOPIC_ARN", Path: "/shared/topic", Missing: true},
+	}
+	if !reflect.DeepEqual(differences, want) {
+		t.Errorf("parameterDifferences() = %+v, want %+v", differences, want)
+	}
+
+	count, err := diffParameters(s, parameters, false)
+	if err != nil || count != len(want) {
+		t.Errorf("diffParameters() = %d, %v, want %d differences", count, err, len(want))
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	setOneStdin  bool
+	setOneFile   string
+	setOneEditor bool
+)
+
+var setOneCmd = &cobra.Command{
+	Use:   "set-one <option_name>",
+	Short: "Store a single component parameter in SSM, reading its value securely",
+	Long: `Store a single component parameter in SSM, reading its value securely.
+
+Only the parameter named is written, so small fixes don't risk touching any
+other one. The name is the option_name in the input, or the one given by its
+naming. The value is read from stdin with --stdin, without its trailing
+newline, or asked for without echoing it. The value in the input, if any, is
+ignored.
+
+Multiline values, like certificates, can be read from stdin, taken as is from
+the file given in --file, or written in the editor in $VISUAL or $EDITOR with
+--editor, without the trailing newline added by the editor.`,
+	Example: `  ssmeb set-one -i params.yaml -e production DB_PASSWORD
+  pass show db/production | ssmeb set-one -i params.yaml -e production DB_PASSWORD --stdin
+  ssmeb set-one -i params.yaml -e production TLS_CERT --file cert.pem`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("input", inputName(), "environment", environment, "option", args[0])
+		return runSetOne(args[0])
+	},
+}
+
+func init() {
+	setOneCmd.Flags().BoolVar(&setOneStdin, "stdin", false, "read the value from stdin instead of asking for it")
+	setOneCmd.Flags().StringVar(&setOneFile, "file", "", "use the contents of the file as the value instead of asking for it")
+	setOneCmd.Flags().BoolVar(&setOneEditor, "editor", false, "write the value in the editor instead of typing it at the prompt")
+	setOneCmd.Flags().BoolVar(&normalizeValues, "normalize", false, "strip the byte order mark from the value and convert its CRLF line endings to LF")
+	rootCmd.AddCommand(setOneCmd)
+}
+
+// runSetOne stores the value read for the component parameter with the given name
+func runSetOne(name string) error {
+	parameters, err := loadParameters()
+	if err != nil {
+		return err
+	}
+	parameters, err = expandPlaceholders(parameters)
+	if err != nil {
+		return err
+	}
+
+	var par config.Parameter
+	for _, candidate := range parameters.Component {
+		if !candidate.IsWildcard() && (candidate.Name == name || parameters.Naming.Apply(candidate.Name) == name) {
+			par = candidate
+			break
+		}
+	}
+	if par.Name == "" {
+		return fmt.Errorf("Unknown component option `%s`, only the component parameters can be set", name)
+	}
+
+	switch {
+	case setOneStdin && (setOneFile != "" || setOneEditor) || setOneFile != "" && setOneEditor:
+		return fmt.Errorf("Only one of --stdin, --file and --editor can be given")
+	case setOneStdin:
+		data, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("Error reading stdin: %v", err)
+		}
+		par.Value = strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+	case setOneFile != "":
+		data, err := ioutil.ReadFile(setOneFile)
+		if err != nil {
+			return fmt.Errorf("Error reading file `%s`: %v", setOneFile, err)
+		}
+		par.Value = string(data)
+	case setOneEditor:
+		par.Value, err = editValue(par.Path, "")
+		if err != nil {
+			return err
+		}
+	default:
+		par.Value, err = readSecret(fmt.Sprintf("* Input value for `%s` (not echoed): ", par.Path))
+		if err != nil {
+			return err
+		}
+	}
+	if par.Value == "" {
+		return fmt.Errorf("Empty value for `%s`, nothing was set", name)
+	}
+
+	s, err := newStore()
+	if err != nil {
+		return err
+	}
+	parameters.Component = []config.Parameter{par}
+	if err := setParameters(s, parameters, nil, nil); err != nil {
+		return fmt.Errorf("Error setting values: %v", err)
+	}
+	return nil
+}
+
+// readSecret prints the prompt and reads a line from stdin, turning off its echo
+// while typing when it's a terminal
+func readSecret(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		if stty("-echo") == nil {
+			defer func() {
+				stty("echo")
+				fmt.Fprintln(os.Stderr)
+			}()
+		}
+	}
+	text, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && (err != io.EOF || text == "") {
+		return "", err
+	}
+	return strings.TrimRight(text, "\r\n"), nil
+}
+
+// stty changes the settings of the terminal in stdin
+func stty(setting string) error {
+	cmd := exec.Command("stty", setting)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
+
+// editValue opens the text in the editor given in $VISUAL or $EDITOR, vi by default,
+// returning the edited text without the trailing newline added by most editors. The
+// temporary file is named after the path, and removed afterwards.
+func editValue(path string, text string) (string, error) {
+	file, err := ioutil.TempFile("", "ssmeb-"+strings.Replace(strings.Trim(path, "/"), "/", "-", -1)+"-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(text); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// the editor may have arguments, e.g. `code --wait`
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", file.Name())
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("Error running the editor `%s`: %v", editor, err)
+	}
+	data, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r"), nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/codacy/ssmeb/pkg/snapshot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	snapshotOutput string
+	snapshotKMSKey string
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Record the current values of the parameters in a snapshot file",
+	Long: `Record the current values of the parameters in a snapshot file.
+
+The snapshot holds the values in plain text, so the file is only readable by
+its owner, unless --kms-key encrypts it with a data key generated by that KMS
+key. It's used as the baseline of the drift command and by the offline mode,
+which decrypt it with KMS as needed.`,
+	Example: `  ssmeb snapshot -i params.yaml -e production -o production.snapshot --kms-key alias/snapshots`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("input", inputName(), "output", snapshotOutput, "environment", environment)
+		return runSnapshot(snapshotOutput)
+	},
+}
+
+func init() {
+	snapshotCmd.Flags().StringVarP(&snapshotOutput, "output", "o", "", "destination of the snapshot (required)")
+	snapshotCmd.Flags().StringVar(&snapshotKMSKey, "kms-key", getEnv("SSMEB_SNAPSHOT_KMS_KEY", ""), "id, ARN or alias of the KMS key encrypting the snapshot (defaults to plain text)")
+	rootCmd.AddCommand(snapshotCmd)
+}
+
+// runSnapshot resolves the parameters and saves their values to output
+func runSnapshot(output string) error {
+	if output == "" {
+		return fmt.Errorf("Missing mandatory argument: `output`")
+	}
+
+	values, err := resolveValues()
+	if err != nil {
+		return err
+	}
+	recorded := snapshot.New(environment, values)
+	// left zero when reproducible mode records no time
+	recorded.Created, _, err = generationTime()
+	if err != nil {
+		return err
+	}
+	if snapshotKMSKey != "" {
+		err = recorded.WriteEncryptedFile(output, kms.New(newSession()), snapshotKMSKey)
+	} else {
+		err = recorded.WriteFile(output)
+	}
+	if err != nil {
+		return fmt.Errorf("Error writing to file `%s`: %v", output, err)
+	}
+	fmt.Fprintf(os.Stderr, "%d values saved to `%s`\n", len(values), output)
+	return nil
+}
@@ -2,230 +2,626 @@ package main
 
 import (
 	"bufio"
-	"flag"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
-	ssm "github.com/aws/aws-sdk-go/service/ssm"
-	yaml "gopkg.in/yaml.v2"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/codacy/ssmeb/pkg/cachestore"
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/render"
+	"github.com/codacy/ssmeb/pkg/resolver"
+	"github.com/codacy/ssmeb/pkg/s3io"
+	"github.com/codacy/ssmeb/pkg/snapshot"
+	"github.com/codacy/ssmeb/pkg/store"
+	"github.com/codacy/ssmeb/pkg/stores"
+	"github.com/spf13/cobra"
 )
 
-// parameters is the type this program expects its input file to have.
-type parameters struct {
-	// Component holds parameters owned by this app
-	Component []parameter `yaml:"component"`
-	// External holds parameters external to this app. They can't be set.
-	External []parameter `yaml:"external"`
-}
-
-// parameter holds info about an ssm parameter
-type parameter struct {
-	// Name is the name of environment variable stored in the ssm parameter (required)
-	Name string `yaml:"option_name"`
-	// Description is an optional string describing parameter
-	Description string `yaml:"description"`
-	// Path is key for the parameter on the Systems Manager
-	Path string `yaml:"path"`
-	// Value is the value stored on the Systems Manager. This is optional but useful when using the set mode
-	Value string `yaml:"value"`
-}
+// Flags shared by every subcommand
+var (
+	inputs         []string
+	inputFormat    string
+	environment    string
+	backend        string
+	cacheDir       string
+	cacheTTL       time.Duration
+	offline        bool
+	snapshotIn     string
+	reproducible   bool
+	only           []string
+	except         []string
+	preflightCheck bool
+)
 
-// ebOptionSettings is the output format of this program, which conforms with
-// the format used for elastic beanstalk extensions
-type ebOptionSettings struct {
-	Options []ebOption `yaml:"option_settings"`
+var rootCmd = &cobra.Command{
+	Use:           "ssmeb",
+	Short:         "A simple tool to get ssm parameters to an .ebextensions file",
+	SilenceUsage:  true,
+	SilenceErrors: true,
 }
 
-// ebOption hold info about a beanstalk option
-type ebOption struct {
-	// Name is the option name
-	Name string `yaml:"option_name"`
-	// Value is the option value
-	Value string `yaml:"value"`
+func init() {
+	var defaultInputs []string
+	if input := getEnv("SSMEB_INPUT", ""); input != "" {
+		defaultInputs = []string{input}
+	}
+	rootCmd.PersistentFlags().StringArrayVarP(&inputs, "input", "i", defaultInputs, "input template environment variables config, an s3:// or https:// url, or - to read stdin, can be repeated or a glob to merge several files")
+	rootCmd.PersistentFlags().StringVar(&inputFormat, "input-format", "", "format of the input files, yaml, json, hcl, cue or jsonnet (detected by default, hcl, cue and jsonnet by the extension)")
+	rootCmd.PersistentFlags().StringVarP(&environment, "environment", "e", getEnv("SSMEB_ENVIRONMENT", ""), "environment name used as prefix for the ssm parameters (e.g. codacy)")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "resolve the values from --snapshot instead of the backend")
+	rootCmd.PersistentFlags().StringVar(&snapshotIn, "snapshot", getEnv("SSMEB_SNAPSHOT", ""), "snapshot file, used in offline mode and as baseline of drift")
+	rootCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", getEnv("SSMEB_CACHE_DIR", defaultCacheDir()), "directory caching the values got from the store")
+	rootCmd.PersistentFlags().DurationVar(&cacheTTL, "cache-ttl", getEnvDuration("SSMEB_CACHE_TTL", 0), "how long values are cached, e.g. 10m (disabled by default)")
+	rootCmd.PersistentFlags().BoolVar(&reproducible, "reproducible", false, "leave out timestamps, or use SOURCE_DATE_EPOCH, so the same inputs and values give identical files")
+	rootCmd.PersistentFlags().StringSliceVar(&only, "only", nil, "only use the options with these names, which can be globs like DB_*")
+	rootCmd.PersistentFlags().StringSliceVar(&except, "except", nil, "leave out the options with these names, which can be globs like DB_*")
+	rootCmd.PersistentFlags().BoolVar(&preflightCheck, "preflight", false, "check that every required parameter exists in SSM before getting any value, reporting all the missing ones")
+	rootCmd.PersistentFlags().Float64Var(&maxTPS, "max-tps", 0, "most SSM calls made per second, to leave throughput to other users of the account (unlimited by default)")
+	rootCmd.PersistentFlags().BoolVar(&useFIPS, "fips", os.Getenv("AWS_USE_FIPS_ENDPOINT") == "true", "use the FIPS endpoints of the AWS services, as required in some GovCloud workloads")
+	rootCmd.PersistentFlags().StringVar(&webIdentityRoleARN, "web-identity-role-arn", "", "role assumed with the web identity token in AWS_WEB_IDENTITY_TOKEN_FILE, e.g. with IRSA in EKS (defaults to AWS_ROLE_ARN when the token file is set)")
+	rootCmd.PersistentFlags().StringVar(&componentName, "component", getEnv("SSMEB_COMPONENT", ""), "component of the input to use, when it has a components section")
+	rootCmd.PersistentFlags().StringVar(&overridesFile, "overrides", getEnv("SSMEB_OVERRIDES", ""), "yaml file mapping option names to values used instead of the ones in the store, e.g. to point them at local services")
+	rootCmd.PersistentFlags().StringVar(&backend, "backend", getEnv("SSMEB_BACKEND", "ssm"), "store holding the parameters: `ssm`, azurekeyvault:<vault url>, gcpsecretmanager:<project> or file:<json file>")
 }
 
 func main() {
+	args := os.Args[1:]
 
-	// read and parse input
-	var input string
-	flag.StringVar(&input, "input", "", "input template environment variables config")
-	flag.StringVar(&input, "i", "", "`input` flag shorthand")
+	var err error
+	if isLegacyInvocation(args) {
+		err = runLegacy(args)
+	} else {
+		err = rootCmd.Execute()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
 
-	var output string
-	flag.StringVar(&output, "output", "", "destination of the resulting elastic beanstalk data")
-	flag.StringVar(&output, "o", "", "`output` flag shorthand")
+// loadParameters reads the input files given in the shared flags, failing if none was
+// provided, applies the environment and keeps the parameters selected by the only and except flags
+func loadParameters() (config.Parameters, error) {
+	return loadParametersIn(environment)
+}
 
-	var environment string
-	flag.StringVar(&environment, "environment", "", "environment name used as prefix for the ssm parameters (e.g. codacy)")
-	flag.StringVar(&environment, "e", "", "`environment` flag shorthand")
+// loadParametersIn is like loadParameters, applying the given environment instead
+func loadParametersIn(environment string) (config.Parameters, error) {
+	parameters, err := readComponentParameters()
+	if err != nil {
+		return parameters, err
+	}
+	parameters, err = parameters.WithEnvironment(environment).Select(only, except)
+	if err != nil {
+		return parameters, fmt.Errorf("Error selecting parameters: %v", err)
+	}
+	return parameters, nil
+}
 
-	var mode string
-	flag.StringVar(&mode, "mode", "get", "enable set or get mode")
-	flag.StringVar(&mode, "m", "get", "`mode` flag shorthand")
+// expandPlaceholders replaces the placeholders of the parameters that depend on AWS,
+// only contacting it when they are used
+func expandPlaceholders(parameters config.Parameters) (config.Parameters, error) {
+	if parameters.Uses(config.PlaceholderEnvironment) {
+		return parameters, fmt.Errorf("Missing mandatory argument: `environment`, used as placeholder in the input")
+	}
+	return stores.ExpandPlaceholders(newSession(), parameters)
+}
 
-	flag.Parse()
-	if input == "" {
-		log.Fatal("Missing mandatory argument: `input`")
+// readParameters reads the input files given in the shared flags as written, merging them,
+// without applying the environment nor the only and except flags
+func readParameters() (config.Parameters, error) {
+	filenames, err := inputFiles()
+	if err != nil {
+		return config.Parameters{}, err
 	}
 
-	fmt.Fprintln(os.Stderr, "-----------------------------------------")
-	fmt.Fprintln(os.Stderr, "input:       ", input)
-	fmt.Fprintln(os.Stderr, "output:      ", output)
-	fmt.Fprintln(os.Stderr, "environment: ", environment)
-	fmt.Fprintln(os.Stderr, "mode:        ", mode)
-	fmt.Fprintln(os.Stderr, "-----------------------------------------")
+	var files []config.Parameters
+	for _, filename := range filenames {
+		parameters, err := readInputFile(filename)
+		if err != nil {
+			return config.Parameters{}, err
+		}
+		files = append(files, parameters)
+	}
 
-	parameters, err := readParametersFile(input, environment)
+	parameters, err := config.Merge(files...)
 	if err != nil {
-		log.Fatalf("Error reading file `%s`: %v", input, err)
+		return parameters, fmt.Errorf("Error merging `%s`: %v", inputName(), err)
 	}
+	return parameters, nil
+}
 
-	session := session.Must(session.NewSessionWithOptions(session.Options{
-		SharedConfigState: session.SharedConfigEnable,
-	}))
-
-	if mode == "get" {
-		ebOptions, err := getBeanstalkOptions(session, parameters)
-		if err != nil {
-			log.Fatalf("Error getting values: %v", err)
+// readInputFile reads a single input file as written, with the files it includes
+func readInputFile(filename string) (config.Parameters, error) {
+	var parameters config.Parameters
+	var err error
+	if filename == stdinInput {
+		var data []byte
+		data, err = readInput(filename)
+		if err == nil {
+			parameters, err = config.LoadData(data, ".", inputFormat)
+		}
+	} else if isRemoteInput(filename) {
+		var data []byte
+		data, err = readInput(filename)
+		if err == nil {
+			parameters, err = config.ParseFormat(data, inputFormat)
 		}
+		if err == nil && len(parameters.Include) > 0 {
+			err = fmt.Errorf("include is not supported in remote inputs")
+		}
+	} else {
+		parameters, err = config.Load(filename, inputFormat)
+	}
+	if err != nil {
+		return config.Parameters{}, fmt.Errorf("Error reading file `%s`: %v", filename, err)
+	}
+	return parameters, nil
+}
+
+// stdinInput is the name of the input read from stdin
+const stdinInput = "-"
 
-		ebYaml, err := yaml.Marshal(ebOptions)
+// stdinData holds the input read from stdin, since it can only be read once
+var stdinData []byte
+
+// remoteInputTimeout is how long fetching a remote input may take
+const remoteInputTimeout = 30 * time.Second
+
+// readInput returns the contents of the input file with name filename, of stdin if
+// it's stdinInput, or of the object or page at its url if it's a remote input
+func readInput(filename string) ([]byte, error) {
+	if isRemoteInput(filename) {
+		// fetched every time, so long running commands see the updates
+		return fetchInput(filename)
+	}
+	if filename != stdinInput {
+		return ioutil.ReadFile(filename)
+	}
+	if stdinData == nil {
+		data, err := ioutil.ReadAll(os.Stdin)
 		if err != nil {
-			log.Fatalf("Error marshaling beanstalk options: %v", err)
+			return nil, err
 		}
-		if output == "" {
-			fmt.Println(string(ebYaml))
-		} else {
-			err = writeToFile(output, ebYaml)
-			if err != nil {
-				log.Fatalf("Error writing to file `%s`", output)
-			}
+		stdinData = data
+	}
+	return stdinData, nil
+}
+
+// isRemoteInput reports whether the input is an s3://bucket/key or https:// url
+func isRemoteInput(input string) bool {
+	return s3io.IsURL(input) || strings.HasPrefix(input, "https://")
+}
+
+// fetchInput downloads the remote input at url, from S3 with the AWS credentials or
+// over HTTPS without any
+func fetchInput(url string) ([]byte, error) {
+	if s3io.IsURL(url) {
+		return s3io.Read(s3.New(newSession()), url)
+	}
+	client := &http.Client{Timeout: remoteInputTimeout}
+	response, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response %s", response.Status)
+	}
+	return ioutil.ReadAll(response.Body)
+}
+
+// inputFiles returns the names of the input files given in the shared flags, expanding
+// globs, failing if none was provided
+func inputFiles() ([]string, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("Missing mandatory argument: `input`")
+	}
+	var filenames []string
+	for _, input := range inputs {
+		if isRemoteInput(input) || !strings.ContainsAny(input, "*?[") {
+			filenames = append(filenames, input)
+			continue
 		}
-	} else if mode == "set" {
-		err := setBeanstalkOptions(session, parameters)
+		matches, err := filepath.Glob(input)
 		if err != nil {
-			log.Fatalf("Error setting values: %v", err)
+			return nil, fmt.Errorf("Invalid input `%s`: %v", input, err)
 		}
-	} else {
-		log.Fatalf("Invalid mode: %s", mode)
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("No file matches input `%s`", input)
+		}
+		filenames = append(filenames, matches...)
 	}
+	return filenames, nil
+}
 
+// inputName describes the input files given in the shared flags, for messages
+func inputName() string {
+	return strings.Join(inputs, ", ")
 }
 
-// readParametersFile reads parameter from a file with name filename, and prepends `/environment`
-// to its path if the environment is not an empty string
-func readParametersFile(filename string, environment string) (parameters, error) {
-	var parameters parameters
-	inputFile, err := ioutil.ReadFile(filename)
+// resolveOptions reads the input file and gets the value of each parameter from the store
+func resolveOptions() ([]render.Option, error) {
+	values, err := resolveValues()
 	if err != nil {
-		return parameters, err
+		return nil, err
 	}
+	return resolver.Options(values), nil
+}
+
+// resolveSummary counts the outcome of the parameters in the last call to resolveValues
+var resolveSummary resolver.Summary
 
-	err = yaml.Unmarshal(inputFile, &parameters)
+// printSummary prints the counts of parameters by outcome at the end of a run
+func printSummary(summary resolver.Summary) {
+	printSettings(
+		"ok", strconv.Itoa(summary.OK),
+		"skipped", strconv.Itoa(summary.Skipped),
+		"defaulted", strconv.Itoa(summary.Defaulted),
+		"failed", strconv.Itoa(summary.Failed))
+}
+
+// resolveValues reads the input file and gets each parameter from the store
+func resolveValues() ([]resolver.Value, error) {
+	parameters, err := loadParameters()
 	if err != nil {
-		return parameters, err
+		return nil, err
 	}
+	return resolveParameters(parameters)
+}
 
-	if environment != "" {
-		for i, par := range parameters.Component {
-			parameters.Component[i].Path = "/" + environment + par.Path
-		}
-		for i, par := range parameters.External {
-			parameters.External[i].Path = "/" + environment + par.Path
+// resolveParameters gets each of the parameters from the store
+func resolveParameters(parameters config.Parameters) ([]resolver.Value, error) {
+	parameters, err := expandPlaceholders(parameters)
+	if err != nil {
+		return nil, err
+	}
+	if err := preflight(parameters); err != nil {
+		return nil, err
+	}
+	parameters = withLabel(parameters, getLabel)
+	overrides, err := readOverrides(parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := newStore()
+	if err != nil {
+		return nil, err
+	}
+	r := resolver.New(withSharedValues(withOverrides(s, overrides), ""))
+	if !offline {
+		storeFor := credentialStores()
+		r.StoreFor = func(par config.Parameter) (store.Store, error) {
+			s, err := storeFor(par)
+			if err != nil {
+				return nil, err
+			}
+			return withSharedValues(withOverrides(s, overrides), par.Credentials()), nil
 		}
 	}
+	r.Progress = os.Stderr
+	r.KeepGoing = keepGoing
+	r.SkipMissingExternal = skipMissing
+	values, err := r.Resolve(parameters)
+	resolveSummary = r.Summary
+	if err != nil {
+		return nil, fmt.Errorf("Error getting values: %v", err)
+	}
+	return values, nil
+}
 
-	return parameters, nil
+// newSession creates an AWS session using the shared config (e.g. ~/.aws/config)
+func newSession() *session.Session {
+	return newSessionIn("")
 }
 
-// getBeanstalkOptions converts the parameters into ebOptionSettings, by getting the data
-// for each one from SSM using a client created from the provided session.
-func getBeanstalkOptions(session *session.Session, parameters parameters) (ebOptionSettings, error) {
-	ssmClient := ssm.New(session)
+// newSessionIn is like newSession, in the given region instead of the configured one if not empty
+func newSessionIn(region string) *session.Session {
+	options := session.Options{SharedConfigState: session.SharedConfigEnable}
+	if region != "" {
+		options.Config.Region = aws.String(region)
+	}
+	configureEndpoints(&options)
+	configureWebIdentity(&options)
+	return limitSSM(session.Must(session.NewSessionWithOptions(options)))
+}
 
-	var eb ebOptionSettings
+// newSessionAs is like newSession, using the profile of the shared config if not empty and
+// then assuming the role if not empty
+func newSessionAs(profile string, roleARN string) *session.Session {
+	options := session.Options{SharedConfigState: session.SharedConfigEnable, Profile: profile}
+	configureEndpoints(&options)
+	configureWebIdentity(&options)
+	s := limitSSM(session.Must(session.NewSessionWithOptions(options)))
+	if roleARN == "" {
+		return s
+	}
+	return s.Copy(&aws.Config{Credentials: stscreds.NewCredentials(s, roleARN)})
+}
 
-	for _, par := range parameters.Component {
-		fmt.Fprintf(os.Stderr, "* Getting `%s` from path `%s`... ", par.Name, par.Path)
-		parOutput, err := ssmClient.GetParameter(&ssm.GetParameterInput{Name: &par.Path})
-		if err != nil {
-			return eb, err
-		}
-		eb.Options = append(eb.Options, ebOption{Name: par.Name, Value: *parOutput.Parameter.Value})
-		fmt.Fprintln(os.Stderr, "OK")
+// newStore creates the store holding the parameter values, which is the one selected in the
+// backend flag, except for paths prefixed with another source (e.g. `secretsmanager://`)
+func newStore() (store.Store, error) {
+	return newStoreIn("")
+}
+
+// newStoreIn is like newStore, with the AWS stores in the given region instead of the
+// configured one if not empty
+func newStoreIn(region string) (store.Store, error) {
+	if offline {
+		return newSnapshotStore()
 	}
+	return newStoreWith(newSessionIn(region), "")
+}
 
-	for _, par := range parameters.External {
-		fmt.Fprintf(os.Stderr, "* Getting `%s` from path `%s`... ", par.Name, par.Path)
-		parOutput, err := ssmClient.GetParameter(&ssm.GetParameterInput{Name: &par.Path})
+// credentialStores returns a function creating the stores of the parameters got with their
+// own credentials, once for each role and profile
+func credentialStores() func(par config.Parameter) (store.Store, error) {
+	stores := map[string]store.Store{}
+	return func(par config.Parameter) (store.Store, error) {
+		credentials := par.Credentials()
+		if s, ok := stores[credentials]; ok {
+			return s, nil
+		}
+		if name, _ := splitBackend(backend); name != "ssm" {
+			return nil, fmt.Errorf("role_arn and profile can only be used with the `ssm` backend")
+		}
+		s, err := newStoreWith(newSessionAs(par.Profile, par.RoleARN), credentials)
 		if err != nil {
-			return eb, err
+			return nil, err
 		}
-		eb.Options = append(eb.Options, ebOption{Name: par.Name, Value: *parOutput.Parameter.Value})
-		fmt.Fprintln(os.Stderr, "OK")
+		stores[credentials] = s
+		return s, nil
+	}
+}
+
+// newStoreWith is like newStore, with the AWS stores using the session. The credentials,
+// if not empty, keep the cached values apart from the ones got with other credentials.
+func newStoreWith(session *session.Session, credentials string) (store.Store, error) {
+	router, err := stores.New(session, backend, environment)
+	if err != nil {
+		return nil, err
+	}
+	if cacheTTL <= 0 {
+		return router, nil
 	}
 
-	return eb, nil
+	key, err := cacheKey()
+	if err != nil {
+		return nil, fmt.Errorf("Error reading cache key: %v", err)
+	}
+	// the same paths hold other values in other accounts, and aren't readable by everyone
+	identity, err := sts.New(session).GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: the values aren't cached, the AWS credentials couldn't be identified: %v\n", err)
+		return router, nil
+	}
+	namespace := backend + "\x00" + aws.StringValue(session.Config.Region) + "\x00" + cacheIdentity(aws.StringValue(identity.Arn))
+	if credentials != "" {
+		namespace += "\x00" + credentials
+	}
+	cached, err := cachestore.New(router, cacheDir, namespace, cacheTTL, key)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening cache `%s`: %v", cacheDir, err)
+	}
+	return cached, nil
 }
 
-// setBeanstalkOptions sends parameters into SSM using a client created from the provided session
-func setBeanstalkOptions(session *session.Session, parameters parameters) error {
-	ssmClient := ssm.New(session)
+// cacheIdentity returns the principal of the ARN of the caller identity the cached values
+// are kept apart by. The session name of assumed roles is left out, as it usually changes
+// on every run.
+func cacheIdentity(arn string) string {
+	if parts := strings.Split(arn, "/"); len(parts) == 3 && strings.Contains(parts[0], ":assumed-role") {
+		return parts[0] + "/" + parts[1]
+	}
+	return arn
+}
 
-	for _, par := range parameters.Component {
+// newSnapshotStore creates a store serving the values recorded in the snapshot flag
+func newSnapshotStore() (store.Store, error) {
+	if snapshotIn == "" {
+		return nil, fmt.Errorf("Missing mandatory argument in offline mode: `snapshot`")
+	}
+	recorded, err := readSnapshot(snapshotIn)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading file `%s`: %v", snapshotIn, err)
+	}
+	if recorded.Environment != environment {
+		fmt.Fprintf(os.Stderr, "Warning: `%s` was recorded for environment `%s`, not `%s`\n", snapshotIn, recorded.Environment, environment)
+	}
+	return recorded.NewStore(), nil
+}
 
-		value := par.Value
-		if value == "" {
-			reader := bufio.NewReader(os.Stdin)
-			fmt.Printf("* Input value for `%s`: ", par.Path)
+// readSnapshot reads the snapshot in filename, decrypting it with KMS if it's encrypted
+func readSnapshot(filename string) (snapshot.Snapshot, error) {
+	return snapshot.ReadFile(filename, kms.New(newSession()))
+}
 
-			text, err := reader.ReadString('\n')
-			if err != nil {
-				return err
-			}
-			value = strings.Replace(text, "\n", "", -1)
-		} else {
-			fmt.Printf("* Setting value for `%s`...\n", par.Path)
-		}
+// defaultCacheDir returns the directory used by default to cache values
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "ssmeb")
+}
 
-		overwrite := true
-		parType := "String"
-		ssmPar := ssm.PutParameterInput{
-			Name:        &par.Path,
-			Description: &par.Description,
-			Value:       &value,
-			Overwrite:   &overwrite,
-			Type:        &parType,
-		}
-		fmt.Println(ssmPar)
-		putOutput, err := ssmClient.PutParameter(&ssmPar)
+// cacheKey returns the key encrypting the cache, which is read from SSMEB_CACHE_KEY as
+// hexadecimal or from a file in the user config directory, created on first use
+func cacheKey() ([]byte, error) {
+	if key := os.Getenv("SSMEB_CACHE_KEY"); key != "" {
+		return hex.DecodeString(key)
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return cachestore.LoadOrCreateKey(filepath.Join(dir, "ssmeb", "cache.key"))
+}
+
+// splitBackend splits a backend flag like `name:argument` into its parts
+func splitBackend(backend string) (string, string) {
+	return stores.SplitBackend(backend)
+}
+
+// printSettings prints the given name/value pairs to stderr, so the user can check
+// which configuration is being used without polluting the output
+func printSettings(settings ...string) {
+	fmt.Fprintln(os.Stderr, "-----------------------------------------")
+	for i := 0; i+1 < len(settings); i += 2 {
+		fmt.Fprintf(os.Stderr, "%-13s %s\n", settings[i]+":", settings[i+1])
+	}
+	fmt.Fprintln(os.Stderr, "-----------------------------------------")
+}
+
+// getEnv returns the value of the environment variable named by key, or
+// fallback if it is unset or empty. It is used to provide defaults for flags.
+func getEnv(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvDuration is like getEnv, for flags holding a duration. Invalid values are
+// reported and replaced by fallback.
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Ignoring invalid duration in %s: %v", key, err)
+		return fallback
+	}
+	return duration
+}
+
+// stdinReader reads the answers to the prompts, shared so the lines buffered by one
+// prompt aren't lost to the next one when they're piped
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// promptValue asks the user for the value of the parameter stored in path. Since only
+// a line can be typed, answering `@file` uses the contents of the file instead, as is,
+// so multiline values can be set, while values starting with `@` are typed with another
+// one in front, like `@@value`. When the parameter is already stored, its value is shown,
+// masked for secrets, and an empty answer keeps it.
+func promptValue(path string, current store.Parameter, found bool) (string, error) {
+	if found {
+		fmt.Printf("* Input value for `%s` (@file to read it, @@ for a leading @, empty keeps %s): ", path, maskedValue(current))
+	} else {
+		fmt.Printf("* Input value for `%s` (@file to read it, @@ for a leading @): ", path)
+	}
+
+	text, err := stdinReader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	text = strings.TrimSuffix(strings.TrimSuffix(text, "\n"), "\r")
+	if text == "" && found {
+		return current.Value, nil
+	}
+	if strings.HasPrefix(text, "@@") {
+		return text[1:], nil
+	}
+	if filename := strings.TrimPrefix(text, "@"); filename != text {
+		data, err := ioutil.ReadFile(filename)
 		if err != nil {
-			return err
+			return "", fmt.Errorf("Error reading file `%s`, type @@ for a value starting with @: %v", filename, err)
 		}
-		fmt.Println(putOutput)
+		fmt.Printf("  Read %d bytes from `%s`\n", len(data), filename)
+		return string(data), nil
 	}
+	return text, nil
+}
+
+// maskedValue returns the stored value as shown in prompts, masked for secrets and
+// quoted otherwise so blanks and line breaks can be told apart
+func maskedValue(par store.Parameter) string {
+	if par.Secret {
+		return "********"
+	}
+	return strconv.Quote(par.Value)
+}
+
+// outputModeUsage is the usage of the output-mode flag of the commands writing files
+const outputModeUsage = "permissions of the output file in octal, e.g. 0640 (defaults to 0600 if any value is a secret, 0644 otherwise)"
+
+// outputFileMode returns the permissions of the output file, which are given in
+// the output-mode flag or, by default, only allow the owner to read secret values
+func outputFileMode(options []render.Option) (os.FileMode, error) {
+	if outputMode == "" {
+		if render.HasSecrets(options) {
+			return 0600, nil
+		}
+		return 0644, nil
+	}
+	mode, err := strconv.ParseUint(outputMode, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("Invalid output mode `%s`, expected octal permissions like 0600", outputMode)
+	}
+	return os.FileMode(mode), nil
+}
+
+// writeToS3 saves the data to the object in the s3://bucket/key url, encrypted with the
+// KMS key if one is given. The object's permissions are left to the bucket policy.
+func writeToS3(url string, data []byte, keyID string) error {
+	err := s3io.WriteWithKey(s3.New(newSession()), url, data, keyID)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d bytes written successfully to `%s`\n", len(data), url)
 	return nil
 }
 
-// writeToFile saves the data to a file whose name is given in output
-func writeToFile(output string, data []byte) error {
-	outFile, err := os.Create(output)
+// writeToFile saves the data to a file whose name is given in output, with the given
+// permissions. The data is written to a temporary file in the same directory which is
+// then renamed, so the output is never left truncated if something fails midway.
+func writeToFile(output string, data []byte, mode os.FileMode) error {
+	outFile, err := ioutil.TempFile(filepath.Dir(output), "."+filepath.Base(output)+".tmp")
 	if err != nil {
 		return err
 	}
+	// no-op once renamed, cleans up after a failure otherwise
+	defer os.Remove(outFile.Name())
+
+	err = outFile.Chmod(mode)
+	if err != nil {
+		outFile.Close()
+		return err
+	}
 	bytesOut, err := outFile.Write(data)
 	if err != nil {
+		outFile.Close()
+		return err
+	}
+	err = outFile.Sync()
+	if err != nil {
+		outFile.Close()
 		return err
 	}
-	fmt.Fprintf(os.Stderr, "%d bytes written successfully to `%s`\n", bytesOut, output)
 	err = outFile.Close()
 	if err != nil {
 		return err
 	}
+	err = os.Rename(outFile.Name(), output)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d bytes written successfully to `%s`\n", bytesOut, output)
 	return nil
 }
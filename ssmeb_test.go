@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codacy/ssmeb/pkg/store"
+)
+
+func TestPromptValue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssmeb-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certificate := filepath.Join(dir, "cert.pem")
+	if err := ioutil.WriteFile(certificate, []byte("-----BEGIN CERTIFICATE-----\nMII\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	current := store.Parameter{Value: "old", Secret: true}
+
+	tests := []struct {
+		name   string
+		answer string
+		found  bool
+		want   string
+		err    bool
+	}{
+		{"typed", "s3cret\n", false, "s3cret", false},
+		{"typed with CRLF", "s3cret\r\n", true, "s3cret", false},
+		{"file", "@" + certificate + "\n", false, "-----BEGIN CERTIFICATE-----\nMII\n", false},
+		{"escaped at", "@@bc123\n", false, "@bc123", false},
+		{"missing file", "@bc123\n", false, "", true},
+		{"empty keeps the stored value", "\n", true, "old", false},
+		{"empty without a stored value", "\n", false, "", false},
+		{"no answer", "", false, "", true},
+	}
+	defer func(reader *bufio.Reader) { stdinReader = reader }(stdinReader)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stdinReader = bufio.NewReader(strings.NewReader(test.answer))
+			got, err := promptValue("/staging/db/password", current, test.found)
+			if (err != nil) != test.err || got != test.want {
+				t.Errorf("promptValue() = %q, %v, want %q (error: %v)", got, err, test.want, test.err)
+			}
+		})
+	}
+}
+
+func TestCacheIdentity(t *testing.T) {
+	tests := []struct{ arn, want string }{
+		{"arn:aws:iam::123456789012:user/alice", "arn:aws:iam::123456789012:user/alice"},
+		{"arn:aws:sts::123456789012:assumed-role/deployer/aws-go-sdk-1700000000", "arn:aws:sts::123456789012:assumed-role/deployer"},
+		{"arn:aws:sts::210987654321:assumed-role/deployer/alice", "arn:aws:sts::210987654321:assumed-role/deployer"},
+		{"arn:aws:iam::123456789012:root", "arn:aws:iam::123456789012:root"},
+	}
+	for _, test := range tests {
+		if got := cacheIdentity(test.arn); got != test.want {
+			t.Errorf("cacheIdentity(%s) = %s, want %s", test.arn, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/codacy/ssmeb/pkg/render"
+)
+
+// defaultSumFile is the file recording the digest of the values got, by default
+const defaultSumFile = ".ssmeb.sum"
+
+// valuesDigest returns a digest of the names and values of the options, in any order,
+// which tells whether they changed without revealing them
+func valuesDigest(options []render.Option) string {
+	sorted := append([]render.Option{}, options...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	hash := sha256.New()
+	for _, option := range sorted {
+		fmt.Fprintf(hash, "%s\x00%s\x00", option.Name, option.Value)
+	}
+	return fmt.Sprintf("sha256:%x", hash.Sum(nil))
+}
+
+// writeSum records the digest of the options in the file with name filename
+func writeSum(filename string, options []render.Option) error {
+	var data bytes.Buffer
+	fmt.Fprintf(&data, "# Digest of the values got by ssmeb, not the values themselves\n")
+	fmt.Fprintf(&data, "# environment: %s\n", environment)
+	fmt.Fprintln(&data, valuesDigest(options))
+	if err := ioutil.WriteFile(filename, data.Bytes(), 0644); err != nil {
+		return fmt.Errorf("Error writing to file `%s`: %v", filename, err)
+	}
+	fmt.Fprintf(os.Stderr, "Digest of the values written to `%s`\n", filename)
+	return nil
+}
+
+// checkSum fails if the digest of the options differs from the one recorded in the
+// file with name filename
+func checkSum(filename string, options []render.Option) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("Error reading file `%s`: %v", filename, err)
+	}
+	var recorded, recordedEnvironment string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "# environment:") {
+			recordedEnvironment = strings.TrimSpace(strings.TrimPrefix(line, "# environment:"))
+		} else if line != "" && !strings.HasPrefix(line, "#") && recorded == "" {
+			recorded = line
+		}
+	}
+	if recorded == "" {
+		return fmt.Errorf("No digest found in `%s`", filename)
+	}
+	if recordedEnvironment != environment {
+		return fmt.Errorf("`%s` was recorded for environment `%s`, not `%s`", filename, recordedEnvironment, environment)
+	}
+	if valuesDigest(options) != recorded {
+		return fmt.Errorf("The values differ from the ones recorded in `%s`", filename)
+	}
+	fmt.Fprintf(os.Stderr, "The values match the ones recorded in `%s`\n", filename)
+	return nil
+}
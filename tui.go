@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+var tuiShowValues bool
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Browse the component parameters with their values in the store and edit them",
+	Long: `Browse the component parameters with their values in the store and edit them.
+
+The component parameters are listed with their live values and whether they
+match the values in the input. Picking one by its number asks for a new value,
+which is written to the store after confirming it, a safer alternative to the
+AWS console for routine tweaks. The values of SecureString parameters are
+masked unless --show-values is given.`,
+	Example: `  ssmeb tui -i params.yaml -e production`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("input", inputName(), "environment", environment)
+		parameters, err := loadParameters()
+		if err != nil {
+			return err
+		}
+		parameters, err = expandPlaceholders(parameters)
+		if err != nil {
+			return err
+		}
+		s, err := newStore()
+		if err != nil {
+			return err
+		}
+		b := &browser{
+			prompter:   prompter{reader: bufio.NewReader(os.Stdin), out: os.Stderr},
+			store:      s,
+			showValues: tuiShowValues,
+		}
+		return b.run(parameters.Component)
+	},
+}
+
+func init() {
+	tuiCmd.Flags().BoolVar(&tuiShowValues, "show-values", false, "show the values of SecureString parameters")
+	rootCmd.AddCommand(tuiCmd)
+}
+
+// browser lists parameters with their values in a store and edits them
+type browser struct {
+	prompter
+	store      store.Store
+	showValues bool
+}
+
+// run lists the parameters and edits the ones picked until the user quits
+func (b *browser) run(parameters []config.Parameter) error {
+	var editable []config.Parameter
+	for _, par := range parameters {
+		if !par.IsWildcard() {
+			editable = append(editable, par)
+		}
+	}
+	if len(editable) == 0 {
+		return fmt.Errorf("No component parameters to browse")
+	}
+
+	for {
+		if err := b.list(editable); err != nil {
+			return err
+		}
+		answer, err := b.ask("Number of the parameter to edit, r to refresh or q to quit", "r")
+		if err == io.EOF || answer == "q" {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if answer == "r" {
+			continue
+		}
+		number, err := strconv.Atoi(answer)
+		if err != nil || number < 1 || number > len(editable) {
+			fmt.Fprintf(b.out, "Invalid choice `%s`\n", answer)
+			continue
+		}
+		if err := b.edit(editable[number-1]); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// list prints a numbered table of the parameters with their values in the store and
+// whether they match the input
+func (b *browser) list(parameters []config.Parameter) error {
+	w := tabwriter.NewWriter(b.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "\n#\tOPTION\tPATH\tSTATUS\tVALUE")
+	for i, par := range parameters {
+		stored, found, err := store.Lookup(b.store, par.Path)
+		if err != nil {
+			return fmt.Errorf("Error getting `%s`: %v", par.Path, err)
+		}
+		status, value := "ok", b.display(par.Type == config.TypeSecureString || stored.Secret, stored.Value)
+		switch {
+		case !found:
+			status, value = "missing", ""
+		case par.Value == "":
+			status = "not in input"
+		case par.Value != stored.Value:
+			status = "differs from input"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i+1, par.Name, par.Path, status, value)
+	}
+	return w.Flush()
+}
+
+// edit asks for a new value of the parameter and writes it to the store once confirmed
+func (b *browser) edit(par config.Parameter) error {
+	stored, found, err := store.Lookup(b.store, par.Path)
+	if err != nil {
+		return fmt.Errorf("Error getting `%s`: %v", par.Path, err)
+	}
+	value, err := b.ask(fmt.Sprintf("New value of `%s` (blank to cancel)", par.Name), "")
+	if err != nil || value == "" {
+		return err
+	}
+	value, err = checkEncoding(par.Path, value)
+	if err != nil {
+		fmt.Fprintln(b.out, err)
+		return nil
+	}
+	if found && value == stored.Value {
+		fmt.Fprintln(b.out, "The value is unchanged")
+		return nil
+	}
+	// SecureStrings stay encrypted even if the input doesn't declare their type
+	secret := par.Type == config.TypeSecureString || found && stored.Secret
+	if found {
+		fmt.Fprintf(b.out, "    - %s\n", b.display(secret, stored.Value))
+	}
+	fmt.Fprintf(b.out, "    + %s\n", b.display(secret, value))
+	confirm, err := b.ask(fmt.Sprintf("Write `%s`? (y/N)", par.Path), "")
+	if err != nil || !strings.EqualFold(confirm, "y") {
+		fmt.Fprintln(b.out, "Cancelled")
+		return nil
+	}
+
+	version, err := b.store.Put(store.Parameter{Path: par.Path, Value: value, Description: par.Description, Secret: secret})
+	if err != nil {
+		return fmt.Errorf("Error setting `%s`: %v", par.Path, err)
+	}
+	fmt.Fprintf(b.out, "OK (version %d)\n", version)
+	if par.Value != "" && par.Value != value {
+		fmt.Fprintf(b.out, "Note: the input holds another value for `%s`, which `ssmeb set` would write back\n", par.Name)
+	}
+	return nil
+}
+
+// display returns the value as shown to the user, masked for secrets unless they're shown
+func (b *browser) display(secret bool, value string) string {
+	if secret && !b.showValues {
+		return "********"
+	}
+	return value
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/codacy/ssmeb/pkg/config"
+	"github.com/codacy/ssmeb/pkg/ssmstore"
+	"github.com/codacy/ssmeb/pkg/ssmstore/ssmfake"
+)
+
+func TestBrowserKeepsSecureString(t *testing.T) {
+	client := ssmfake.New()
+	client.PutParameter(&ssm.PutParameterInput{
+		Name:  aws.String("/staging/db/password"),
+		Value: aws.String("old-secret"),
+		Type:  aws.String(ssm.ParameterTypeSecureString),
+	})
+	var out bytes.Buffer
+	b := &browser{
+		prompter: prompter{reader: bufio.NewReader(strings.NewReader("new-secret\ny\n")), out: &out},
+		store:    ssmstore.New(client),
+	}
+	par := config.Parameter{Name: "DB_PASSWORD", Path: "/staging/db/password"}
+
+	if err := b.list([]config.Parameter{par}); err != nil {
+		t.Fatalf("list() error = %v", err)
+	}
+	if err := b.edit(par); err != nil {
+		t.Fatalf("edit() error = %v", err)
+	}
+	if strings.Contains(out.String(), "secret") {
+		t.Errorf("the output shows the SecureString value:\n%s", out.String())
+	}
+	got, err := client.GetParameter(&ssm.GetParameterInput{Name: aws.String("/staging/db/password")})
+	if err != nil || aws.StringValue(got.Parameter.Type) != ssm.ParameterTypeSecureString {
+		t.Errorf("/staging/db/password = %v (%v), want a SecureString", got, err)
+	}
+	if value, _ := client.Value("/staging/db/password"); value != "new-secret" {
+		t.Errorf("value = %q, want %q", value, "new-secret")
+	}
+}
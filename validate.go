@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check that the input file is well formed, without contacting AWS",
+	Long: `Check that the input file is well formed, without contacting AWS.
+
+The whole file is checked as written, including the overrides of every
+environment, regardless of the environment, only and except flags. When an
+environment is given, the paths already starting with it are reported too, as
+they'd be prefixed with it twice.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		parameters, err := readParameters()
+		if err != nil {
+			return err
+		}
+
+		problems := append(parameters.Validate(), parameters.ValidateEnvironment(environment)...)
+		for _, problem := range problems {
+			fmt.Println("*", problem)
+		}
+		if len(problems) > 0 {
+			return fmt.Errorf("`%s` has %d problem(s)", inputName(), len(problems))
+		}
+		fmt.Fprintf(os.Stderr, "`%s` is valid\n", inputName())
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
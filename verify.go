@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/codacy/ssmeb/pkg/render"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyAgainst string
+	verifyFormat  string
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check that a committed output file matches the values currently in the store",
+	Long: `Check that a committed output file matches the values currently in the store.
+
+The output is generated in memory, as get would, and compared with the file,
+ignoring the header added by --header. Exits with an error if they differ,
+which catches both stale files and changes made to the store out of band.`,
+	Example: `  ssmeb verify -i params.yaml -e production --against .ebextensions/env_variables.config`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		printSettings("input", inputName(), "against", verifyAgainst, "environment", environment)
+		return runVerify(verifyAgainst, verifyFormat)
+	},
+}
+
+func init() {
+	verifyCmd.Flags().StringVar(&verifyAgainst, "against", "", "committed output file to check (required)")
+	verifyCmd.Flags().StringVar(&verifyFormat, "format", "ebyaml", "format of the file")
+	verifyCmd.Flags().BoolVar(&sortOutput, "sort", false, "the options in the file are sorted by name")
+	verifyCmd.Flags().BoolVar(&getComments, "descriptions", false, "the elastic beanstalk file has the descriptions of the parameters as comments")
+	rootCmd.AddCommand(verifyCmd)
+}
+
+// runVerify fails if the file against differs from the output generated from the store
+func runVerify(against string, format string) error {
+	if against == "" {
+		return fmt.Errorf("Missing mandatory argument: `against`")
+	}
+	if !render.HasFormat(format) {
+		return fmt.Errorf("Invalid output format `%s`, expected one of %v", format, render.Formats())
+	}
+	committed, err := ioutil.ReadFile(against)
+	if err != nil {
+		return fmt.Errorf("Error reading file `%s`: %v", against, err)
+	}
+
+	options, err := resolveOptions()
+	if err != nil {
+		return err
+	}
+	generated, err := renderFormat(format, options)
+	if err != nil {
+		return err
+	}
+
+	committedLines := bytes.Split(bytes.TrimSpace(stripHeader(committed)), []byte("\n"))
+	generatedLines := bytes.Split(bytes.TrimSpace(generated), []byte("\n"))
+	for i := 0; i < len(committedLines) || i < len(generatedLines); i++ {
+		if i >= len(committedLines) || i >= len(generatedLines) || !bytes.Equal(committedLines[i], generatedLines[i]) {
+			// the lines aren't printed, as they may hold secrets
+			return fmt.Errorf("`%s` differs from the values in the store, starting at line %d of its contents", against, i+1)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "`%s` is up to date\n", against)
+	return nil
+}
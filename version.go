@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/spf13/cobra"
+)
+
+// Build metadata, injected at link time with:
+//
+//	go build -ldflags "-X main.version=1.2.3 -X main.commit=$(git rev-parse HEAD) -X main.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version, git commit and build date of this binary",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(versionString())
+	},
+}
+
+func init() {
+	rootCmd.Version = versionString()
+	rootCmd.SetVersionTemplate("{{.Version}}\n")
+	rootCmd.AddCommand(versionCmd)
+}
+
+// versionString describes this binary's build metadata in a single line
+func versionString() string {
+	return fmt.Sprintf("ssmeb %s (commit %s, built %s, %s)", version, commit, date, runtime.Version())
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+)
+
+// webIdentityRoleARN is the role assumed with the web identity token, e.g. the one of the
+// service account of an EKS pod
+var webIdentityRoleARN string
+
+// webIdentityProviderName identifies the credentials got with a web identity token
+const webIdentityProviderName = "WebIdentityCredentials"
+
+// webIdentityProvider gets the credentials of a role assumed with the web identity token
+// in a file, like the one mounted by EKS for IAM roles for service accounts (IRSA)
+type webIdentityProvider struct {
+	credentials.Expiry
+	client      stsiface.STSAPI
+	roleARN     string
+	tokenFile   string
+	sessionName string
+}
+
+// configureWebIdentity makes the sessions created with the options assume a role with
+// the web identity token in AWS_WEB_IDENTITY_TOKEN_FILE, unless they use a profile. It's
+// done when the web-identity-role-arn flag is set or, like newer SDKs do, when
+// AWS_ROLE_ARN is set along with the token file and there are no static credentials.
+func configureWebIdentity(options *session.Options) {
+	if options.Profile != "" {
+		return
+	}
+	roleARN := webIdentityRoleARN
+	tokenFile := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+	if roleARN == "" {
+		if tokenFile == "" || os.Getenv("AWS_ACCESS_KEY_ID") != "" {
+			return
+		}
+		roleARN = os.Getenv("AWS_ROLE_ARN")
+		if roleARN == "" {
+			return
+		}
+	}
+	sessionName := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if sessionName == "" {
+		sessionName = "ssmeb-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+
+	// the role is assumed with the token alone, without signing the call
+	anonymous := *options
+	anonymous.Config.Credentials = credentials.AnonymousCredentials
+	client := sts.New(session.Must(session.NewSessionWithOptions(anonymous)))
+	options.Config.Credentials = credentials.NewCredentials(&webIdentityProvider{
+		client:      client,
+		roleARN:     roleARN,
+		tokenFile:   tokenFile,
+		sessionName: sessionName,
+	})
+}
+
+// Retrieve assumes the role with the token, which is read every time since it's rotated
+func (p *webIdentityProvider) Retrieve() (credentials.Value, error) {
+	if p.tokenFile == "" {
+		return credentials.Value{ProviderName: webIdentityProviderName}, fmt.Errorf("AWS_WEB_IDENTITY_TOKEN_FILE must be set to assume `%s` with a web identity", p.roleARN)
+	}
+	token, err := ioutil.ReadFile(p.tokenFile)
+	if err != nil {
+		return credentials.Value{ProviderName: webIdentityProviderName}, fmt.Errorf("Error reading the web identity token: %v", err)
+	}
+	output, err := p.client.AssumeRoleWithWebIdentity(&sts.AssumeRoleWithWebIdentityInput{
+		RoleArn:          aws.String(p.roleARN),
+		RoleSessionName:  aws.String(p.sessionName),
+		WebIdentityToken: aws.String(strings.TrimSpace(string(token))),
+	})
+	if err != nil {
+		return credentials.Value{ProviderName: webIdentityProviderName}, fmt.Errorf("Error assuming `%s` with the web identity token: %v", p.roleARN, err)
+	}
+
+	// renewed a bit early, so they don't expire in the middle of a call
+	p.SetExpiration(aws.TimeValue(output.Credentials.Expiration), time.Minute)
+	return credentials.Value{
+		AccessKeyID:     aws.StringValue(output.Credentials.AccessKeyId),
+		SecretAccessKey: aws.StringValue(output.Credentials.SecretAccessKey),
+		SessionToken:    aws.StringValue(output.Credentials.SessionToken),
+		ProviderName:    webIdentityProviderName,
+	}, nil
+}